@@ -8,6 +8,7 @@ import (
 	"github.com/mandelsoft/goutils/errors"
 	"github.com/mandelsoft/goutils/maputils"
 
+	"github.com/mandelsoft/ctxmgmt/utils/jsonschema"
 	"github.com/mandelsoft/ctxmgmt/utils/runtime"
 )
 
@@ -30,6 +31,13 @@ type ActionTypeRegistry interface {
 	GetAction(name string) Action
 	SupportedActionVersions(name string) []string
 
+	// ActionSpecSchemas provides JSON schemas for the
+	// serialized forms of the registered action specifications.
+	ActionSpecSchemas() map[string]*jsonschema.Schema
+	// ActionResultSchemas provides JSON schemas for the
+	// serialized forms of the registered action results.
+	ActionResultSchemas() map[string]*jsonschema.Schema
+
 	Copy() ActionTypeRegistry
 }
 
@@ -191,6 +199,14 @@ func (r *actionRegistry) EncodeActionResult(spec ActionResult, marshaler runtime
 	return r.resultspecs.Encode(spec, marshaler)
 }
 
+func (r *actionRegistry) ActionSpecSchemas() map[string]*jsonschema.Schema {
+	return r.actionspecs.Schemas()
+}
+
+func (r *actionRegistry) ActionResultSchemas() map[string]*jsonschema.Schema {
+	return r.resultspecs.Schemas()
+}
+
 func (r *actionRegistry) SupportedActionVersions(name string) []string {
 	r.lock.Lock()
 	defer r.lock.Unlock()
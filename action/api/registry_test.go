@@ -74,6 +74,21 @@ var _ = Describe("action registry", func() {
 			d := Must(registry.DecodeActionResult(data, runtime.DefaultJSONEncoding))
 			Expect(d).To(Equal(spec))
 		})
+
+		It("provides schemas for the serialized formats", func() {
+			schemas := registry.ActionSpecSchemas()
+			Expect(schemas).To(HaveKey("testAction/v1"))
+			Expect(schemas).To(HaveKey("testAction/v2"))
+			Expect(schemas["testAction/v1"].Properties).To(HaveKey("field"))
+			Expect(schemas["testAction/v2"].Properties).To(HaveKey("data"))
+			Expect(schemas["testAction/v2"].Properties).NotTo(HaveKey("field"))
+
+			MustBeSuccessful(schemas["testAction/v2"].Validate([]byte(`{"type":"testAction/v2","data":"acme.com"}`)))
+			Expect(schemas["testAction/v2"].Validate([]byte(`{"type":"testAction/v1","field":"acme.com"}`))).To(MatchError(
+				`schema validation failed: field: unknown property, type: value testAction/v1 not in [testAction/v2]`))
+
+			Expect(registry.ActionResultSchemas()["testAction/v1"].Properties).To(HaveKey("message"))
+		})
 	})
 
 	Context("data context", func() {
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 
 	"github.com/mandelsoft/goutils/errors"
+	"github.com/mandelsoft/goutils/maputils"
 
 	"github.com/mandelsoft/ctxmgmt"
 	cfgcpi "github.com/mandelsoft/ctxmgmt/config/cpi"
@@ -55,6 +56,16 @@ func (a *Config) AddRawAttribute(attr string, data []byte) error {
 	return err
 }
 
+// Validate validates the attribute values against the schemas
+// declared by the attribute types.
+func (a *Config) Validate() error {
+	list := errors.ErrListf("attribute config")
+	for _, n := range maputils.OrderedKeys(a.Attributes) {
+		list.Add(errors.Wrapf(ctxmgmt.ValidateAttribute(ctxmgmt.DefaultAttributeScheme, n, a.Attributes[n]), "attribute %q", n))
+	}
+	return list.Result()
+}
+
 func (a *Config) ApplyTo(ctx cfgcpi.Context, target interface{}) error {
 	list := errors.ErrListf("applying config")
 	t, ok := target.(cfgcpi.Context)
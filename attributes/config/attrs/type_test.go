@@ -0,0 +1,68 @@
+package attrs_test
+
+import (
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	logcfg "github.com/mandelsoft/logging/config"
+
+	"github.com/mandelsoft/ctxmgmt"
+	"github.com/mandelsoft/ctxmgmt/attributes/config/attrs"
+	"github.com/mandelsoft/ctxmgmt/attrs/logforward"
+	"github.com/mandelsoft/ctxmgmt/attrs/tmpcache"
+	"github.com/mandelsoft/ctxmgmt/config"
+	"github.com/mandelsoft/ctxmgmt/utils/runtime"
+)
+
+var _ = Describe("attribute config", func() {
+	It("applies valid attributes", func() {
+		ctx := config.New()
+		cfg := attrs.New()
+		MustBeSuccessful(cfg.AddRawAttribute(logforward.ATTR_SHORT, []byte(`{"defaultLevel": "Debug"}`)))
+		MustBeSuccessful(ctx.ApplyConfig(cfg, "attrs"))
+		Expect(logforward.Get(ctx).DefaultLevel).To(Equal("Debug"))
+	})
+
+	It("decodes yaml logging configs", func() {
+		v := Must(ctxmgmt.DefaultAttributeScheme.Decode(logforward.ATTR_KEY, []byte(`
+defaultLevel: Debug
+`), runtime.DefaultJSONEncoding))
+		Expect(v).To(Equal(&logcfg.Config{DefaultLevel: "Debug"}))
+	})
+
+	It("rejects raw attributes violating the schema", func() {
+		cfg := attrs.New()
+		Expect(cfg.AddRawAttribute(tmpcache.ATTR_KEY, []byte(`{"path": "/tmp"}`))).To(MatchError(
+			`invalid value for attribute "` + tmpcache.ATTR_KEY + `": schema validation failed: expected string, but found object`))
+	})
+
+	It("validates config", func() {
+		cfg := attrs.New()
+		cfg.Attributes[logforward.ATTR_SHORT] = []byte(`{"defaultLevel": 1, "level": "Debug"}`)
+		Expect(cfg.Validate()).To(MatchError(`attribute config: attribute "` + logforward.ATTR_SHORT + `": invalid value for attribute "` +
+			logforward.ATTR_KEY + `": schema validation failed: defaultLevel: expected string, but found number, level: unknown property`))
+	})
+
+	It("enforces schema when applied", func() {
+		ctx := config.New()
+		cfg := attrs.New()
+		cfg.Attributes[tmpcache.ATTR_SHORT] = []byte(`[]`)
+		Expect(ctx.ApplyConfig(cfg, "attrs")).To(HaveOccurred())
+		Expect(ctx.GetAttributes().GetAttribute(tmpcache.ATTR_KEY)).To(BeNil())
+	})
+	It("resets retracted attributes", func() {
+		ctx := config.New()
+		cfg1 := attrs.New()
+		MustBeSuccessful(cfg1.AddRawAttribute(logforward.ATTR_SHORT, []byte(`{"defaultLevel": "Debug"}`)))
+		cfg2 := attrs.New()
+		MustBeSuccessful(cfg2.AddRawAttribute(tmpcache.ATTR_SHORT, []byte(`"/tmp/cache"`)))
+		h := Must(ctx.ApplyConfigWithHandle(cfg1, "first"))
+		MustBeSuccessful(ctx.ApplyConfig(cfg2, "second"))
+		Expect(logforward.Get(ctx)).NotTo(BeNil())
+
+		ctx.Retract(config.ByHandle(h))
+		Expect(logforward.Get(ctx)).To(BeNil())
+		Expect(ctx.GetAttributes().GetAttribute(tmpcache.ATTR_KEY)).NotTo(BeNil())
+	})
+})
@@ -0,0 +1,34 @@
+package attrs
+
+import (
+	"github.com/mandelsoft/goutils/maputils"
+
+	"github.com/mandelsoft/ctxmgmt"
+	cfgcpi "github.com/mandelsoft/ctxmgmt/config/cpi"
+	"github.com/mandelsoft/ctxmgmt/config/validation"
+)
+
+func init() {
+	validation.RegisterValidator(ConfigType, validation.ValidatorFunction(validate))
+}
+
+// validate checks the attribute names against the known
+// attribute types and their shortcuts.
+func validate(ctx cfgcpi.ContextProvider, cfg cfgcpi.Config) []validation.Finding {
+	c, ok := cfg.(*Config)
+	if !ok {
+		return nil
+	}
+	scheme := ctxmgmt.DefaultAttributeScheme
+	names := scheme.KnownTypeNames()
+	shortcuts := scheme.Shortcuts()
+	names = append(names, maputils.OrderedKeys(shortcuts)...)
+
+	var findings []validation.Finding
+	for _, n := range maputils.OrderedKeys(c.Attributes) {
+		if _, err := scheme.GetType(n); err != nil && shortcuts[n] == "" {
+			findings = append(findings, validation.Unknown(validation.ERROR, "attributes."+n, "attribute", n, names))
+		}
+	}
+	return findings
+}
@@ -2,6 +2,7 @@ package logging_test
 
 import (
 	"bytes"
+	"reflect"
 
 	"github.com/mandelsoft/ctxmgmt/attributes"
 	. "github.com/mandelsoft/ctxmgmt/logging/testhelper"
@@ -13,8 +14,10 @@ import (
 	"github.com/mandelsoft/logging"
 	"github.com/tonglil/buflogr"
 
+	"github.com/mandelsoft/ctxmgmt"
 	logcfg "github.com/mandelsoft/ctxmgmt/attributes/config/logging"
 	"github.com/mandelsoft/ctxmgmt/config"
+	"github.com/mandelsoft/ctxmgmt/config/cpi"
 	ctxlog "github.com/mandelsoft/ctxmgmt/logging"
 )
 
@@ -239,3 +242,31 @@ ERROR <nil> error realm ${REALM}
 		})
 	})
 })
+
+var _ = Describe("context type validation", func() {
+	It("accepts registered and pseudo context types", func() {
+		Expect(logcfg.New(config.CONTEXT_TYPE, logging.DebugLevel).Validate()).To(Succeed())
+		Expect(logcfg.New(logcfg.CONTEXT_GLOBAL, logging.DebugLevel).Validate()).To(Succeed())
+		Expect(logcfg.New("", logging.DebugLevel).Validate()).To(Succeed())
+	})
+
+	It("rejects unknown context types", func() {
+		Expect(logcfg.New("unknown", logging.DebugLevel).Validate()).To(MatchError(`context type "unknown" is unknown`))
+	})
+
+	It("configures contexts of unregistered context types", func() {
+		ctx := config.New()
+		Expect(ctx.ApplyConfig(logcfg.New("acme.test", logging.DebugLevel), "logging")).To(Succeed())
+
+		c := &thirdPartyContext{}
+		c.InternalContext = ctxmgmt.NewContextBase(c, "acme.test", reflect.TypeOf(thirdPartyContext{}), nil, ctxmgmt.ComposeDelegates(logging.NewDefault(), nil))
+		Expect(logcfg.New("acme.test", logging.TraceLevel).ApplyTo(ctx, c)).To(Succeed())
+		Expect(c.LoggingContext().GetDefaultLevel()).To(Equal(logging.TraceLevel))
+
+		Expect(cpi.IsErrNoContext(logcfg.New("acme.other", logging.InfoLevel).ApplyTo(ctx, c))).To(BeTrue())
+	})
+})
+
+type thirdPartyContext struct {
+	ctxmgmt.InternalContext
+}
@@ -3,6 +3,7 @@ package logging
 import (
 	"github.com/mandelsoft/ctxmgmt/attributes"
 	logdata "github.com/mandelsoft/ctxmgmt/utils/cobrautils/logopts/logging"
+	"github.com/mandelsoft/goutils/errors"
 	"github.com/mandelsoft/logging"
 	logcfg "github.com/mandelsoft/logging/config"
 
@@ -17,6 +18,17 @@ const (
 	ConfigTypeV1 = ConfigType + runtime.VersionSeparator + "v1"
 )
 
+// Pseudo context types used to configure the
+// logging outside of data contexts.
+const (
+	// CONTEXT_DEFAULT configures the local static logging context.
+	CONTEXT_DEFAULT = "default"
+	// CONTEXT_GLOBAL configures the global logging context.
+	CONTEXT_GLOBAL = "global"
+	// CONTEXT_SLAVE is forwarded to slave executables, only.
+	CONTEXT_SLAVE = "slave"
+)
+
 func init() {
 	cpi.RegisterConfigType(cpi.NewConfigType[*Config](ConfigType, usage))
 	cpi.RegisterConfigType(cpi.NewConfigType[*Config](ConfigTypeV1, usage))
@@ -38,6 +50,22 @@ type Config struct {
 	ExtraId string `json:"extraId,omitempty"`
 }
 
+// Validate checks the context type to be either a pseudo context type
+// or a context type registered at the ctxmgmt.DefaultContextTypeRegistry.
+// Because the registration of context types is optional, it is
+// not enforced when applying the config. Contexts of unregistered
+// types are configured, also.
+func (c *Config) Validate() error {
+	switch c.ContextType {
+	case "", CONTEXT_DEFAULT, CONTEXT_GLOBAL, CONTEXT_SLAVE:
+		return nil
+	}
+	if ctxmgmt.GetContextType(c.ContextType) == nil {
+		return errors.ErrUnknown(ctxmgmt.KIND_CONTEXTTYPE, c.ContextType)
+	}
+	return nil
+}
+
 // New creates a logging config specification.
 func New(ctxtype string, deflvl int) *Config {
 	return &Config{
@@ -70,30 +98,27 @@ func (c *Config) GetType() string {
 
 func (c *Config) ApplyTo(ctx cpi.Context, target interface{}) error {
 	// first: check for forward configuration
-	// TODO: context type registry
 	if lc, ok := target.(*logdata.LoggingConfiguration); ok {
 		switch c.ContextType {
-		case "default", "global", "slave":
+		case CONTEXT_DEFAULT, CONTEXT_GLOBAL, CONTEXT_SLAVE:
 			lc.LogConfig.DefaultLevel = c.Settings.DefaultLevel
 			lc.LogConfig.Rules = append(lc.LogConfig.Rules, c.Settings.Rules...)
 		}
 		return nil
 	}
 
-	// TODO: add registry for context types
-
-	// second: main use case is to configure vrious logging contexts
+	// second: main use case is to configure various logging contexts
 	switch c.ContextType {
 	// configure local static logging context.
 	// here, config is only applied once for every
 	// setting hash.
-	case "default":
+	case CONTEXT_DEFAULT:
 		return local.Configure(&c.Settings, c.ExtraId)
 
-	case "global":
+	case CONTEXT_GLOBAL:
 		return local.ConfigureGlobal(&c.Settings, c.ExtraId)
 
-	case "slave":
+	case CONTEXT_SLAVE:
 		return nil
 
 	// configure logging context providers.
@@ -137,4 +162,8 @@ context hierarchy.
 
 If no context type is specified, the config will be applies to any target
 acting as logging context provider, which is not a non-root context.
+
+Additionally, the pseudo context types <code>default</code> and
+<code>global</code> can be used to configure the static logging contexts,
+and <code>slave</code> to provide settings forwarded to slave executables.
 `
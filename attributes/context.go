@@ -17,6 +17,18 @@ const CONTEXT_TYPE = "attributes" + ctxmgmt.CONTEXT_SUFFIX
 
 type AttributesContext = ctxmgmt.AttributesContext
 
+func init() {
+	ctxmgmt.RegisterContextType(ctxmgmt.NewContextType(CONTEXT_TYPE,
+		"root context providing attributes and logging for a context hierarchy",
+		func(mode ...ctxmgmt.BuilderMode) ctxmgmt.Context {
+			return newWithActions(ctxmgmt.Mode(mode...), nil, handlers.NewRegistry(nil, handlers.DefaultRegistry()))
+		},
+		func(ctx context.Context) ctxmgmt.Context {
+			return ForContext(ctx)
+		},
+	))
+}
+
 // DefaultContext is the default context initialized by init functions.
 var DefaultContext = NewWithActions(nil, handlers.DefaultRegistry())
 
@@ -30,6 +42,16 @@ func ForContext(ctx context.Context) AttributesContext {
 	return c.(AttributesContext)
 }
 
+// DefinedForContext returns the Context explicitly bound to a context.Context.
+// If no context is bound, the default context and false is returned.
+func DefinedForContext(ctx context.Context) (AttributesContext, bool) {
+	c, ok := ctxmgmt.ForContextByKey(ctx, key, DefaultContext)
+	if c == nil {
+		return nil, ok
+	}
+	return c.(AttributesContext), ok
+}
+
 // WithContext create a new Context bound to a context.Context.
 func WithContext(ctx context.Context, parentAttrs ctxmgmt.Attributes) (ctxmgmt.Context, context.Context) {
 	c := New(parentAttrs)
@@ -73,10 +95,30 @@ func newWithActions(mode ctxmgmt.BuilderMode, parentAttrs ctxmgmt.Attributes, ac
 	return ctxmgmt.SetupContext(mode, c.CreateView()) // see above
 }
 
+// Update triggers the config updater, if one has been assured
+// by a config context (see AssureUpdater). The context is the
+// updater for its attributes, which are also used standalone.
 func (c *_context) Update() error {
+	if c.updater == nil {
+		return nil
+	}
 	return c.updater.Update()
 }
 
+// InUpdate implements ctxmgmt.UpdateState.
+func (c *_context) InUpdate() bool {
+	if s, ok := c.updater.(ctxmgmt.UpdateState); ok {
+		return s.InUpdate()
+	}
+	return false
+}
+
+// ResetConfig removes the attributes set by configuration.
+func (c *_context) ResetConfig() error {
+	ctxmgmt.ResetConfiguredAttributes(c.GetAttributes())
+	return nil
+}
+
 func (c *_context) CreateView() AttributesContext {
 	return newView(c, true)
 }
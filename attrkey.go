@@ -0,0 +1,169 @@
+package ctxmgmt
+
+import (
+	"io"
+	"reflect"
+
+	"github.com/mandelsoft/goutils/errors"
+	"github.com/mandelsoft/goutils/generics"
+	"github.com/modern-go/reflect2"
+
+	"github.com/mandelsoft/ctxmgmt/utils/jsonschema"
+	"github.com/mandelsoft/ctxmgmt/utils/runtime"
+)
+
+// AttributeKey is a type-safe key for a context attribute
+// with values of type T.
+// It acts as AttributeType, whose serialization format
+// is derived from the Go type T, and offers typed
+// access methods for the attribute.
+type AttributeKey[T any] struct {
+	name        string
+	description string
+	schema      *jsonschema.Schema
+	def         func(Context) T
+}
+
+var (
+	_ AttributeType  = (*AttributeKey[string])(nil)
+	_ Converter      = (*AttributeKey[string])(nil)
+	_ SchemaProvider = (*AttributeKey[string])(nil)
+)
+
+// NewAttributeKey creates a new attribute key for values of
+// type T and registers it at the DefaultAttributeScheme.
+func NewAttributeKey[T any](name string, desc string, short ...string) *AttributeKey[T] {
+	k := &AttributeKey[T]{
+		name:        name,
+		description: desc,
+		schema:      jsonschema.For[T](),
+	}
+	err := RegisterAttributeType(name, k, short...)
+	if err != nil {
+		panic(errors.Wrapf(err, "attribute %q", name))
+	}
+	return k
+}
+
+// WithDefault sets a factory used to provide a default value
+// if the attribute is not set for a context.
+func (k *AttributeKey[T]) WithDefault(f func(ctx Context) T) *AttributeKey[T] {
+	k.def = f
+	return k
+}
+
+// WithSchema replaces the JSON schema derived from the type T.
+// A nil schema disables the validation.
+func (k *AttributeKey[T]) WithSchema(s *jsonschema.Schema) *AttributeKey[T] {
+	k.schema = s
+	return k
+}
+
+func (k *AttributeKey[T]) Name() string {
+	return k.name
+}
+
+func (k *AttributeKey[T]) Description() string {
+	return k.description
+}
+
+// Schema provides the JSON schema for the serialized form of the values.
+func (k *AttributeKey[T]) Schema() *jsonschema.Schema {
+	return k.schema
+}
+
+func (k *AttributeKey[T]) Encode(v interface{}, marshaller runtime.Marshaler) ([]byte, error) {
+	if _, ok := v.(T); !ok {
+		return nil, errors.ErrInvalid("attribute value type", generics.TypeOf[T]().String(), k.name)
+	}
+	if marshaller == nil {
+		marshaller = runtime.DefaultJSONEncoding
+	}
+	return marshaller.Marshal(v)
+}
+
+func (k *AttributeKey[T]) Decode(data []byte, unmarshaller runtime.Unmarshaler) (interface{}, error) {
+	var v T
+	var target interface{} = &v
+
+	if unmarshaller == nil {
+		unmarshaller = runtime.DefaultYAMLEncoding
+	}
+	if t := generics.TypeOf[T](); t.Kind() == reflect.Pointer {
+		v = reflect.New(t.Elem()).Interface().(T)
+		target = v
+	}
+	err := unmarshaller.Unmarshal(data, target)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid attribute value for %s", k.name)
+	}
+	return v, nil
+}
+
+// Convert rejects values not matching the type T,
+// when set by the untyped attribute API.
+func (k *AttributeKey[T]) Convert(v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if _, ok := v.(T); !ok {
+		return nil, errors.ErrInvalid("attribute value type", reflect.TypeOf(v).String(), k.name)
+	}
+	return v, nil
+}
+
+// Lookup provides the attribute value for the given context, if set.
+func (k *AttributeKey[T]) Lookup(ctx Context) (T, bool) {
+	var _nil T
+
+	if reflect2.IsNil(ctx) {
+		return _nil, false
+	}
+	v := ctx.GetAttributes().GetAttribute(k.name)
+	if v == nil {
+		return _nil, false
+	}
+	t, ok := v.(T)
+	return t, ok
+}
+
+// Get provides the attribute value for the given context.
+// If not set, the default value is returned.
+func (k *AttributeKey[T]) Get(ctx Context) T {
+	var _nil T
+
+	if v, ok := k.Lookup(ctx); ok {
+		return v
+	}
+	if k.def != nil {
+		return k.def(ctx)
+	}
+	return _nil
+}
+
+// GetOrCreate provides the attribute value for the given context.
+// If not set, the default value is created and set for the context.
+func (k *AttributeKey[T]) GetOrCreate(ctx Context) T {
+	if k.def == nil {
+		return k.Get(ctx)
+	}
+	v, _ := ctx.GetAttributes().GetOrCreateAttribute(k.name, func(c Context) interface{} {
+		return k.def(c)
+	}).(T)
+	return v
+}
+
+// Set sets the attribute value for the given context.
+func (k *AttributeKey[T]) Set(ctx Context, v T) error {
+	return ctx.GetAttributes().SetAttribute(k.name, v)
+}
+
+// Watch registers a typed watcher for changes of the attribute
+// for the given context.
+func (k *AttributeKey[T]) Watch(ctx Context, w func(old, new T)) io.Closer {
+	return ctx.GetAttributes().Watch(k.name, AttributeWatcherFunction(func(evt *AttributeEvent) {
+		o, _ := evt.Old.(T)
+		n, _ := evt.New.(T)
+		w(o, n)
+	}))
+}
@@ -0,0 +1,79 @@
+package ctxmgmt_test
+
+import (
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	me "github.com/mandelsoft/ctxmgmt"
+	"github.com/mandelsoft/ctxmgmt/attributes"
+	"github.com/mandelsoft/ctxmgmt/utils/runtime"
+)
+
+type keyValue struct {
+	Name  string `json:"name"`
+	Count int    `json:"count,omitempty"`
+}
+
+var (
+	ptrKey = me.NewAttributeKey[*keyValue]("github.com/mandelsoft/ctxmgmt/test/ptrkey", "pointer test key", "ptrkey")
+	intKey = me.NewAttributeKey[int]("github.com/mandelsoft/ctxmgmt/test/intkey", "int test key").
+		WithDefault(func(ctx me.Context) int { return 42 })
+)
+
+var _ = Describe("attribute keys", func() {
+	var ctx me.AttributesContext
+
+	BeforeEach(func() {
+		ctx = attributes.New()
+	})
+
+	It("sets and gets typed values", func() {
+		_, ok := ptrKey.Lookup(ctx)
+		Expect(ok).To(BeFalse())
+		Expect(ptrKey.Get(ctx)).To(BeNil())
+
+		Expect(ptrKey.Set(ctx, &keyValue{Name: "test"})).To(Succeed())
+		Expect(ptrKey.Get(ctx)).To(Equal(&keyValue{Name: "test"}))
+	})
+
+	It("provides defaults", func() {
+		Expect(intKey.Get(ctx)).To(Equal(42))
+		_, ok := intKey.Lookup(ctx)
+		Expect(ok).To(BeFalse())
+
+		Expect(intKey.GetOrCreate(ctx)).To(Equal(42))
+		v, ok := intKey.Lookup(ctx)
+		Expect(ok).To(BeTrue())
+		Expect(v).To(Equal(42))
+	})
+
+	It("decodes by shortcut name", func() {
+		Expect(ctx.GetAttributes().SetEncodedAttribute("ptrkey", []byte("name: alice\ncount: 2\n"), runtime.DefaultYAMLEncoding)).To(Succeed())
+		Expect(ptrKey.Get(ctx)).To(Equal(&keyValue{Name: "alice", Count: 2}))
+
+		Expect(ctx.GetAttributes().SetEncodedAttribute(intKey.Name(), []byte("5"), runtime.DefaultJSONEncoding)).To(Succeed())
+		Expect(intKey.Get(ctx)).To(Equal(5))
+	})
+
+	It("encodes values", func() {
+		data := Must(me.DefaultAttributeScheme.Encode("ptrkey", &keyValue{Name: "bob"}, runtime.DefaultJSONEncoding))
+		Expect(string(data)).To(Equal(`{"name":"bob"}`))
+	})
+
+	It("rejects values of wrong type", func() {
+		Expect(ctx.GetAttributes().SetAttribute(intKey.Name(), "string")).NotTo(Succeed())
+		_, err := me.DefaultAttributeScheme.Encode(intKey.Name(), "string", runtime.DefaultJSONEncoding)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("watches typed changes", func() {
+		var changes []int
+		intKey.Watch(ctx, func(old, new int) {
+			changes = append(changes, old, new)
+		})
+		Expect(intKey.Set(ctx, 1)).To(Succeed())
+		Expect(intKey.Set(ctx, 2)).To(Succeed())
+		Expect(changes).To(Equal([]int{0, 1, 1, 2}))
+	})
+})
@@ -2,14 +2,17 @@ package ctxmgmt
 
 import (
 	"io"
+	"slices"
 	"sort"
 	"sync"
 
 	"github.com/mandelsoft/goutils/errors"
 	"github.com/mandelsoft/goutils/finalizer"
 	"github.com/mandelsoft/goutils/general"
+	"github.com/mandelsoft/goutils/maputils"
 
 	"github.com/mandelsoft/ctxmgmt/utils"
+	"github.com/mandelsoft/ctxmgmt/utils/jsonschema"
 	"github.com/mandelsoft/ctxmgmt/utils/runtime"
 )
 
@@ -27,6 +30,14 @@ type Converter interface {
 	Convert(interface{}) (interface{}, error)
 }
 
+// SchemaProvider is an optional interface an AttributeType can implement to
+// declare a JSON schema for the serialized form of its values.
+// If provided, it is enforced by the AttributeScheme when decoding
+// attribute values.
+type SchemaProvider interface {
+	Schema() *jsonschema.Schema
+}
+
 type AttributeScheme interface {
 	Register(name string, typ AttributeType, short ...string) error
 
@@ -41,6 +52,24 @@ type AttributeScheme interface {
 	KnownTypeNames() []string
 }
 
+// AttributeValidator is an optional interface an AttributeScheme can
+// implement to validate serialized attribute values without decoding
+// them.
+type AttributeValidator interface {
+	Validate(attr string, data []byte) error
+}
+
+// ValidateAttribute validates the serialized value of an attribute.
+// If the scheme does not implement AttributeValidator, the value
+// is validated by decoding it.
+func ValidateAttribute(scheme AttributeScheme, attr string, data []byte) error {
+	if v, ok := scheme.(AttributeValidator); ok {
+		return v.Validate(attr, data)
+	}
+	_, err := scheme.Decode(attr, data, nil)
+	return err
+}
+
 var DefaultAttributeScheme = NewDefaultAttributeScheme()
 
 // KnownTypes is a set of known type names mapped to appropriate object decoders.
@@ -65,12 +94,39 @@ func (t KnownTypes) TypeNames() []string {
 	return types
 }
 
+// Schema provides the JSON schema declared for the given type name.
+// It returns nil, if no schema is declared.
+func (t KnownTypes) Schema(name string) *jsonschema.Schema {
+	return schemaFor(t[name])
+}
+
+// Schemas provides the declared JSON schemas of all known types
+// declaring a schema.
+func (t KnownTypes) Schemas() map[string]*jsonschema.Schema {
+	schemas := map[string]*jsonschema.Schema{}
+	for n, e := range t {
+		if s := schemaFor(e); s != nil {
+			schemas[n] = s
+		}
+	}
+	return schemas
+}
+
+func schemaFor(t AttributeType) *jsonschema.Schema {
+	if p, ok := t.(SchemaProvider); ok {
+		return p.Schema()
+	}
+	return nil
+}
+
 type defaultScheme struct {
 	lock  sync.RWMutex
 	types KnownTypes
 	short utils.Properties
 }
 
+var _ AttributeValidator = (*defaultScheme)(nil)
+
 func NewDefaultAttributeScheme() AttributeScheme {
 	return &defaultScheme{
 		types: KnownTypes{},
@@ -187,9 +243,31 @@ func (d *defaultScheme) Decode(attr string, data []byte, unmarshaler runtime.Unm
 	if t == nil {
 		return nil, errors.ErrUnknown("attribute", attr)
 	}
+	err := validate(t, data)
+	if err != nil {
+		return nil, err
+	}
 	return t.Decode(data, unmarshaler)
 }
 
+func (d *defaultScheme) Validate(attr string, data []byte) error {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+	t := d.getType(attr)
+	if t == nil {
+		return errors.ErrUnknown("attribute", attr)
+	}
+	return validate(t, data)
+}
+
+func validate(t AttributeType, data []byte) error {
+	s := schemaFor(t)
+	if s == nil {
+		return nil
+	}
+	return errors.Wrapf(s.Validate(data), "invalid value for attribute %q", t.Name())
+}
+
 type DefaultAttributeType struct{}
 
 func (_ DefaultAttributeType) Encode(v interface{}, marshaller runtime.Marshaler) ([]byte, error) {
@@ -205,25 +283,49 @@ type _attributes struct {
 	parent     Attributes
 	updater    *Updater
 	attributes map[string]interface{}
+	// configured are the names of the attributes set by configuration.
+	configured map[string]struct{}
+
+	wlock    sync.Mutex
+	watchers map[string][]*attributeWatch
+	children map[*_attributes]struct{}
 }
 
 var _ Attributes = &_attributes{}
 
+// NewAttributes creates a new attribute set for a context
+// inheriting attribute values from the given parent attribute set.
+// If the attribute set is not finalized, it is kept by the parent
+// set to propagate attribute changes.
 func NewAttributes(ctx Context, parent Attributes, updater *Updater) Attributes {
 	return newAttributes(ctx, parent, updater)
 }
 
 func newAttributes(ctx Context, parent Attributes, updater *Updater) *_attributes {
-	return &_attributes{
+	c := &_attributes{
 		id:         attrsrange.NextId(),
 		ctx:        ctx,
 		parent:     parent,
 		updater:    updater,
 		attributes: map[string]interface{}{},
+		configured: map[string]struct{}{},
+		watchers:   map[string][]*attributeWatch{},
+		children:   map[*_attributes]struct{}{},
+	}
+	if p, ok := parent.(*_attributes); ok {
+		p.addChild(c)
 	}
+	return c
 }
 
 func (c *_attributes) Finalize() error {
+	if p, ok := c.parent.(*_attributes); ok {
+		p.removeChild(c)
+	}
+	c.wlock.Lock()
+	c.watchers = map[string][]*attributeWatch{}
+	c.wlock.Unlock()
+
 	list := errors.ErrListf("finalizing attributes")
 	for n, a := range c.attributes {
 		if f, ok := a.(finalizer.Finalizable); ok {
@@ -233,17 +335,127 @@ func (c *_attributes) Finalize() error {
 	return list.Result()
 }
 
+func (c *_attributes) addChild(child *_attributes) {
+	c.wlock.Lock()
+	defer c.wlock.Unlock()
+	c.children[child] = struct{}{}
+}
+
+func (c *_attributes) removeChild(child *_attributes) {
+	c.wlock.Lock()
+	defer c.wlock.Unlock()
+	delete(c.children, child)
+}
+
+// lookup provides the effective attribute value without
+// triggering an update.
+func (c *_attributes) lookup(name string) interface{} {
+	c.RLock()
+	v := c.attributes[name]
+	c.RUnlock()
+	if v != nil {
+		return v
+	}
+	return c.lookupParent(name)
+}
+
+func (c *_attributes) lookupParent(name string) interface{} {
+	switch p := c.parent.(type) {
+	case nil:
+		return nil
+	case *_attributes:
+		return p.lookup(name)
+	default:
+		return p.GetAttribute(name)
+	}
+}
+
+func (c *_attributes) Watch(name string, w AttributeWatcher) io.Closer {
+	if s := DefaultAttributeScheme.Shortcuts()[name]; s != "" {
+		name = s
+	}
+	c.wlock.Lock()
+	defer c.wlock.Unlock()
+	watch := &attributeWatch{attrs: c, name: name, watcher: w}
+	c.watchers[name] = append(c.watchers[name], watch)
+	return watch
+}
+
+func (c *_attributes) unwatch(watch *attributeWatch) {
+	c.wlock.Lock()
+	defer c.wlock.Unlock()
+	list := c.watchers[watch.name]
+	for i, e := range list {
+		if e == watch {
+			c.watchers[watch.name] = append(list[:i:i], list[i+1:]...)
+			break
+		}
+	}
+	if len(c.watchers[watch.name]) == 0 {
+		delete(c.watchers, watch.name)
+	}
+}
+
+// notify propagates a change of an attribute value to the watchers
+// of this attribute set and to all child attribute sets
+// inheriting the value.
+func (c *_attributes) notify(name string, old, value interface{}, inherited bool) {
+	c.wlock.Lock()
+	watchers := slices.Clone(c.watchers[name])
+	children := make([]*_attributes, 0, len(c.children))
+	for child := range c.children {
+		children = append(children, child)
+	}
+	c.wlock.Unlock()
+
+	if len(watchers) > 0 {
+		evt := &AttributeEvent{
+			Context:   c.ctx,
+			Name:      name,
+			Old:       old,
+			New:       value,
+			Inherited: inherited,
+		}
+		for _, w := range watchers {
+			w.watcher.AttributeChanged(evt)
+		}
+	}
+	for _, child := range children {
+		child.RLock()
+		shadowed := child.attributes[name] != nil
+		child.RUnlock()
+		if !shadowed {
+			child.notify(name, old, value, true)
+		}
+	}
+}
+
+type attributeWatch struct {
+	attrs   *_attributes
+	name    string
+	watcher AttributeWatcher
+}
+
+func (w *attributeWatch) Close() error {
+	w.attrs.unwatch(w)
+	return nil
+}
+
 func (c *_attributes) GetAttribute(name string, def ...interface{}) interface{} {
 	if *c.updater != nil {
 		(*c.updater).Update()
 	}
 	c.RLock()
-	defer c.RUnlock()
-	if a := c.attributes[name]; a != nil {
-		return a
+	a := c.attributes[name]
+	parent := c.parent
+	c.RUnlock()
+	if a != nil {
+		if a = c.evaluate(name, a); a != nil {
+			return a
+		}
 	}
-	if c.parent != nil {
-		if a := c.parent.GetAttribute(name); a != nil {
+	if parent != nil {
+		if a := parent.GetAttribute(name); a != nil {
 			return a
 		}
 	}
@@ -263,13 +475,19 @@ func (c *_attributes) SetEncodedAttribute(name string, data []byte, unmarshaller
 	return nil
 }
 
-func (c *_attributes) setAttribute(name string, value interface{}) error {
+func (c *_attributes) setAttribute(name string, value interface{}, configured bool) (interface{}, interface{}, error) {
 	c.Lock()
 	defer c.Unlock()
 
-	_, err := DefaultAttributeScheme.Encode(name, value, nil)
-	if err != nil && !errors.IsErrUnknownKind(err, "attribute") {
-		return err
+	if _, lazy := value.(LazyAttributeValue); !lazy {
+		_, err := DefaultAttributeScheme.Encode(name, value, nil)
+		if err != nil && !errors.IsErrUnknownKind(err, "attribute") {
+			return nil, nil, err
+		}
+		value, err = DefaultAttributeScheme.Convert(name, value)
+		if err != nil && !errors.IsErrUnknownKind(err, "attribute") {
+			return nil, nil, err
+		}
 	}
 	old := c.attributes[name]
 	if old != nil && old != value {
@@ -277,17 +495,30 @@ func (c *_attributes) setAttribute(name string, value interface{}) error {
 			c.Close()
 		}
 	}
-	value, err = DefaultAttributeScheme.Convert(name, value)
-	if err != nil && !errors.IsErrUnknownKind(err, "attribute") {
-		return err
-	}
 	c.attributes[name] = value
-	return nil
+	if configured {
+		c.configured[name] = struct{}{}
+	} else {
+		delete(c.configured, name)
+	}
+	return old, value, nil
+}
+
+// inUpdate checks whether attributes are set by a configuration update.
+func (c *_attributes) inUpdate() bool {
+	if s, ok := (*c.updater).(UpdateState); ok {
+		return s.InUpdate()
+	}
+	return false
 }
 
 func (c *_attributes) SetAttribute(name string, value interface{}) error {
-	err := c.setAttribute(name, value)
+	old, value, err := c.setAttribute(name, value, c.inUpdate())
 	if err == nil {
+		if old == nil {
+			old = c.lookupParent(name)
+		}
+		c.notify(name, old, value, false)
 		if *c.updater != nil {
 			(*c.updater).Update()
 		}
@@ -295,26 +526,56 @@ func (c *_attributes) SetAttribute(name string, value interface{}) error {
 	return err
 }
 
-func (c *_attributes) getOrCreateAttribute(name string, creator AttributeFactory) interface{} {
+func (c *_attributes) getOrCreateAttribute(name string, creator AttributeFactory) (interface{}, bool) {
 	c.Lock()
 	defer c.Unlock()
 	if v := c.attributes[name]; v != nil {
-		return v
+		return v, false
 	}
 	if c.parent != nil {
 		if v := c.parent.GetAttribute(name); v != nil {
-			return v
+			return v, false
 		}
 	}
 	v := creator(c.ctx)
 	c.attributes[name] = v
-	return v
+	return v, true
 }
 
 func (c *_attributes) GetOrCreateAttribute(name string, creator AttributeFactory) interface{} {
-	r := c.getOrCreateAttribute(name, creator)
+	r, created := c.getOrCreateAttribute(name, creator)
+	if created {
+		c.notify(name, nil, r, false)
+	}
 	if *c.updater != nil {
 		(*c.updater).Update()
 	}
-	return r
+	return c.evaluate(name, r)
+}
+
+// ResetConfiguredAttributes removes the attribute values set by
+// configuration (during an update of the owning context) from an
+// attribute set. Afterwards, the values inherited from the parent
+// attribute set are effective again.
+func ResetConfiguredAttributes(attrs Attributes) {
+	c, ok := attrs.(*_attributes)
+	if !ok {
+		return
+	}
+	c.Lock()
+	names := maputils.OrderedKeys(c.configured)
+	old := make([]interface{}, len(names))
+	for i, n := range names {
+		old[i] = c.attributes[n]
+		if cl, ok := old[i].(io.Closer); ok {
+			cl.Close()
+		}
+		delete(c.attributes, n)
+	}
+	c.configured = map[string]struct{}{}
+	c.Unlock()
+
+	for i, n := range names {
+		c.notify(n, old[i], c.lookupParent(n), false)
+	}
 }
@@ -0,0 +1,58 @@
+package activationattr
+
+import (
+	"github.com/mandelsoft/ctxmgmt"
+)
+
+const (
+	ATTR_KEY   = "github.com/mandelsoft/ctxmgmt/activation"
+	ATTR_SHORT = "activation"
+)
+
+// Settings describes the application settings used to evaluate
+// the activation conditions of config sets.
+type Settings struct {
+	// Profiles are the names of the profiles selected for the application.
+	Profiles []string `json:"profiles,omitempty"`
+	// Version is the (semantic) version of the application.
+	Version string `json:"version,omitempty"`
+}
+
+var Key = ctxmgmt.NewAttributeKey[*Settings](ATTR_KEY, `
+*activation settings* Settings used to evaluate the activation conditions
+of config sets:
+- <code>profiles</code>: list of selected profile names
+- <code>version</code>: the semantic version of the application
+`, ATTR_SHORT)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Get provides the activation settings of a context.
+// If not set, empty settings are returned.
+func Get(ctx ctxmgmt.Context) *Settings {
+	s := Key.Get(ctx)
+	if s == nil {
+		return &Settings{}
+	}
+	return s
+}
+
+func Set(ctx ctxmgmt.Context, s *Settings) error {
+	return Key.Set(ctx, s)
+}
+
+// SetProfiles selects profiles for a context keeping
+// the other settings.
+func SetProfiles(ctx ctxmgmt.Context, profiles ...string) error {
+	s := *Get(ctx)
+	s.Profiles = profiles
+	return Set(ctx, &s)
+}
+
+// SetVersion sets the application version for a context
+// keeping the other settings.
+func SetVersion(ctx ctxmgmt.Context, version string) error {
+	s := *Get(ctx)
+	s.Version = version
+	return Set(ctx, &s)
+}
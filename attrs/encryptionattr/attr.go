@@ -0,0 +1,49 @@
+package encryptionattr
+
+import (
+	"github.com/mandelsoft/goutils/sliceutils"
+
+	"github.com/mandelsoft/ctxmgmt"
+)
+
+const (
+	ATTR_KEY   = "github.com/mandelsoft/ctxmgmt/encryption"
+	ATTR_SHORT = "encryption"
+)
+
+// Settings describes the keys used to decrypt
+// encrypted values in config files.
+type Settings struct {
+	// KeyFiles are the paths of key files in the
+	// filesystem of the context (see vfsattr).
+	KeyFiles []string `json:"keyFiles,omitempty"`
+}
+
+var Key = ctxmgmt.NewAttributeKey[*Settings](ATTR_KEY, `
+*encryption settings* Settings used to decrypt encrypted values
+in config files:
+- <code>keyFiles</code>: list of key files
+`, ATTR_SHORT)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Get provides the encryption settings of a context.
+// If not set, empty settings are returned.
+func Get(ctx ctxmgmt.Context) *Settings {
+	s := Key.Get(ctx)
+	if s == nil {
+		return &Settings{}
+	}
+	return s
+}
+
+func Set(ctx ctxmgmt.Context, s *Settings) error {
+	return Key.Set(ctx, s)
+}
+
+// AddKeyFiles adds key files to the settings of a context.
+func AddKeyFiles(ctx ctxmgmt.Context, files ...string) error {
+	s := *Get(ctx)
+	s.KeyFiles = sliceutils.CopyAppendUnique(s.KeyFiles, files...)
+	return Set(ctx, &s)
+}
@@ -1,6 +1,8 @@
 package attrs
 
 import (
+	_ "github.com/mandelsoft/ctxmgmt/attrs/activationattr"
+	_ "github.com/mandelsoft/ctxmgmt/attrs/encryptionattr"
 	_ "github.com/mandelsoft/ctxmgmt/attrs/logforward"
 	_ "github.com/mandelsoft/ctxmgmt/attrs/tmpcache"
 	_ "github.com/mandelsoft/ctxmgmt/attrs/vfsattr"
@@ -8,6 +8,7 @@ import (
 	"sigs.k8s.io/yaml"
 
 	"github.com/mandelsoft/ctxmgmt"
+	"github.com/mandelsoft/ctxmgmt/utils/jsonschema"
 	"github.com/mandelsoft/ctxmgmt/utils/runtime"
 )
 
@@ -35,6 +36,10 @@ to be forwarded to other tools.
 `
 }
 
+func (a AttributeType) Schema() *jsonschema.Schema {
+	return jsonschema.For[*logcfg.Config]()
+}
+
 func (a AttributeType) Encode(v interface{}, marshaller runtime.Marshaler) ([]byte, error) {
 	if _, ok := v.(*logcfg.Config); !ok {
 		return nil, fmt.Errorf("logging config required")
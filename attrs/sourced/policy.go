@@ -0,0 +1,107 @@
+package sourced
+
+import (
+	"time"
+
+	"github.com/mandelsoft/ctxmgmt"
+)
+
+// State describes the state of the last successful read
+// of a source.
+type State struct {
+	// Valid indicates whether the source has been read at all.
+	Valid bool
+	// Time is the time of the last read.
+	Time time.Time
+	// Stamp is the stamp of the source at the last read,
+	// if the source is a Stamper.
+	Stamp string
+}
+
+// Policy decides whether a source has to be re-evaluated.
+type Policy interface {
+	// Outdated checks whether the state of the last read
+	// is outdated and the source must be read again.
+	Outdated(ctx ctxmgmt.Context, src Source, state *State) bool
+	String() string
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type always struct{}
+
+// Always re-evaluates the source on every access.
+func Always() Policy {
+	return always{}
+}
+
+func (always) Outdated(ctx ctxmgmt.Context, src Source, state *State) bool {
+	return true
+}
+
+func (always) String() string {
+	return "always"
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type once struct{}
+
+// Once evaluates the source only once, on first access.
+func Once() Policy {
+	return once{}
+}
+
+func (once) Outdated(ctx ctxmgmt.Context, src Source, state *State) bool {
+	return !state.Valid
+}
+
+func (once) String() string {
+	return "once"
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type ttl time.Duration
+
+// TTL re-evaluates the source on access, if the last
+// evaluation is older than the given duration.
+func TTL(d time.Duration) Policy {
+	return ttl(d)
+}
+
+func (p ttl) Outdated(ctx ctxmgmt.Context, src Source, state *State) bool {
+	return !state.Valid || time.Since(state.Time) >= time.Duration(p)
+}
+
+func (p ttl) String() string {
+	return "ttl " + time.Duration(p).String()
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type onchange struct{}
+
+// OnChange re-evaluates the source on access, if its content
+// has changed since the last evaluation.
+// Changes are detected for sources implementing the Stamper interface,
+// all other sources are re-evaluated on every access.
+func OnChange() Policy {
+	return onchange{}
+}
+
+func (onchange) Outdated(ctx ctxmgmt.Context, src Source, state *State) bool {
+	if !state.Valid {
+		return true
+	}
+	s, ok := src.(Stamper)
+	if !ok {
+		return true
+	}
+	stamp, err := s.Stamp(ctx)
+	return err != nil || stamp != state.Stamp
+}
+
+func (onchange) String() string {
+	return "on change"
+}
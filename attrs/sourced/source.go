@@ -0,0 +1,179 @@
+package sourced
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/mandelsoft/goutils/errors"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	"github.com/mandelsoft/ctxmgmt"
+	"github.com/mandelsoft/ctxmgmt/attrs/vfsattr"
+	"github.com/mandelsoft/ctxmgmt/utils/runtime"
+)
+
+// Source provides the serialized form of an attribute value.
+type Source interface {
+	// Read provides the actual content of the source.
+	// Nil content indicates an unset value.
+	Read(ctx ctxmgmt.Context) ([]byte, error)
+	String() string
+}
+
+// Stamper is an optional interface for a Source, which is able
+// to cheaply indicate content changes. It is used by the
+// OnChange policy.
+type Stamper interface {
+	// Stamp provides a value, which changes whenever
+	// the content of the source changes.
+	Stamp(ctx ctxmgmt.Context) (string, error)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type file struct {
+	path string
+}
+
+var _ Stamper = (*file)(nil)
+
+// File provides a file on the filesystem configured for a context by
+// the vfsattr attribute as Source.
+// A non-existing file is handled as unset value.
+func File(path string) Source {
+	return &file{path}
+}
+
+func (s *file) Read(ctx ctxmgmt.Context) ([]byte, error) {
+	data, err := vfs.ReadFile(vfsattr.Get(ctx), s.path)
+	if err != nil {
+		if vfs.IsErrNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+func (s *file) Stamp(ctx ctxmgmt.Context) (string, error) {
+	fi, err := vfsattr.Get(ctx).Stat(s.path)
+	if err != nil {
+		if vfs.IsErrNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return fmt.Sprintf("%d/%d", fi.ModTime().UnixNano(), fi.Size()), nil
+}
+
+func (s *file) String() string {
+	return "file " + s.path
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type env struct {
+	name string
+}
+
+var _ Stamper = (*env)(nil)
+
+// Env provides an environment variable as Source.
+// An undefined variable is handled as unset value.
+func Env(name string) Source {
+	return &env{name}
+}
+
+func (s *env) Read(ctx ctxmgmt.Context) ([]byte, error) {
+	v, ok := os.LookupEnv(s.name)
+	if !ok {
+		return nil, nil
+	}
+	return []byte(v), nil
+}
+
+func (s *env) Stamp(ctx ctxmgmt.Context) (string, error) {
+	v, ok := os.LookupEnv(s.name)
+	if !ok {
+		return "", nil
+	}
+	return "=" + v, nil
+}
+
+func (s *env) String() string {
+	return "env " + s.name
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type command struct {
+	name string
+	args []string
+}
+
+// Command provides the standard output of a command as Source.
+// Commands are always executed to read the source, the OnChange
+// policy therefore re-executes the command on every access.
+func Command(name string, args ...string) Source {
+	return &command{name, args}
+}
+
+func (s *command) Read(ctx ctxmgmt.Context) ([]byte, error) {
+	var stderr bytes.Buffer
+
+	cmd := exec.Command(s.name, s.args...)
+	cmd.Stderr = &stderr
+	data, err := cmd.Output()
+	if err != nil {
+		if stderr.Len() > 0 {
+			return nil, errors.Wrapf(err, "%s: %s", s, strings.TrimSpace(stderr.String()))
+		}
+		return nil, errors.Wrapf(err, "%s", s)
+	}
+	return data, nil
+}
+
+func (s *command) String() string {
+	return strings.Join(append([]string{"command", s.name}, s.args...), " ")
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type attribute struct {
+	name string
+}
+
+var _ Stamper = (*attribute)(nil)
+
+// Attribute provides the value of another attribute of the
+// context as Source. The value is transferred in its serialized
+// form as provided by the DefaultAttributeScheme.
+func Attribute(name string) Source {
+	return &attribute{name}
+}
+
+func (s *attribute) Read(ctx ctxmgmt.Context) ([]byte, error) {
+	v := ctx.GetAttributes().GetAttribute(s.name)
+	if v == nil {
+		return nil, nil
+	}
+	if str, ok := v.(string); ok {
+		return []byte(str), nil
+	}
+	return ctxmgmt.DefaultAttributeScheme.Encode(s.name, v, runtime.DefaultJSONEncoding)
+}
+
+func (s *attribute) Stamp(ctx ctxmgmt.Context) (string, error) {
+	data, err := s.Read(ctx)
+	if data == nil || err != nil {
+		return "", err
+	}
+	return "=" + string(data), nil
+}
+
+func (s *attribute) String() string {
+	return "attribute " + s.name
+}
@@ -0,0 +1,181 @@
+package sourced
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mandelsoft/goutils/errors"
+	"github.com/mandelsoft/goutils/optionutils"
+
+	"github.com/mandelsoft/ctxmgmt"
+	"github.com/mandelsoft/ctxmgmt/utils/runtime"
+)
+
+// Decoder decodes the content of a source into an attribute value.
+type Decoder func(ctx ctxmgmt.Context, attr string, data []byte) (interface{}, error)
+
+// DefaultDecoder decodes the source content using the
+// DefaultAttributeScheme. For unknown attribute types the
+// content is used as string value.
+func DefaultDecoder(ctx ctxmgmt.Context, attr string, data []byte) (interface{}, error) {
+	v, err := ctxmgmt.DefaultAttributeScheme.Decode(attr, data, runtime.DefaultYAMLEncoding)
+	if errors.IsErrUnknownKind(err, "attribute") {
+		return strings.TrimSpace(string(data)), nil
+	}
+	return v, err
+}
+
+type Option = optionutils.Option[*Options]
+
+type Options struct {
+	Decoder Decoder
+}
+
+var _ Option = (*Options)(nil)
+
+func (o *Options) ApplyTo(opts *Options) {
+	if o.Decoder != nil {
+		opts.Decoder = o.Decoder
+	}
+}
+
+type decoder Decoder
+
+func (o decoder) ApplyTo(opts *Options) {
+	opts.Decoder = Decoder(o)
+}
+
+// WithDecoder sets the decoder used to map the source content
+// to an attribute value.
+func WithDecoder(d Decoder) Option {
+	return decoder(d)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Value is an attribute value backed by a Source and
+// re-evaluated according to a Policy.
+// It can be used as value for any attribute (see ctxmgmt.LazyAttributeValue).
+// If a re-evaluation fails, the last successfully evaluated value
+// is kept.
+// The source is evaluated without holding a lock. Accesses during an
+// evaluation, for example by sources depending on other attributes
+// (see Attribute), provide the last evaluated value. If there is none,
+// the access fails with a cyclic dependency error.
+type Value struct {
+	lock    sync.Mutex
+	source  Source
+	policy  Policy
+	decoder Decoder
+
+	state      State
+	value      interface{}
+	evaluating bool
+}
+
+var (
+	_ ctxmgmt.LazyAttributeValue = (*Value)(nil)
+	_ ctxmgmt.LazyValueDescriber = (*Value)(nil)
+)
+
+// New provides a new source backed attribute value.
+func New(src Source, policy Policy, opts ...Option) *Value {
+	eff := optionutils.EvalOptions(opts...)
+	if eff.Decoder == nil {
+		eff.Decoder = DefaultDecoder
+	}
+	if policy == nil {
+		policy = OnChange()
+	}
+	return &Value{
+		source:  src,
+		policy:  policy,
+		decoder: eff.Decoder,
+	}
+}
+
+// Set sets a source backed value for an attribute of the given context.
+func Set(ctx ctxmgmt.Context, attr string, src Source, policy Policy, opts ...Option) error {
+	if s := ctxmgmt.DefaultAttributeScheme.Shortcuts()[attr]; s != "" {
+		attr = s
+	}
+	return ctx.GetAttributes().SetAttribute(attr, New(src, policy, opts...))
+}
+
+func (v *Value) Source() Source {
+	return v.source
+}
+
+func (v *Value) Policy() Policy {
+	return v.policy
+}
+
+// DescribeLazyValue describes the source and policy of the value
+// together with the last evaluated value without evaluating
+// the source.
+func (v *Value) DescribeLazyValue() (string, interface{}) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	return fmt.Sprintf("%s (%s)", v.source, v.policy), v.value
+}
+
+func (v *Value) EvaluateAttribute(ctx ctxmgmt.Context, name string) (interface{}, error) {
+	v.lock.Lock()
+	if v.evaluating {
+		defer v.lock.Unlock()
+		if v.state.Valid {
+			return v.value, nil
+		}
+		return nil, errors.Newf("%s: evaluation already in progress (cyclic dependency)", v.source)
+	}
+	value, state := v.value, v.state
+	v.evaluating = true
+	v.lock.Unlock()
+
+	value, state, err := v.evaluate(ctx, name, value, state)
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	v.evaluating = false
+	if err != nil {
+		return v.value, errors.Wrapf(err, "%s", v.source)
+	}
+	v.value = value
+	v.state = state
+	return v.value, nil
+}
+
+// evaluate evaluates the source, if the given value is outdated
+// according to the policy, and provides the new value and state.
+func (v *Value) evaluate(ctx ctxmgmt.Context, name string, value interface{}, state State) (interface{}, State, error) {
+	if state.Valid && !v.policy.Outdated(ctx, v.source, &state) {
+		return value, state, nil
+	}
+
+	var stamp string
+	var err error
+	if s, ok := v.source.(Stamper); ok {
+		stamp, err = s.Stamp(ctx)
+		if err != nil {
+			return nil, state, err
+		}
+	}
+	data, err := v.source.Read(ctx)
+	if err != nil {
+		return nil, state, err
+	}
+	value = nil
+	if data != nil {
+		value, err = v.decoder(ctx, name, data)
+		if err != nil {
+			return nil, state, err
+		}
+	}
+	return value, State{
+		Valid: true,
+		Time:  time.Now(),
+		Stamp: stamp,
+	}, nil
+}
@@ -0,0 +1,158 @@
+package sourced_test
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	"github.com/mandelsoft/ctxmgmt"
+	"github.com/mandelsoft/ctxmgmt/attributes"
+	"github.com/mandelsoft/ctxmgmt/attrs/sourced"
+	"github.com/mandelsoft/ctxmgmt/attrs/tmpcache"
+	"github.com/mandelsoft/ctxmgmt/attrs/vfsattr"
+	"github.com/mandelsoft/ctxmgmt/config"
+)
+
+const ATTR = "sourced.test"
+
+var _ = Describe("sourced attributes", func() {
+	var ctx attributes.AttributesContext
+	var fs vfs.FileSystem
+
+	BeforeEach(func() {
+		ctx = attributes.New()
+		fs = memoryfs.New()
+		vfsattr.Set(ctx, fs)
+	})
+
+	It("follows file changes", func() {
+		MustBeSuccessful(vfs.WriteFile(fs, "cache", []byte("/tmp/first"), 0o600))
+		MustBeSuccessful(sourced.Set(ctx, tmpcache.ATTR_SHORT, sourced.File("cache"), sourced.OnChange()))
+
+		Expect(tmpcache.Get(ctx).Path).To(Equal("/tmp/first"))
+		MustBeSuccessful(vfs.WriteFile(fs, "cache", []byte("/tmp/second/path"), 0o600))
+		Expect(tmpcache.Get(ctx).Path).To(Equal("/tmp/second/path"))
+	})
+
+	It("keeps last value on errors", func() {
+		MustBeSuccessful(vfs.WriteFile(fs, "cache", []byte("/tmp/first"), 0o600))
+		MustBeSuccessful(sourced.Set(ctx, tmpcache.ATTR_KEY, sourced.File("cache"), sourced.Always()))
+		Expect(tmpcache.Get(ctx).Path).To(Equal("/tmp/first"))
+
+		MustBeSuccessful(vfs.WriteFile(fs, "cache", []byte("{ invalid"), 0o600))
+		Expect(tmpcache.Get(ctx).Path).To(Equal("/tmp/first"))
+	})
+
+	It("evaluates environment variables", func() {
+		DeferCleanup(os.Unsetenv, "SOURCED_TEST_VALUE")
+		MustBeSuccessful(sourced.Set(ctx, ATTR, sourced.Env("SOURCED_TEST_VALUE"), sourced.Always()))
+
+		Expect(ctx.GetAttributes().GetAttribute(ATTR, "default")).To(Equal("default"))
+		os.Setenv("SOURCED_TEST_VALUE", "value")
+		Expect(ctx.GetAttributes().GetAttribute(ATTR)).To(Equal("value"))
+	})
+
+	It("caches according to ttl", func() {
+		DeferCleanup(os.Unsetenv, "SOURCED_TEST_VALUE")
+		os.Setenv("SOURCED_TEST_VALUE", "first")
+		MustBeSuccessful(sourced.Set(ctx, ATTR, sourced.Env("SOURCED_TEST_VALUE"), sourced.TTL(100*time.Millisecond)))
+
+		Expect(ctx.GetAttributes().GetAttribute(ATTR)).To(Equal("first"))
+		os.Setenv("SOURCED_TEST_VALUE", "second")
+		Expect(ctx.GetAttributes().GetAttribute(ATTR)).To(Equal("first"))
+		Eventually(func() interface{} { return ctx.GetAttributes().GetAttribute(ATTR) }).Should(Equal("second"))
+	})
+
+	It("evaluates commands", func() {
+		MustBeSuccessful(sourced.Set(ctx, ATTR, sourced.Command("echo", "hello"), sourced.Once()))
+		Expect(ctx.GetAttributes().GetAttribute(ATTR)).To(Equal("hello"))
+	})
+
+	It("maps other attributes", func() {
+		MustBeSuccessful(ctx.GetAttributes().SetAttribute(ATTR, "/tmp/other"))
+		MustBeSuccessful(sourced.Set(ctx, tmpcache.ATTR_KEY, sourced.Attribute(ATTR), sourced.OnChange()))
+		Expect(tmpcache.Get(ctx).Path).To(Equal("/tmp/other"))
+
+		MustBeSuccessful(ctx.GetAttributes().SetAttribute(ATTR, "/tmp/changed"))
+		Expect(tmpcache.Get(ctx).Path).To(Equal("/tmp/changed"))
+	})
+
+	It("is inherited with evaluation in the defining context", func() {
+		MustBeSuccessful(vfs.WriteFile(fs, "cache", []byte("/tmp/first"), 0o600))
+		MustBeSuccessful(sourced.Set(ctx, tmpcache.ATTR_KEY, sourced.File("cache"), sourced.OnChange()))
+
+		cfg := config.WithSharedAttributes(ctx).New()
+		Expect(tmpcache.Get(cfg).Path).To(Equal("/tmp/first"))
+	})
+
+	It("uses custom decoder", func() {
+		MustBeSuccessful(vfs.WriteFile(fs, "count", []byte("a\nb\nc\n"), 0o600))
+		MustBeSuccessful(sourced.Set(ctx, ATTR, sourced.File("count"), sourced.OnChange(),
+			sourced.WithDecoder(func(ctx ctxmgmt.Context, attr string, data []byte) (interface{}, error) {
+				return len(data), nil
+			})))
+		Expect(ctx.GetAttributes().GetAttribute(ATTR)).To(Equal(6))
+	})
+
+	It("is described without evaluation", func() {
+		MustBeSuccessful(vfs.WriteFile(fs, "cache", []byte("/tmp/first"), 0o600))
+		MustBeSuccessful(sourced.Set(ctx, tmpcache.ATTR_KEY, sourced.File("cache"), sourced.OnChange()))
+
+		d := ctxmgmt.DescribeAttributes(ctx.GetAttributes())
+		Expect(d.Values).To(ContainElement(&ctxmgmt.AttributeDescription{
+			Name:   tmpcache.ATTR_KEY,
+			GoType: "*sourced.Value",
+			Lazy:   "file cache (on change)",
+		}))
+
+		Expect(tmpcache.Get(ctx).Path).To(Equal("/tmp/first"))
+		MustBeSuccessful(vfs.WriteFile(fs, "cache", []byte("/tmp/second"), 0o600))
+		d = ctxmgmt.DescribeAttributes(ctx.GetAttributes())
+		Expect(d.Values).To(ContainElement(&ctxmgmt.AttributeDescription{
+			Name:   tmpcache.ATTR_KEY,
+			GoType: "*tmpcache.Attribute",
+			Lazy:   "file cache (on change)",
+			Value:  json.RawMessage(`"/tmp/first"`),
+		}))
+	})
+
+	It("does not deadlock for self references", func() {
+		MustBeSuccessful(sourced.Set(ctx, "a", sourced.Attribute("a"), sourced.OnChange()))
+		Eventually(func() interface{} { return ctx.GetAttributes().GetAttribute("a") }).WithTimeout(5 * time.Second).Should(BeNil())
+	})
+
+	It("does not deadlock for cyclic references", func() {
+		MustBeSuccessful(sourced.Set(ctx, "a", sourced.Attribute("b"), sourced.OnChange()))
+		MustBeSuccessful(sourced.Set(ctx, "b", sourced.Attribute("a"), sourced.OnChange()))
+		Eventually(func() interface{} { return ctx.GetAttributes().GetAttribute("a") }).WithTimeout(5 * time.Second).Should(BeNil())
+		Eventually(func() interface{} { return ctx.GetAttributes().GetAttribute("b") }).WithTimeout(5 * time.Second).Should(BeNil())
+	})
+
+	It("reports cyclic evaluations", func() {
+		src := &recursiveSource{}
+		src.value = sourced.New(src, sourced.Always())
+		_, err := src.value.EvaluateAttribute(ctx, ATTR)
+		Expect(err).To(MatchError(ContainSubstring("recursive: evaluation already in progress (cyclic dependency)")))
+	})
+})
+
+// recursiveSource evaluates its own value.
+type recursiveSource struct {
+	value *sourced.Value
+}
+
+func (s *recursiveSource) Read(ctx ctxmgmt.Context) ([]byte, error) {
+	_, err := s.value.EvaluateAttribute(ctx, ATTR)
+	return nil, err
+}
+
+func (s *recursiveSource) String() string {
+	return "recursive"
+}
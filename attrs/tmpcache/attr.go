@@ -10,6 +10,7 @@ import (
 
 	"github.com/mandelsoft/ctxmgmt"
 	"github.com/mandelsoft/ctxmgmt/attrs/vfsattr"
+	"github.com/mandelsoft/ctxmgmt/utils/jsonschema"
 	"github.com/mandelsoft/ctxmgmt/utils/runtime"
 )
 
@@ -37,6 +38,13 @@ blowing up the memory consumption.
 `
 }
 
+func (a AttributeType) Schema() *jsonschema.Schema {
+	return &jsonschema.Schema{
+		Type:        jsonschema.TYPE_STRING,
+		Description: "folder name for temporary blob cache",
+	}
+}
+
 func (a AttributeType) Encode(v interface{}, marshaller runtime.Marshaler) ([]byte, error) {
 	if a, ok := v.(*Attribute); !ok {
 		return nil, fmt.Errorf("temppcache attribute")
@@ -0,0 +1,112 @@
+package ctxmgmt_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	me "github.com/mandelsoft/ctxmgmt"
+	"github.com/mandelsoft/ctxmgmt/attributes"
+	"github.com/mandelsoft/ctxmgmt/attrs/tmpcache"
+	"github.com/mandelsoft/ctxmgmt/config"
+)
+
+type recorder struct {
+	events []me.AttributeEvent
+}
+
+func (r *recorder) AttributeChanged(evt *me.AttributeEvent) {
+	e := *evt
+	e.Context = nil
+	r.events = append(r.events, e)
+}
+
+var _ = Describe("attribute watches", func() {
+	const ATTR = "test"
+
+	var root me.AttributesContext
+	var cfg config.Context
+	var rootrec, cfgrec *recorder
+
+	BeforeEach(func() {
+		root = attributes.New()
+		cfg = config.WithSharedAttributes(root).New()
+		rootrec = &recorder{}
+		cfgrec = &recorder{}
+	})
+
+	It("notifies local changes", func() {
+		root.GetAttributes().Watch(ATTR, rootrec)
+		Expect(root.GetAttributes().SetAttribute(ATTR, "a")).To(Succeed())
+		Expect(root.GetAttributes().SetAttribute(ATTR, "b")).To(Succeed())
+
+		Expect(rootrec.events).To(Equal([]me.AttributeEvent{
+			{Name: ATTR, New: "a"},
+			{Name: ATTR, Old: "a", New: "b"},
+		}))
+	})
+
+	It("notifies inherited changes", func() {
+		root.GetAttributes().Watch(ATTR, rootrec)
+		cfg.GetAttributes().Watch(ATTR, cfgrec)
+		Expect(root.GetAttributes().SetAttribute(ATTR, "a")).To(Succeed())
+
+		Expect(rootrec.events).To(Equal([]me.AttributeEvent{
+			{Name: ATTR, New: "a"},
+		}))
+		Expect(cfgrec.events).To(Equal([]me.AttributeEvent{
+			{Name: ATTR, New: "a", Inherited: true},
+		}))
+	})
+
+	It("respects shadowed values", func() {
+		cfg.GetAttributes().Watch(ATTR, cfgrec)
+		Expect(root.GetAttributes().SetAttribute(ATTR, "a")).To(Succeed())
+		Expect(cfg.GetAttributes().SetAttribute(ATTR, "local")).To(Succeed())
+		Expect(root.GetAttributes().SetAttribute(ATTR, "b")).To(Succeed())
+
+		Expect(cfgrec.events).To(Equal([]me.AttributeEvent{
+			{Name: ATTR, New: "a", Inherited: true},
+			{Name: ATTR, Old: "a", New: "local"},
+		}))
+	})
+
+	It("unregisters watcher", func() {
+		w := root.GetAttributes().Watch(ATTR, rootrec)
+		Expect(root.GetAttributes().SetAttribute(ATTR, "a")).To(Succeed())
+		Expect(w.Close()).To(Succeed())
+		Expect(root.GetAttributes().SetAttribute(ATTR, "b")).To(Succeed())
+
+		Expect(rootrec.events).To(Equal([]me.AttributeEvent{
+			{Name: ATTR, New: "a"},
+		}))
+	})
+
+	It("drops watchers on finalization", func() {
+		cfg.GetAttributes().Watch(ATTR, cfgrec)
+		Expect(cfg.GetAttributes().Finalize()).To(Succeed())
+		Expect(root.GetAttributes().SetAttribute(ATTR, "a")).To(Succeed())
+		Expect(cfgrec.events).To(BeEmpty())
+	})
+})
+
+var _ = Describe("attribute validation", func() {
+	It("validates with the default scheme", func() {
+		Expect(me.ValidateAttribute(me.DefaultAttributeScheme, tmpcache.ATTR_KEY, []byte(`"/tmp"`))).To(Succeed())
+		Expect(me.ValidateAttribute(me.DefaultAttributeScheme, tmpcache.ATTR_KEY, []byte(`{}`))).To(MatchError(ContainSubstring("expected string")))
+	})
+
+	It("validates by decoding for schemes without validator", func() {
+		scheme := struct{ me.AttributeScheme }{me.DefaultAttributeScheme}
+		Expect(me.ValidateAttribute(scheme, tmpcache.ATTR_KEY, []byte(`"/tmp"`))).To(Succeed())
+		Expect(me.ValidateAttribute(scheme, tmpcache.ATTR_KEY, []byte(`{}`))).To(MatchError(ContainSubstring("expected string")))
+	})
+})
+
+var _ = Describe("standalone attributes context", func() {
+	It("accesses attributes without config updater", func() {
+		ctx := attributes.New()
+		Expect(ctx.GetAttributes().SetAttribute("test", "value")).To(Succeed())
+		Expect(ctx.GetAttributes().GetAttribute("test")).To(Equal("value"))
+		Expect(ctx.GetAttributes().GetOrCreateAttribute("other", func(ctx me.Context) interface{} { return "created" })).To(Equal("created"))
+	})
+})
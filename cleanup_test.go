@@ -0,0 +1,134 @@
+package ctxmgmt_test
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	me "github.com/mandelsoft/ctxmgmt"
+	"github.com/mandelsoft/ctxmgmt/attributes"
+	"github.com/mandelsoft/ctxmgmt/config"
+	"github.com/mandelsoft/ctxmgmt/utils/cleanup"
+)
+
+type cleanupLog struct {
+	lock sync.Mutex
+	log  []string
+}
+
+func (l *cleanupLog) Step(name string, err ...error) cleanup.Function {
+	return func() error {
+		l.lock.Lock()
+		defer l.lock.Unlock()
+		l.log = append(l.log, name)
+		for _, e := range err {
+			return e
+		}
+		return nil
+	}
+}
+
+func (l *cleanupLog) Close(name string) *closer {
+	return &closer{name, l}
+}
+
+func (l *cleanupLog) Get() []string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return append([]string(nil), l.log...)
+}
+
+type closer struct {
+	name string
+	log  *cleanupLog
+}
+
+func (c *closer) Close() error {
+	return c.log.Step(c.name)()
+}
+
+var _ = Describe("cleanup graph", func() {
+	var log *cleanupLog
+
+	BeforeEach(func() {
+		log = &cleanupLog{}
+	})
+
+	Context("context", func() {
+		It("plans the cleanup", func() {
+			ctx := attributes.New()
+			Expect(me.Cleanups(ctx).Add("cache", log.Step("cache"), me.CLEANUP_ATTRIBUTES)).To(Succeed())
+			Expect(me.Cleanups(ctx).Add("provider", log.Step("provider"), "cache")).To(Succeed())
+
+			Expect(me.CleanupPlan(ctx).Names()).To(Equal([]string{"provider", "cache", me.CLEANUP_FINALIZERS, me.CLEANUP_ATTRIBUTES}))
+			Expect(log.Get()).To(BeEmpty())
+		})
+
+		It("provides the cleanup graph for composite contexts", func() {
+			ctx := config.New()
+			Expect(me.Cleanups(ctx)).NotTo(BeNil())
+			Expect(me.CleanupPlan(ctx).Names()).To(Equal([]string{me.CLEANUP_FINALIZERS, me.CLEANUP_ATTRIBUTES}))
+		})
+
+		It("cleans up resources in dependency order", func() {
+			ctx := attributes.New()
+			Expect(me.Cleanups(ctx).Add("provider", log.Step("provider"), "cache", "client")).To(Succeed())
+			Expect(me.Cleanups(ctx).Add("cache", log.Step("cache"))).To(Succeed())
+			Expect(me.Cleanups(ctx).Add("client", log.Step("client"), "cache")).To(Succeed())
+			ctx.Finalizer().With(func() error {
+				log.Step("finalizer")()
+				return nil
+			})
+			ctx = nil
+
+			Eventually(func() []string {
+				runtime.GC()
+				time.Sleep(time.Millisecond)
+				return log.Get()
+			}).WithTimeout(5 * time.Second).Should(Equal([]string{"provider", "client", "cache", "finalizer"}))
+		})
+	})
+
+	Context("session", func() {
+		It("closes in dependency order", func() {
+			sess := me.NewSession()
+			_, err := sess.AddNamedCloser("provider", log.Close("provider"), "cache")
+			Expect(err).To(Succeed())
+			sess.AddCloser(log.Close("anonymous"))
+			_, err = sess.AddNamedCloser("cache", log.Close("cache"))
+			Expect(err).To(Succeed())
+
+			Expect(sess.ClosePlan().Names()).To(Equal([]string{"#1", "provider", "cache"}))
+			Expect(sess.Close()).To(Succeed())
+			Expect(log.Get()).To(Equal([]string{"anonymous", "provider", "cache"}))
+		})
+
+		It("rejects invalid closers", func() {
+			sess := me.NewSession()
+			_, err := sess.AddNamedCloser("#1", log.Close("x"))
+			Expect(err).To(MatchError(`closer name "#1" is invalid`))
+
+			_, err = sess.AddNamedCloser("a", log.Close("a"), "a")
+			Expect(err).To(MatchError("dependency cycle a->a"))
+		})
+
+		It("aggregates errors", func() {
+			sess := me.NewSession()
+			sess.AddCloser(log.Close("first"))
+			_, err := sess.AddNamedCloser("failing", errCloser("failed"))
+			Expect(err).To(Succeed())
+			Expect(sess.Close()).To(MatchError("closing session: cleanup: failing: unable to close: failed"))
+			Expect(log.Get()).To(Equal([]string{"first"}))
+		})
+	})
+})
+
+type errCloser string
+
+func (e errCloser) Close() error {
+	return fmt.Errorf("%s", string(e))
+}
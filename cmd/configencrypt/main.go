@@ -0,0 +1,77 @@
+// configencrypt encrypts selected fields of a config file in place,
+// so that it can be committed safely. Fields are given by element
+// paths, for example configurations[*].consumers[*].credentials.
+// Optionally, a new key file is generated.
+//
+//	configencrypt --key-file <key file> [--generate-key] [--document] [<config file> <field>...]
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/spf13/pflag"
+
+	"github.com/mandelsoft/ctxmgmt/config/cfgutils"
+	"github.com/mandelsoft/ctxmgmt/utils/encryption"
+)
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	flags := pflag.NewFlagSet("configencrypt", pflag.ContinueOnError)
+	keyFile := flags.String("key-file", "", "key file")
+	generate := flags.Bool("generate-key", false, "generate a new key file")
+	document := flags.Bool("document", false, "encrypt the complete document")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: configencrypt --key-file <key file> [--generate-key] [--document] [<config file> <field>...]\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *keyFile == "" {
+		flags.Usage()
+		return fmt.Errorf("key file required")
+	}
+
+	var key *encryption.Key
+	var err error
+	if *generate {
+		if _, err := os.Stat(*keyFile); err == nil {
+			return fmt.Errorf("key file %q already exists", *keyFile)
+		}
+		key, err = encryption.GenerateKey()
+		if err == nil {
+			err = encryption.WriteKeyFile(osfs.OsFs, *keyFile, key)
+		}
+	} else {
+		key, err = encryption.ReadKeyFile(osfs.OsFs, *keyFile)
+	}
+	if err != nil {
+		return err
+	}
+
+	if flags.NArg() == 0 {
+		if !*generate {
+			flags.Usage()
+			return fmt.Errorf("config file required")
+		}
+		return nil
+	}
+	fields := flags.Args()[1:]
+	if *document {
+		fields = append(fields, "")
+	}
+	if len(fields) == 0 {
+		flags.Usage()
+		return fmt.Errorf("fields to encrypt required")
+	}
+	return cfgutils.EncryptFile(flags.Arg(0), key, fields)
+}
@@ -0,0 +1,59 @@
+// configschema prints a JSON schema for generic.config documents
+// covering all config types and credential repository types known by
+// this library. It can be used by editors to validate and complete
+// config files.
+//
+//	configschema [--yaml] [<output file>]
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/pflag"
+
+	"github.com/mandelsoft/ctxmgmt"
+	"github.com/mandelsoft/ctxmgmt/config/cfgutils"
+	"github.com/mandelsoft/ctxmgmt/credentials"
+)
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	flags := pflag.NewFlagSet("configschema", pflag.ContinueOnError)
+	asYAML := flags.Bool("yaml", false, "print schema as YAML document")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: configschema [--yaml] [<output file>]\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return fmt.Errorf("too many arguments")
+	}
+
+	schema := cfgutils.Schema(credentials.New(ctxmgmt.MODE_DEFAULTED))
+	var data []byte
+	var err error
+	if *asYAML {
+		data, err = schema.AsYAML()
+	} else {
+		data, err = schema.AsJSON()
+		data = append(data, '\n')
+	}
+	if err != nil {
+		return err
+	}
+	if flags.NArg() == 1 {
+		return os.WriteFile(flags.Arg(0), data, 0o644)
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
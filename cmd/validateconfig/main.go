@@ -0,0 +1,74 @@
+// validateconfig validates config files against the config types,
+// credential repository types, attributes and identity matchers
+// known by this library. It reports problems with suggestions for
+// misspelled names and exits with a non-zero exit code, if errors
+// are found, so it can be used in CI pipelines.
+//
+//	validateconfig [--strict] [--json] <config file>...
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/pflag"
+
+	"github.com/mandelsoft/ctxmgmt"
+	"github.com/mandelsoft/ctxmgmt/config/cfgutils"
+	"github.com/mandelsoft/ctxmgmt/config/validation"
+	"github.com/mandelsoft/ctxmgmt/credentials"
+)
+
+const (
+	EXIT_OK       = 0
+	EXIT_FINDINGS = 1
+	EXIT_FAILURE  = 2
+)
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	flags := pflag.NewFlagSet("validateconfig", pflag.ContinueOnError)
+	strict := flags.Bool("strict", false, "fail on warnings, also")
+	asJSON := flags.Bool("json", false, "report findings as JSON")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: validateconfig [--strict] [--json] <config file>...\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return EXIT_FAILURE
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return EXIT_FAILURE
+	}
+
+	ctx := credentials.New(ctxmgmt.MODE_DEFAULTED)
+	report := &validation.Report{}
+	for _, path := range flags.Args() {
+		r, err := cfgutils.ValidateFile(ctx, path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s\n", err)
+			return EXIT_FAILURE
+		}
+		report.Findings = append(report.Findings, r.Findings...)
+	}
+
+	if *asJSON {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s\n", err)
+			return EXIT_FAILURE
+		}
+		fmt.Printf("%s\n", data)
+	} else {
+		fmt.Print(report.String())
+	}
+	if report.HasErrors(*strict) {
+		return EXIT_FINDINGS
+	}
+	return EXIT_OK
+}
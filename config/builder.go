@@ -20,6 +20,17 @@ func WithConfigTypeScheme(scheme ConfigTypeScheme) internal.Builder {
 	return internal.Builder{}.WithConfigTypeScheme(scheme)
 }
 
+func WithElementMode(element string, mode ctxmgmt.BuilderMode) internal.Builder {
+	return internal.Builder{}.WithElementMode(element, mode)
+}
+
 func New(mode ...ctxmgmt.BuilderMode) Context {
 	return internal.Builder{}.New(mode...)
 }
+
+// NewOverlay creates a copy-on-write overlay context for a parent
+// config context. Configuration applied to the parent is visible in
+// the overlay, while configuration applied to the overlay is kept local.
+func NewOverlay(parent Context) Context {
+	return internal.NewOverlay(parent)
+}
@@ -8,8 +8,6 @@ import (
 	"github.com/mandelsoft/goutils/general"
 	"github.com/mandelsoft/goutils/ioutils"
 	"github.com/mandelsoft/goutils/pkgutils"
-	"github.com/mandelsoft/spiff/features"
-	"github.com/mandelsoft/spiff/spiffing"
 	"github.com/mandelsoft/vfs/pkg/osfs"
 	"github.com/mandelsoft/vfs/pkg/vfs"
 
@@ -83,20 +81,29 @@ func ConfigureByData(ctx config.ContextProvider, data []byte, info string) error
 }
 
 func ConfigureByData2(ctx config.ContextProvider, data []byte, info string) (config.Config, error) {
-	var err error
-
-	sctx := spiffing.New().WithFeatures(features.INTERPOLATION, features.CONTROL)
-	data, err = spiffing.Process(sctx, spiffing.NewSourceData(info, data))
-	if err != nil {
-		return nil, errors.Wrapf(err, "processing ocm config %q", info)
-	}
-	cfg, err := ctx.ConfigContext().GetConfigForData(data, nil)
+	cfg, src, err := prepareConfig(ctx, data, info)
 	if err != nil {
-		return nil, errors.Wrapf(err, "invalid ocm config file %q", info)
+		return nil, err
 	}
-	err = ctx.ConfigContext().ApplyConfig(cfg, info)
+	err = ctx.ConfigContext().ApplyConfig(cfg, info, config.WithSource(src))
 	if err != nil {
 		return nil, errors.Wrapf(err, "cannot apply ocm config %q", info)
 	}
 	return cfg, nil
 }
+
+// prepareConfig preprocesses config data with spiff and the
+// registered config data processors (for example to decrypt
+// encrypted values) and decodes the resulting config object.
+// Additionally, it provides a description of the source
+// document used to track the provenance of the config.
+func prepareConfig(ctx config.ContextProvider, data []byte, info string) (config.Config, *config.ConfigSource, error) {
+	return config.DecodeConfigData(ctx, data, info)
+}
+
+// ProcessConfigData preprocesses config data with spiff like
+// ConfigureByData and provides the processed data together
+// with the description of the source document.
+func ProcessConfigData(data []byte, info string) ([]byte, *config.ConfigSource, error) {
+	return config.ProcessConfigData(data, info)
+}
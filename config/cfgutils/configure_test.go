@@ -0,0 +1,64 @@
+package cfgutils_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/ctxmgmt/config"
+	me "github.com/mandelsoft/ctxmgmt/config/cfgutils"
+	"github.com/mandelsoft/ctxmgmt/credentials"
+	credcfg "github.com/mandelsoft/ctxmgmt/credentials/config"
+)
+
+const nestedConfig = `# application config
+type: generic.config.mandelsoft.de/v1
+configurations:
+  - type: credentials.config.mandelsoft.de
+    consumers:
+      - identity:
+          type: test
+          host: configured
+        credentials:
+          - type: Credentials
+            properties:
+              user: alice
+`
+
+var _ = Describe("configure", func() {
+	var path string
+	var ctx credentials.Context
+
+	BeforeEach(func() {
+		path = filepath.Join(GinkgoT().TempDir(), ".appconfig")
+		ctx = credentials.New()
+	})
+
+	It("records the provenance of config files", func() {
+		Expect(os.WriteFile(path, []byte(nestedConfig), 0o600)).To(Succeed())
+		Expect(me.Configure(ctx, path)).To(Succeed())
+
+		p := ctx.ConfigContext().Provenance(config.ByType(credcfg.ConfigType))
+		Expect(p).To(HaveLen(1))
+		Expect(p[0].Location()).To(Equal(path + ":4:5 (configurations[0])"))
+		Expect(p[0].Path).To(Equal([]string{path, "config entry 0"}))
+	})
+
+	It("records positions of spiff processed config files", func() {
+		data := strings.ReplaceAll(nestedConfig, "user: alice", "user: (( \"ali\" \"ce\" ))")
+		Expect(os.WriteFile(path, []byte(data), 0o600)).To(Succeed())
+		Expect(me.Configure(ctx, path)).To(Succeed())
+		creds, err := credentials.CredentialsForConsumer(ctx, credentials.NewConsumerIdentity("test", "host", "configured"))
+		Expect(err).To(Succeed())
+		Expect(creds.GetProperty("user")).To(Equal("alice"))
+
+		p := ctx.ConfigContext().Provenance(config.ByType(credcfg.ConfigType))
+		Expect(p).To(HaveLen(1))
+		Expect(p[0].Source).To(Equal(path))
+		Expect(p[0].Element).To(Equal("configurations[0]"))
+		Expect(p[0].Line).To(BeNumerically(">", 0))
+	})
+})
@@ -0,0 +1,325 @@
+package cfgutils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mandelsoft/goutils/errors"
+	"github.com/mandelsoft/goutils/optionutils"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	"github.com/mandelsoft/ctxmgmt/config"
+	configcfg "github.com/mandelsoft/ctxmgmt/config/extensions/config"
+)
+
+// DEFAULT_APPLICATION_NAME is the default application name
+// used to derive the config file locations for Discover.
+const DEFAULT_APPLICATION_NAME = "app"
+
+// Configuration layers in the order of their precedence.
+// Configuration of later layers overrides configuration
+// of earlier ones.
+const (
+	// LAYER_SYSTEM is the system-wide configuration
+	// /etc/<name>/config.
+	LAYER_SYSTEM = "system"
+	// LAYER_USER is the user configuration
+	// $XDG_CONFIG_HOME/<name>/config (default ~/.config/<name>/config)
+	// and ~/.<name>config.
+	LAYER_USER = "user"
+	// LAYER_PROJECT are the files .<name>config found in the working
+	// directory and its parent directories. Files in inner directories
+	// override files in outer directories.
+	LAYER_PROJECT = "project"
+	// LAYER_ENV are the files listed in the environment variable
+	// <NAME>_CONFIG (separated by the path list separator).
+	LAYER_ENV = "env"
+)
+
+// LayerSetName provides the name of the config set
+// used to record a configuration layer.
+func LayerSetName(layer string) string {
+	return "layer." + layer
+}
+
+type DiscoveryOption = optionutils.Option[*DiscoveryOptions]
+
+type DiscoveryOptions struct {
+	// FileSystem is the file system used to read config files.
+	// The default is the OS filesystem.
+	FileSystem vfs.FileSystem
+	// Name is the application name used to derive the
+	// config file locations. The default is DEFAULT_APPLICATION_NAME.
+	Name string
+	// SystemDir is the directory for system-wide configuration.
+	// The default is /etc/<name>.
+	SystemDir string
+	// HomeDir is the home directory of the user.
+	// The default is determined by the OS.
+	HomeDir string
+	// ConfigHome is the XDG config home directory.
+	// The default is taken from XDG_CONFIG_HOME or ~/.config.
+	ConfigHome string
+	// WorkingDir is the directory to start the search for
+	// project configuration. The default is the working
+	// directory of the filesystem.
+	WorkingDir string
+	// Getenv is used to access environment variables.
+	// The default is os.Getenv.
+	Getenv func(string) string
+}
+
+var _ DiscoveryOption = (*DiscoveryOptions)(nil)
+
+func (o *DiscoveryOptions) ApplyTo(opts *DiscoveryOptions) {
+	optionutils.Transfer(&opts.FileSystem, o.FileSystem)
+	optionutils.Transfer(&opts.Name, o.Name)
+	optionutils.Transfer(&opts.SystemDir, o.SystemDir)
+	optionutils.Transfer(&opts.HomeDir, o.HomeDir)
+	optionutils.Transfer(&opts.ConfigHome, o.ConfigHome)
+	optionutils.Transfer(&opts.WorkingDir, o.WorkingDir)
+	if o.Getenv != nil {
+		opts.Getenv = o.Getenv
+	}
+}
+
+// WithFileSystem sets the file system used to read config files.
+func WithFileSystem(fs vfs.FileSystem) DiscoveryOption {
+	return &DiscoveryOptions{FileSystem: fs}
+}
+
+// WithApplicationName sets the application name used
+// to derive the config file locations.
+func WithApplicationName(name string) DiscoveryOption {
+	return &DiscoveryOptions{Name: name}
+}
+
+// WithSystemDir sets the directory for system-wide configuration.
+func WithSystemDir(dir string) DiscoveryOption {
+	return &DiscoveryOptions{SystemDir: dir}
+}
+
+// WithHomeDir sets the home directory of the user.
+func WithHomeDir(dir string) DiscoveryOption {
+	return &DiscoveryOptions{HomeDir: dir}
+}
+
+// WithConfigHome sets the XDG config home directory.
+func WithConfigHome(dir string) DiscoveryOption {
+	return &DiscoveryOptions{ConfigHome: dir}
+}
+
+// WithWorkingDir sets the directory to start the
+// search for project configuration.
+func WithWorkingDir(dir string) DiscoveryOption {
+	return &DiscoveryOptions{WorkingDir: dir}
+}
+
+// WithEnvironment sets the function used to access
+// environment variables.
+func WithEnvironment(getenv func(string) string) DiscoveryOption {
+	return &DiscoveryOptions{Getenv: getenv}
+}
+
+func (o *DiscoveryOptions) complete() error {
+	if o.FileSystem == nil {
+		o.FileSystem = osfs.OsFs
+	}
+	if o.Name == "" {
+		o.Name = DEFAULT_APPLICATION_NAME
+	}
+	if o.Getenv == nil {
+		o.Getenv = os.Getenv
+	}
+	if o.SystemDir == "" {
+		o.SystemDir = vfs.Join(o.FileSystem, vfs.PathSeparatorString+"etc", o.Name)
+	}
+	if o.HomeDir == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			o.HomeDir = home
+		}
+	}
+	if o.ConfigHome == "" {
+		o.ConfigHome = o.Getenv("XDG_CONFIG_HOME")
+		if o.ConfigHome == "" && o.HomeDir != "" {
+			o.ConfigHome = vfs.Join(o.FileSystem, o.HomeDir, ".config")
+		}
+	}
+	if o.WorkingDir == "" {
+		wd, err := o.FileSystem.Getwd()
+		if err != nil {
+			return errors.Wrapf(err, "cannot determine working directory")
+		}
+		o.WorkingDir = wd
+	}
+	return nil
+}
+
+// EnvVar provides the name of the environment variable
+// used to list additional config files.
+func (o *DiscoveryOptions) EnvVar() string {
+	return strings.ToUpper(strings.ReplaceAll(o.Name, "-", "_")) + "_CONFIG"
+}
+
+// Layer describes a discovered configuration layer.
+type Layer struct {
+	// Name is the layer name (see LAYER_SYSTEM, ...).
+	Name string
+	// ConfigSet is the name of the config set recording the layer.
+	ConfigSet string
+	// Files are the config files found for the layer
+	// in the order they are applied.
+	Files []string
+}
+
+// Discovery describes the result of a layered
+// configuration discovery.
+type Discovery struct {
+	// Layers are the layers with config files
+	// in the order they are applied.
+	Layers []Layer
+	// Config is the aggregated config of all layers.
+	Config config.Config
+}
+
+// Files provides all applied config files in the
+// order they are applied.
+func (d *Discovery) Files() []string {
+	var files []string
+	for _, l := range d.Layers {
+		files = append(files, l.Files...)
+	}
+	return files
+}
+
+// Discover configures a config context with configuration files
+// found in standard locations. The layers LAYER_SYSTEM, LAYER_USER,
+// LAYER_PROJECT and LAYER_ENV are applied in this order, so that
+// later layers override earlier ones. Every file is read like with
+// Configure and every layer is recorded as config set
+// (see LayerSetName). Files found for multiple locations are applied
+// only once for their first layer. Missing files are ignored except
+// for files explicitly listed in the environment.
+func Discover(ctx config.ContextProvider, opts ...DiscoveryOption) (*Discovery, error) {
+	if ctx == nil {
+		ctx = config.DefaultContext()
+	}
+	eff := optionutils.EvalOptions(opts...)
+	if err := eff.complete(); err != nil {
+		return nil, err
+	}
+	agg, err := configcfg.NewAggregator(false)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Discovery{}
+	seen := map[string]bool{}
+	for _, layer := range []string{LAYER_SYSTEM, LAYER_USER, LAYER_PROJECT, LAYER_ENV} {
+		candidates, required := eff.candidates(layer)
+		l := Layer{Name: layer, ConfigSet: LayerSetName(layer)}
+		set := config.NewConfigSet("configuration layer " + layer)
+		for _, path := range candidates {
+			path, err := vfs.Canonical(eff.FileSystem, path, false)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid config file path")
+			}
+			if seen[path] {
+				continue
+			}
+			ok, err := vfs.FileExists(eff.FileSystem, path)
+			if err != nil {
+				return nil, errors.Wrapf(err, "cannot access config file %q", path)
+			}
+			if !ok {
+				if required {
+					return nil, errors.ErrNotFound("config file", path)
+				}
+				continue
+			}
+			seen[path] = true
+			cfg, err := discoverFile(ctx, eff.FileSystem, path, l.ConfigSet)
+			if err != nil {
+				return nil, err
+			}
+			if err := set.AddConfig(cfg); err != nil {
+				return nil, err
+			}
+			if err := agg.AddConfig(cfg); err != nil {
+				return nil, err
+			}
+			l.Files = append(l.Files, path)
+		}
+		if len(l.Files) > 0 {
+			ctx.ConfigContext().AddConfigSet(l.ConfigSet, set)
+			d.Layers = append(d.Layers, l)
+		}
+	}
+	d.Config = agg.Get()
+	return d, nil
+}
+
+func discoverFile(ctx config.ContextProvider, fs vfs.FileSystem, path string, set string) (config.Config, error) {
+	data, err := vfs.ReadFile(fs, path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read config file %q", path)
+	}
+	cfg, src, err := prepareConfig(ctx, data, path)
+	if err != nil {
+		return nil, err
+	}
+	err = ctx.ConfigContext().ApplyConfig(cfg, path, config.WithSource(src), config.WithConfigSet(set))
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot apply ocm config %q", path)
+	}
+	return cfg, nil
+}
+
+// candidates provides the config file candidates for a layer
+// in the order they should be applied and whether they
+// are required to exist.
+func (o *DiscoveryOptions) candidates(layer string) ([]string, bool) {
+	fs := o.FileSystem
+	switch layer {
+	case LAYER_SYSTEM:
+		return []string{vfs.Join(fs, o.SystemDir, "config")}, false
+	case LAYER_USER:
+		var files []string
+		if o.ConfigHome != "" {
+			files = append(files, vfs.Join(fs, o.ConfigHome, o.Name, "config"))
+		}
+		if o.HomeDir != "" {
+			files = append(files, vfs.Join(fs, o.HomeDir, "."+o.Name+"config"))
+		}
+		return files, false
+	case LAYER_PROJECT:
+		var files []string
+		dir, err := vfs.Canonical(fs, o.WorkingDir, false)
+		if err != nil {
+			return nil, false
+		}
+		for {
+			files = append([]string{vfs.Join(fs, dir, "."+o.Name+"config")}, files...)
+			if vfs.IsRoot(fs, dir) {
+				break
+			}
+			parent := vfs.Dir(fs, dir)
+			if parent == dir {
+				break
+			}
+			dir = parent
+		}
+		return files, false
+	case LAYER_ENV:
+		var files []string
+		for _, f := range filepath.SplitList(o.Getenv(o.EnvVar())) {
+			if f != "" {
+				files = append(files, f)
+			}
+		}
+		return files, true
+	}
+	return nil, false
+}
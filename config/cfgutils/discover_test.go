@@ -0,0 +1,115 @@
+package cfgutils_test
+
+import (
+	"fmt"
+
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	"github.com/mandelsoft/ctxmgmt/config"
+	me "github.com/mandelsoft/ctxmgmt/config/cfgutils"
+	"github.com/mandelsoft/ctxmgmt/credentials"
+)
+
+const layerConfig = `
+type: credentials.config.mandelsoft.de
+consumers:
+  - identity:
+      type: test
+      host: %s
+    credentials:
+      - type: Credentials
+        properties:
+          user: %s
+`
+
+var _ = Describe("layered configuration discovery", func() {
+	var (
+		fs  vfs.FileSystem
+		ctx credentials.Context
+		env map[string]string
+	)
+
+	write := func(path string, host, user string) {
+		Expect(fs.MkdirAll(vfs.Dir(fs, path), 0o700)).To(Succeed())
+		Expect(vfs.WriteFile(fs, path, []byte(fmt.Sprintf(layerConfig, host, user)), 0o600)).To(Succeed())
+	}
+
+	user := func(host string) string {
+		creds := Must(credentials.CredentialsForConsumer(ctx, credentials.NewConsumerIdentity("test", "host", host)))
+		if creds == nil {
+			return ""
+		}
+		return creds.GetProperty("user")
+	}
+
+	discover := func() (*me.Discovery, error) {
+		return me.Discover(ctx,
+			me.WithFileSystem(fs),
+			me.WithHomeDir("/home/alice"),
+			me.WithWorkingDir("/home/alice/projects/demo/sub"),
+			me.WithEnvironment(func(k string) string { return env[k] }),
+		)
+	}
+
+	BeforeEach(func() {
+		fs = memoryfs.New()
+		ctx = credentials.New()
+		env = map[string]string{}
+	})
+
+	It("applies layers in order of precedence", func() {
+		write("/etc/app/config", "all", "system")
+		write("/home/alice/.config/app/config", "all", "xdg")
+		write("/home/alice/.appconfig", "user", "user")
+		write("/home/alice/projects/.appconfig", "all", "outer")
+		write("/home/alice/projects/demo/.appconfig", "all", "project")
+		write("/tmp/env.yaml", "env", "env")
+		env["APP_CONFIG"] = "/tmp/env.yaml"
+
+		d := Must(discover())
+		Expect(d.Files()).To(Equal([]string{
+			"/etc/app/config",
+			"/home/alice/.config/app/config",
+			"/home/alice/.appconfig",
+			"/home/alice/projects/.appconfig",
+			"/home/alice/projects/demo/.appconfig",
+			"/tmp/env.yaml",
+		}))
+		Expect(d.Layers).To(HaveLen(4))
+		Expect(d.Layers[1]).To(Equal(me.Layer{
+			Name:      me.LAYER_USER,
+			ConfigSet: me.LayerSetName(me.LAYER_USER),
+			Files:     []string{"/home/alice/.config/app/config", "/home/alice/.appconfig"},
+		}))
+
+		Expect(user("all")).To(Equal("project"))
+		Expect(user("user")).To(Equal("user"))
+		Expect(user("env")).To(Equal("env"))
+		Expect(user("unknown")).To(Equal(""))
+
+		Expect(ctx.ConfigContext().ConfigSetNames()).To(ConsistOf(
+			"layer.system", "layer.user", "layer.project", "layer.env",
+		))
+		p := ctx.ConfigContext().Provenance(config.ByConfigSet(me.LayerSetName(me.LAYER_ENV)))
+		Expect(p).To(HaveLen(1))
+		Expect(p[0].Source).To(Equal("/tmp/env.yaml"))
+	})
+
+	It("applies files only once", func() {
+		write("/home/alice/.appconfig", "user", "user")
+		d := Must(discover())
+		Expect(d.Layers).To(HaveLen(1))
+		Expect(d.Layers[0].Name).To(Equal(me.LAYER_USER))
+	})
+
+	It("fails for missing files listed in the environment", func() {
+		env["APP_CONFIG"] = "/tmp/missing.yaml"
+		_, err := discover()
+		Expect(err).To(MatchError(ContainSubstring("/tmp/missing.yaml")))
+	})
+})
@@ -0,0 +1,113 @@
+package cfgutils
+
+import (
+	"github.com/mandelsoft/goutils/errors"
+	"github.com/mandelsoft/goutils/general"
+	"github.com/mandelsoft/goutils/ioutils"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	"github.com/mandelsoft/ctxmgmt"
+	"github.com/mandelsoft/ctxmgmt/attrs/encryptionattr"
+	"github.com/mandelsoft/ctxmgmt/attrs/vfsattr"
+	"github.com/mandelsoft/ctxmgmt/config"
+	"github.com/mandelsoft/ctxmgmt/credentials"
+	"github.com/mandelsoft/ctxmgmt/credentials/identity/configkey"
+	"github.com/mandelsoft/ctxmgmt/utils/encryption"
+)
+
+// DECRYPTION_PROCESSOR is the name of the config data processor
+// decrypting encrypted values in config documents.
+const DECRYPTION_PROCESSOR = "decryption"
+
+func init() {
+	config.RegisterConfigDataProcessor(DECRYPTION_PROCESSOR, config.ConfigDataProcessorFunction(DecryptConfigData))
+}
+
+// DecryptConfigData decrypts the encrypted values of a config document
+// (see encryption.DecryptDocument) with the keys provided by
+// DecryptionKeys. It is used by ConfigureByData and Configure to
+// transparently decrypt config files.
+func DecryptConfigData(ctx config.ContextProvider, data []byte, info string) ([]byte, error) {
+	if ctx == nil {
+		ctx = config.DefaultContext()
+	}
+	return encryption.DecryptDocument(data, DecryptionKeys(ctx))
+}
+
+// DecryptionKeys provides the keys used to decrypt encrypted values in
+// config documents. Keys are taken from the key files configured for the
+// given context (see encryptionattr) and from its credentials context
+// (or the default credentials context) for the consumer type
+// configkey.CONSUMER_TYPE.
+func DecryptionKeys(ctx config.ContextProvider) encryption.KeyResolver {
+	var actx ctxmgmt.Context = ctx.ConfigContext()
+	if c, ok := ctx.(ctxmgmt.Context); ok {
+		actx = c
+	}
+	crctx := credentials.DefaultContext()
+	if p, ok := ctx.(credentials.ContextProvider); ok {
+		crctx = p.CredentialsContext()
+	}
+
+	var keys encryption.Keys
+	var errs error
+	return encryption.KeyResolverFunction(func(id string) (*encryption.Key, error) {
+		if keys == nil {
+			keys, errs = readKeyFiles(actx)
+		}
+		if k := keys[id]; k != nil {
+			return k, nil
+		}
+		k, err := configkey.GetKey(crctx, id)
+		if k != nil || err != nil {
+			return k, err
+		}
+		return nil, errs
+	})
+}
+
+func readKeyFiles(ctx ctxmgmt.Context) (encryption.Keys, error) {
+	fs := vfsattr.Get(ctx)
+	keys := encryption.NewKeys()
+	list := errors.ErrListf("reading key files")
+	for _, f := range encryptionattr.Get(ctx).KeyFiles {
+		path, err := ioutils.ResolvePath(f)
+		if err != nil {
+			list.Add(err)
+			continue
+		}
+		k, err := encryption.ReadKeyFile(fs, path)
+		if err != nil {
+			list.Add(err)
+			continue
+		}
+		keys.Add(k)
+	}
+	return keys, list.Result()
+}
+
+// EncryptFile encrypts the fields of a config file described by
+// element paths (see encryption.EncryptDocument) with the given key
+// and replaces the file by the resulting document.
+// The element path "" encrypts the complete document.
+func EncryptFile(path string, key *encryption.Key, fields []string, fss ...vfs.FileSystem) error {
+	fs := general.OptionalDefaulted[vfs.FileSystem](osfs.OsFs, fss...)
+	path, err := ioutils.ResolvePath(path)
+	if err != nil {
+		return err
+	}
+	fi, err := fs.Stat(path)
+	if err != nil {
+		return errors.Wrapf(err, "cannot read config file %q", path)
+	}
+	data, err := vfs.ReadFile(fs, path)
+	if err != nil {
+		return errors.Wrapf(err, "cannot read config file %q", path)
+	}
+	data, err = encryption.EncryptDocument(data, key, fields...)
+	if err != nil {
+		return errors.Wrapf(err, "config file %q", path)
+	}
+	return vfs.WriteFile(fs, path, data, fi.Mode().Perm())
+}
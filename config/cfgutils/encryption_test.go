@@ -0,0 +1,106 @@
+package cfgutils_test
+
+import (
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	"github.com/mandelsoft/ctxmgmt/attrs/encryptionattr"
+	"github.com/mandelsoft/ctxmgmt/attrs/vfsattr"
+	"github.com/mandelsoft/ctxmgmt/config"
+	me "github.com/mandelsoft/ctxmgmt/config/cfgutils"
+	"github.com/mandelsoft/ctxmgmt/credentials"
+	"github.com/mandelsoft/ctxmgmt/credentials/identity/configkey"
+	"github.com/mandelsoft/ctxmgmt/utils/encryption"
+)
+
+const passwordField = "configurations[*].consumers[*].credentials[*].properties.password"
+
+const secretConfig = `type: generic.config.mandelsoft.de/v1
+configurations:
+  - type: credentials.config.mandelsoft.de
+    consumers:
+      - identity:
+          type: test
+        credentials:
+          - type: Credentials
+            properties:
+              user: alice
+              password: secret
+`
+
+var _ = Describe("encrypted config files", func() {
+	var ctx credentials.Context
+	var fs vfs.FileSystem
+	var key *encryption.Key
+
+	BeforeEach(func() {
+		ctx = credentials.New()
+		fs = memoryfs.New()
+		vfsattr.Set(ctx, fs)
+		key = Must(encryption.GenerateKey())
+
+		MustBeSuccessful(vfs.WriteFile(fs, "/config.yaml", []byte(secretConfig), 0o600))
+		MustBeSuccessful(me.EncryptFile("/config.yaml", key, []string{passwordField}, fs))
+	})
+
+	password := func() string {
+		creds := Must(credentials.CredentialsForConsumer(ctx, credentials.NewConsumerIdentity("test")))
+		return creds.GetProperty("password")
+	}
+
+	It("encrypts fields of config files", func() {
+		data := string(Must(vfs.ReadFile(fs, "/config.yaml")))
+		Expect(data).NotTo(ContainSubstring("password: secret"))
+		Expect(data).To(ContainSubstring("user: alice"))
+		Expect(Must(fs.Stat("/config.yaml")).Mode().Perm()).To(Equal(vfs.FileMode(0o600)))
+	})
+
+	It("decrypts config files with key files", func() {
+		MustBeSuccessful(encryption.WriteKeyFile(fs, "/key", key))
+		MustBeSuccessful(encryptionattr.AddKeyFiles(ctx, "/key"))
+
+		MustBeSuccessful(me.Configure(ctx, "/config.yaml", fs))
+		Expect(password()).To(Equal("secret"))
+
+		p := ctx.ConfigContext().Provenance(config.BySource("/config.yaml"))
+		Expect(p).To(HaveLen(2))
+		Expect(p[1].Element).To(Equal("configurations[0]"))
+		Expect(p[1].Line).To(Equal(3))
+	})
+
+	It("decrypts config files with keys provided by the credentials context", func() {
+		ctx.SetCredentialsForConsumer(configkey.GetConsumerId(key.ID()), credentials.CredentialsFromList(configkey.ATTR_KEY, key.String()))
+
+		MustBeSuccessful(me.Configure(ctx, "/config.yaml", fs))
+		Expect(password()).To(Equal("secret"))
+	})
+
+	It("decrypts encrypted documents", func() {
+		MustBeSuccessful(me.EncryptFile("/config.yaml", key, []string{""}, fs))
+		ctx.SetCredentialsForConsumer(credentials.NewConsumerIdentity(configkey.CONSUMER_TYPE), credentials.CredentialsFromList(configkey.ATTR_KEY, key.String()))
+
+		MustBeSuccessful(me.Configure(ctx, "/config.yaml", fs))
+		Expect(password()).To(Equal("secret"))
+	})
+
+	It("fails without key", func() {
+		other := Must(encryption.GenerateKey())
+		ctx.SetCredentialsForConsumer(credentials.NewConsumerIdentity(configkey.CONSUMER_TYPE), credentials.CredentialsFromList(configkey.ATTR_KEY, other.String()))
+		MustBeSuccessful(encryptionattr.AddKeyFiles(ctx, "/missing"))
+
+		err := me.Configure(ctx, "/config.yaml", fs)
+		Expect(err).To(MatchError(ContainSubstring(`configurations[0].consumers[0].credentials[0].properties.password: key ` + key.ID() + `: reading key files: cannot read key file "/missing"`)))
+	})
+
+	It("validates decrypted config files", func() {
+		MustBeSuccessful(encryption.WriteKeyFile(fs, "/key", key))
+		MustBeSuccessful(encryptionattr.AddKeyFiles(ctx, "/key"))
+
+		report := Must(me.ValidateFile(ctx, "/config.yaml", fs))
+		Expect(report.Findings).To(BeEmpty())
+	})
+})
@@ -0,0 +1,279 @@
+package cfgutils
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/mandelsoft/goutils/errors"
+	"github.com/mandelsoft/goutils/maputils"
+	"sigs.k8s.io/yaml"
+
+	"github.com/mandelsoft/ctxmgmt"
+	attrcfg "github.com/mandelsoft/ctxmgmt/attributes/config/attrs"
+	"github.com/mandelsoft/ctxmgmt/config"
+	configcfg "github.com/mandelsoft/ctxmgmt/config/extensions/config"
+	"github.com/mandelsoft/ctxmgmt/credentials"
+	credcfg "github.com/mandelsoft/ctxmgmt/credentials/config"
+	"github.com/mandelsoft/ctxmgmt/credentials/extensions/repositories/directcreds"
+	"github.com/mandelsoft/ctxmgmt/utils"
+)
+
+// ENV_CONFIG_DATA is the suffix of the environment variable
+// used to provide an inline config document.
+const ENV_CONFIG_DATA = "CONFIG_DATA"
+
+// EnvMapper maps environment variables onto config objects.
+type EnvMapper interface {
+	// MapEnv maps the given variables to config objects.
+	// The variable names are passed without the prefix
+	// <NAME>_<KEY>_ selecting the mapper.
+	MapEnv(ctx config.Context, vars map[string]string) ([]config.Config, error)
+}
+
+type EnvMapperFunction func(ctx config.Context, vars map[string]string) ([]config.Config, error)
+
+func (f EnvMapperFunction) MapEnv(ctx config.Context, vars map[string]string) ([]config.Config, error) {
+	return f(ctx, vars)
+}
+
+type envMapperRegistry struct {
+	lock    sync.Mutex
+	mappers map[string]EnvMapper
+}
+
+var envMappers = &envMapperRegistry{mappers: map[string]EnvMapper{}}
+
+// RegisterEnvMapper registers a mapper for the environment
+// variables with the prefix <NAME>_<KEY>_.
+func RegisterEnvMapper(key string, m EnvMapper) {
+	envMappers.lock.Lock()
+	defer envMappers.lock.Unlock()
+	envMappers.mappers[strings.ToUpper(key)] = m
+}
+
+func (r *envMapperRegistry) get() map[string]EnvMapper {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return maps.Clone(r.mappers)
+}
+
+func init() {
+	RegisterEnvMapper("ATTR", EnvMapperFunction(mapAttributes))
+	RegisterEnvMapper("CONSUMER", EnvMapperFunction(mapConsumers))
+}
+
+// ConfigureByEnv configures a config context from environment variables
+// with the prefix <NAME>_, where the name is the upper-cased application
+// name (see DEFAULT_APPLICATION_NAME). The environment is given as list
+// of <key>=<value> entries, by default os.Environ() is used.
+//
+// The variable <NAME>_CONFIG_DATA may contain an inline config document,
+// which is processed like with ConfigureByData. Variables with the
+// prefix <NAME>_<KEY>_ are mapped to config objects by the mapper
+// registered for <KEY> (see RegisterEnvMapper). The following mappers
+// are provided:
+//   - ATTR: <NAME>_ATTR_<ATTRIBUTE>=<value> sets an attribute. The attribute
+//     name (or a shortcut) is upper-cased and non-alphanumeric characters
+//     are replaced by "_". The value is parsed as YAML or taken as string.
+//   - CONSUMER: <NAME>_CONSUMER_<KEY>_IDENTITY=<attr>=<value>,...
+//     describes the identity of a credential consumer and
+//     <NAME>_CONSUMER_<KEY>_<PROPERTY>=<value> its credential properties.
+//     <KEY> must not contain "_", property names are converted to
+//     lower camel case, for example IDENTITY_TOKEN to identityToken.
+//
+// Every source is applied separately with its own provenance description.
+// The aggregated config is returned.
+func ConfigureByEnv(ctx config.ContextProvider, name string, environ ...string) (config.Config, error) {
+	if ctx == nil {
+		ctx = config.DefaultContext()
+	}
+	if name == "" {
+		name = DEFAULT_APPLICATION_NAME
+	}
+	if len(environ) == 0 {
+		environ = os.Environ()
+	}
+	prefix := envName(name) + "_"
+	vars := map[string]string{}
+	for _, e := range environ {
+		if k, v, ok := strings.Cut(e, "="); ok && strings.HasPrefix(k, prefix) {
+			vars[k[len(prefix):]] = v
+		}
+	}
+
+	agg, err := configcfg.NewAggregator(false)
+	if err != nil {
+		return nil, err
+	}
+	cctx := ctx.ConfigContext()
+	if data, ok := vars[ENV_CONFIG_DATA]; ok {
+		info := "$" + prefix + ENV_CONFIG_DATA
+		cfg, src, err := prepareConfig(ctx, []byte(data), info)
+		if err != nil {
+			return nil, err
+		}
+		if err = cctx.ApplyConfig(cfg, info, config.WithSource(src)); err != nil {
+			return nil, errors.Wrapf(err, "cannot apply ocm config %q", info)
+		}
+		if err = agg.AddConfig(cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	mappers := envMappers.get()
+	for _, key := range maputils.OrderedKeys(mappers) {
+		sub := map[string]string{}
+		for k, v := range vars {
+			if n, ok := strings.CutPrefix(k, key+"_"); ok && n != "" {
+				sub[n] = v
+			}
+		}
+		if len(sub) == 0 {
+			continue
+		}
+		info := "$" + prefix + key + "_*"
+		cfgs, err := mappers[key].MapEnv(cctx, sub)
+		if err != nil {
+			return nil, errors.Wrapf(err, "environment %s", info)
+		}
+		for _, cfg := range cfgs {
+			if err = cctx.ApplyConfig(cfg, info, config.WithSource(config.NewConfigSource(info))); err != nil {
+				return nil, errors.Wrapf(err, "cannot apply config from environment %s", info)
+			}
+			if err = agg.AddConfig(cfg); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return agg.Get(), nil
+}
+
+var nonAlphaNum = regexp.MustCompile("[^A-Z0-9]+")
+
+// envName converts a name into the form used for environment variables.
+func envName(name string) string {
+	return nonAlphaNum.ReplaceAllString(strings.ToUpper(name), "_")
+}
+
+// camelCase converts an upper-case environment variable name
+// into lower camel case.
+func camelCase(name string) string {
+	parts := strings.Split(strings.ToLower(name), "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// envValue provides the JSON representation of a variable value.
+// Values not parsable as YAML are taken as string.
+func envValue(v string) []byte {
+	var value interface{}
+	if err := yaml.Unmarshal([]byte(v), &value); err != nil || value == nil {
+		value = v
+	}
+	data, err := yaml.Marshal(value)
+	if err == nil {
+		data, err = yaml.YAMLToJSON(data)
+	}
+	if err != nil {
+		data = []byte(fmt.Sprintf("%q", v))
+	}
+	return data
+}
+
+func mapAttributes(ctx config.Context, vars map[string]string) ([]config.Config, error) {
+	names := map[string]string{}
+	scheme := ctxmgmt.DefaultAttributeScheme
+	for _, n := range scheme.KnownTypeNames() {
+		names[envName(n)] = n
+	}
+	for s := range scheme.Shortcuts() {
+		names[envName(s)] = s
+	}
+
+	cfg := attrcfg.New()
+	list := errors.ErrListf("attributes")
+	for _, k := range maputils.OrderedKeys(vars) {
+		n, ok := names[k]
+		if !ok {
+			list.Add(errors.ErrUnknown("attribute", k))
+			continue
+		}
+		err := cfg.AddRawAttribute(n, envValue(vars[k]))
+		if err != nil {
+			// fallback to plain string value
+			if cfg.AddRawAttribute(n, []byte(fmt.Sprintf("%q", vars[k]))) == nil {
+				err = nil
+			}
+		}
+		list.Add(errors.Wrapf(err, "attribute %q", n))
+	}
+	if err := list.Result(); err != nil {
+		return nil, err
+	}
+	return []config.Config{cfg}, nil
+}
+
+func mapConsumers(ctx config.Context, vars map[string]string) ([]config.Config, error) {
+	type consumer struct {
+		id    credentials.ConsumerIdentity
+		props utils.Properties
+	}
+	consumers := map[string]*consumer{}
+	for k, v := range vars {
+		key, prop, ok := strings.Cut(k, "_")
+		if !ok || prop == "" {
+			return nil, errors.ErrInvalid("consumer variable", k)
+		}
+		c := consumers[key]
+		if c == nil {
+			c = &consumer{props: utils.Properties{}}
+			consumers[key] = c
+		}
+		if prop == "IDENTITY" {
+			id, err := parseIdentity(v)
+			if err != nil {
+				return nil, errors.Wrapf(err, "consumer %q", key)
+			}
+			c.id = id
+		} else {
+			c.props[camelCase(prop)] = v
+		}
+	}
+
+	cfg := credcfg.New()
+	for _, key := range maputils.OrderedKeys(consumers) {
+		c := consumers[key]
+		if len(c.id) == 0 {
+			return nil, errors.Newf("consumer %q: identity missing", key)
+		}
+		if err := cfg.AddConsumer(c.id, directcreds.NewCredentials(c.props)); err != nil {
+			return nil, errors.Wrapf(err, "consumer %q", key)
+		}
+	}
+	return []config.Config{cfg}, nil
+}
+
+// parseIdentity parses a consumer identity given as
+// comma-separated list of <attr>=<value> pairs.
+func parseIdentity(s string) (credentials.ConsumerIdentity, error) {
+	id := credentials.ConsumerIdentity{}
+	for _, e := range strings.Split(s, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(e), "=")
+		if !ok || k == "" {
+			return nil, errors.ErrInvalid("identity attribute", e)
+		}
+		id[k] = v
+	}
+	if id[credentials.ID_TYPE] == "" {
+		return nil, errors.Newf("identity type missing")
+	}
+	return id, nil
+}
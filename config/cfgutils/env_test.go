@@ -0,0 +1,74 @@
+package cfgutils_test
+
+import (
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/ctxmgmt/attrs/tmpcache"
+	"github.com/mandelsoft/ctxmgmt/config"
+	me "github.com/mandelsoft/ctxmgmt/config/cfgutils"
+	"github.com/mandelsoft/ctxmgmt/credentials"
+)
+
+const inlineConfig = `
+type: credentials.config.mandelsoft.de
+consumers:
+  - identity:
+      type: test
+      host: inline
+    credentials:
+      - type: Credentials
+        properties:
+          user: alice
+`
+
+var _ = Describe("environment configuration", func() {
+	var ctx credentials.Context
+
+	creds := func(id credentials.ConsumerIdentity) credentials.Credentials {
+		return Must(credentials.CredentialsForConsumer(ctx, id))
+	}
+
+	BeforeEach(func() {
+		ctx = credentials.New()
+	})
+
+	It("applies an inline config document", func() {
+		Must(me.ConfigureByEnv(ctx, "app", "APP_CONFIG_DATA="+inlineConfig, "OTHER_CONFIG_DATA=invalid"))
+		Expect(creds(credentials.NewConsumerIdentity("test", "host", "inline")).GetProperty("user")).To(Equal("alice"))
+
+		p := ctx.ConfigContext().Provenance(config.BySource("$APP_CONFIG_DATA"))
+		Expect(p).To(HaveLen(1))
+		Expect(p[0].Line).To(Equal(2))
+	})
+
+	It("maps consumer variables", func() {
+		Must(me.ConfigureByEnv(ctx, "my-app",
+			"MY_APP_CONSUMER_GHCR_IDENTITY=type=OCIRegistry,hostname=ghcr.io",
+			"MY_APP_CONSUMER_GHCR_USERNAME=alice",
+			"MY_APP_CONSUMER_GHCR_IDENTITY_TOKEN=token",
+		))
+		c := creds(credentials.NewConsumerIdentity("OCIRegistry", "hostname", "ghcr.io"))
+		Expect(c.Properties()).To(YAMLEqual(`{"username": "alice", "identityToken": "token"}`))
+
+		p := ctx.ConfigContext().Provenance(config.BySource("$MY_APP_CONSUMER_*"))
+		Expect(p).To(HaveLen(1))
+		Expect(p[0].Description).To(Equal("$MY_APP_CONSUMER_*"))
+	})
+
+	It("maps attribute variables", func() {
+		Must(me.ConfigureByEnv(ctx, "app", "APP_ATTR_BLOBCACHE=/tmp/cache"))
+		Expect(tmpcache.Get(ctx).Path).To(Equal("/tmp/cache"))
+	})
+
+	It("rejects unknown attributes", func() {
+		_, err := me.ConfigureByEnv(ctx, "app", "APP_ATTR_UNKNOWN=value")
+		Expect(err).To(MatchError(ContainSubstring(`attribute "UNKNOWN" is unknown`)))
+	})
+
+	It("requires a consumer identity", func() {
+		_, err := me.ConfigureByEnv(ctx, "app", "APP_CONSUMER_GHCR_USERNAME=alice")
+		Expect(err).To(MatchError(ContainSubstring("identity missing")))
+	})
+})
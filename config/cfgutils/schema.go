@@ -0,0 +1,87 @@
+package cfgutils
+
+import (
+	"reflect"
+
+	"github.com/mandelsoft/goutils/maputils"
+
+	"github.com/mandelsoft/ctxmgmt/config"
+	configcfg "github.com/mandelsoft/ctxmgmt/config/extensions/config"
+	"github.com/mandelsoft/ctxmgmt/credentials"
+	"github.com/mandelsoft/ctxmgmt/utils/jsonschema"
+	"github.com/mandelsoft/ctxmgmt/utils/runtime"
+)
+
+// Names of the definitions of the config schema document
+// describing any object of the appropriate kind. The schemas of the
+// dedicated types are defined with the name prefixed by <kind>.,
+// for example config.generic.config.mandelsoft.de.
+const (
+	SCHEMA_CONFIG      = "config"
+	SCHEMA_REPOSITORY  = "repository"
+	SCHEMA_CREDENTIALS = "credentials"
+)
+
+// Schema provides a JSON schema document for generic.config documents
+// as used for config files, which can be used by editors to validate
+// and complete config files.
+// It covers all config types known by the config context and all
+// credential repository types known by the credentials context of
+// the given context (or the default credentials context). The schemas of
+// the types are derived from their Go types (see runtime.TypeSchema).
+// Nested generic config objects, repository and credentials specifications
+// refer to the appropriate definitions of the document.
+func Schema(ctx config.ContextProvider) *jsonschema.Schema {
+	if ctx == nil {
+		ctx = config.DefaultContext()
+	}
+	crctx := credentials.DefaultContext()
+	if p, ok := ctx.(credentials.ContextProvider); ok {
+		crctx = p.CredentialsContext()
+	}
+
+	refs := jsonschema.References{
+		reflect.TypeFor[config.GenericConfig]():               jsonschema.RefTo(SCHEMA_CONFIG),
+		reflect.TypeFor[credentials.GenericRepositorySpec]():  jsonschema.RefTo(SCHEMA_REPOSITORY),
+		reflect.TypeFor[credentials.GenericCredentialsSpec](): jsonschema.RefTo(SCHEMA_CREDENTIALS),
+	}
+
+	defs := map[string]*jsonschema.Schema{}
+	define := func(kind string, schemas map[string]*jsonschema.Schema) {
+		alt := jsonschema.OneOf()
+		for _, n := range maputils.OrderedKeys(schemas) {
+			defs[kind+"."+n] = schemas[n]
+			alt.OneOf = append(alt.OneOf, jsonschema.RefTo(kind+"."+n))
+		}
+		defs[kind] = alt
+	}
+
+	configs := ctx.ConfigContext().ConfigTypes().Schemas(refs)
+	define(SCHEMA_CONFIG, configs)
+
+	repos := crctx.RepositoryTypes().Schemas(refs)
+	define(SCHEMA_REPOSITORY, repos)
+
+	// credentials specifications are repository specifications
+	// with an optional credentials name.
+	creds := map[string]*jsonschema.Schema{}
+	for n, s := range repos {
+		c := s.Copy()
+		if c.Type == jsonschema.TYPE_OBJECT {
+			c.Properties["credentialsName"] = &jsonschema.Schema{Type: jsonschema.TYPE_STRING}
+		}
+		creds[n] = c
+	}
+	define(SCHEMA_CREDENTIALS, creds)
+
+	root := jsonschema.OneOf()
+	for _, n := range maputils.OrderedKeys(configs) {
+		if k, _ := runtime.KindVersion(n); k == configcfg.ConfigType {
+			root.OneOf = append(root.OneOf, jsonschema.RefTo(SCHEMA_CONFIG+"."+n))
+		}
+	}
+	root.Schema = jsonschema.DRAFT
+	root.Title = configcfg.ConfigType
+	root.Defs = defs
+	return root
+}
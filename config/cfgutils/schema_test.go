@@ -0,0 +1,84 @@
+package cfgutils_test
+
+import (
+	"encoding/json"
+
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	me "github.com/mandelsoft/ctxmgmt/config/cfgutils"
+	"github.com/mandelsoft/ctxmgmt/credentials"
+	"github.com/mandelsoft/ctxmgmt/utils/jsonschema"
+)
+
+const schemaConfig = `
+type: generic.config.mandelsoft.de/v1
+configurations:
+  - type: credentials.config.mandelsoft.de
+    consumers:
+      - identity:
+          type: test
+        credentials:
+          - type: Credentials
+            properties:
+              user: alice
+    repositories:
+      - repository:
+          type: DockerConfig/v1
+          dockerConfigFile: ~/.docker/config.json
+sets:
+  cache:
+    description: blob cache
+    configurations:
+      - type: attributes.config.mandelsoft.de
+        attributes:
+          blobcache: /tmp/cache
+`
+
+var _ = Describe("config schema", func() {
+	var schema *jsonschema.Schema
+
+	BeforeEach(func() {
+		schema = me.Schema(credentials.New())
+	})
+
+	It("provides a schema document", func() {
+		Expect(schema.Schema).To(Equal(jsonschema.DRAFT))
+		Expect(schema.OneOf).To(ConsistOf(
+			jsonschema.RefTo("config.generic.config.mandelsoft.de"),
+			jsonschema.RefTo("config.generic.config.mandelsoft.de/v1"),
+		))
+		Expect(schema.Defs).To(HaveKey("config.credentials.config.mandelsoft.de"))
+		Expect(schema.Defs).To(HaveKey("repository.Credentials/v1"))
+		Expect(schema.Defs["credentials.Credentials/v1"].Properties).To(HaveKey("credentialsName"))
+		Expect(schema.Defs["repository.Credentials/v1"].Properties).NotTo(HaveKey("credentialsName"))
+
+		data := Must(json.Marshal(schema))
+		Expect(Must(jsonschema.Parse(data))).To(Equal(schema))
+	})
+
+	It("accepts valid config documents", func() {
+		MustBeSuccessful(schema.Validate([]byte(schemaConfig)))
+	})
+
+	It("rejects unknown config types", func() {
+		Expect(schema.Validate([]byte(`
+type: generic.config.mandelsoft.de
+configurations:
+  - type: unknown.config.mandelsoft.de
+`))).To(MatchError(ContainSubstring("must match exactly one of 2 alternatives (matched 0)")))
+	})
+
+	It("rejects unknown fields of nested repository specifications", func() {
+		Expect(schema.Validate([]byte(`
+type: generic.config.mandelsoft.de
+configurations:
+  - type: credentials.config.mandelsoft.de
+    repositories:
+      - repository:
+          type: DockerConfig
+          configFile: ~/.docker/config.json
+`))).To(MatchError(ContainSubstring("must match exactly one of 2 alternatives (matched 0)")))
+	})
+})
@@ -0,0 +1,237 @@
+package cfgutils
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+
+	"github.com/mandelsoft/goutils/errors"
+	"github.com/mandelsoft/goutils/maputils"
+	"github.com/mandelsoft/goutils/optionutils"
+	"sigs.k8s.io/yaml"
+
+	"github.com/mandelsoft/ctxmgmt"
+	attrscfg "github.com/mandelsoft/ctxmgmt/attributes/config/attrs"
+	"github.com/mandelsoft/ctxmgmt/config"
+	configcfg "github.com/mandelsoft/ctxmgmt/config/extensions/config"
+	"github.com/mandelsoft/ctxmgmt/credentials"
+	credcfg "github.com/mandelsoft/ctxmgmt/credentials/config"
+	"github.com/mandelsoft/ctxmgmt/credentials/extensions/repositories/directcreds"
+)
+
+// STATE_ENV is the name of the environment variable used
+// to pass an exported context state to a sub process.
+const STATE_ENV = "CTXMGMT_CONTEXT_STATE"
+
+// STATE_INFO is the description used for applying an exported state.
+const STATE_INFO = "exported context state"
+
+type ExportOption = optionutils.Option[*ExportOptions]
+
+type ExportOptions struct {
+	// SkipUnexportable skips state elements, which cannot be serialized,
+	// instead of failing.
+	SkipUnexportable bool
+}
+
+var _ ExportOption = (*ExportOptions)(nil)
+
+func (o *ExportOptions) ApplyTo(opts *ExportOptions) {
+	opts.SkipUnexportable = o.SkipUnexportable
+}
+
+type skipUnexportable bool
+
+func (o skipUnexportable) ApplyTo(opts *ExportOptions) {
+	opts.SkipUnexportable = bool(o)
+}
+
+// SkipUnexportable requests to skip state elements, which cannot
+// be serialized, for example attribute values without encoding
+// or programmatically set credentials sources.
+func SkipUnexportable(b ...bool) ExportOption {
+	return skipUnexportable(optionutils.BoolOption(b...))
+}
+
+// ExportState provides the reproducible state of a context as generic
+// config object. The state consists of
+//   - the config sets
+//   - the applied config objects (since the last reset) in generation order
+//   - the attribute settings visible for the context up to its attributes context
+//   - the consumer credentials explicitly set for a credentials context.
+//
+// Applying the config object to a new context (see LoadState) reconstructs
+// an equivalent context. Attribute values are exported with their actual
+// value, even if they are provided by a lazy attribute source.
+func ExportState(ctx config.ContextProvider, opts ...ExportOption) (*configcfg.Config, error) {
+	eff := optionutils.EvalOptions(opts...)
+	cctx := ctx.ConfigContext()
+	list := errors.ErrListf("exporting context state")
+
+	skip := func(err error) {
+		if !eff.SkipUnexportable {
+			list.Add(err)
+		}
+	}
+
+	state := configcfg.New()
+	for _, n := range cctx.ConfigSetNames() {
+		if set := cctx.GetConfigSet(n); set != nil {
+			state.AddConfigSet(n, set)
+		}
+	}
+
+	_, cfgs := cctx.GetConfig(config.AllGenerations, nil)
+	for i, c := range cfgs {
+		if _, ok := c.(*configcfg.Config); ok {
+			// the effect of generic config objects is completely
+			// described by the sets and the config objects applied by them.
+			continue
+		}
+		err := state.AddConfig(c)
+		if err != nil {
+			skip(errors.Wrapf(err, "config entry %d (%s)", i, c.GetType()))
+		}
+	}
+
+	var actx ctxmgmt.Context = cctx
+	crctx, _ := ctx.(credentials.ContextProvider)
+	if crctx != nil {
+		actx = crctx.CredentialsContext()
+	}
+	if attrs := exportAttributes(actx, cctx.AttributesContext(), skip); attrs != nil {
+		list.Add(state.AddConfig(attrs))
+	}
+	if crctx != nil {
+		if creds := exportCredentials(crctx.CredentialsContext(), skip); creds != nil {
+			list.Add(state.AddConfig(creds))
+		}
+	}
+	return state, list.Result()
+}
+
+// exportAttributes exports the attribute settings visible for the given
+// context up to its attributes context. Settings of more specific attribute
+// sets override inherited ones.
+func exportAttributes(ctx ctxmgmt.Context, attrs ctxmgmt.AttributesContext, skip func(error)) config.Config {
+	values := map[string]*ctxmgmt.AttributeDescription{}
+	for desc := ctxmgmt.DescribeAttributes(ctx.GetAttributes()); desc != nil; desc = desc.Parent {
+		for _, v := range desc.Values {
+			if values[v.Name] == nil {
+				values[v.Name] = v
+			}
+		}
+		if desc.Context == attrs.GetId() {
+			break
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	cfg := attrscfg.New()
+	for _, n := range maputils.OrderedKeys(values) {
+		v := values[n]
+		switch {
+		case v.Error != "":
+			skip(fmt.Errorf("attribute %q: %s", v.Name, v.Error))
+		case len(v.Value) > 0:
+			err := cfg.AddRawAttribute(v.Name, v.Value)
+			if err != nil {
+				skip(errors.Wrapf(err, "attribute %q", v.Name))
+			}
+		}
+	}
+	if len(cfg.Attributes) == 0 {
+		return nil
+	}
+	return cfg
+}
+
+func exportCredentials(ctx credentials.Context, skip func(error)) config.Config {
+	consumers := ctx.GetExplicitConsumers()
+	if len(consumers) == 0 {
+		return nil
+	}
+	cfg := credcfg.New()
+	for _, c := range consumers {
+		specs, err := credentialsSpecs(c.Credentials)
+		if err == nil {
+			err = cfg.AddConsumer(c.Identity, specs...)
+		}
+		if err != nil {
+			skip(errors.Wrapf(err, "consumer %s", c.Identity))
+		}
+	}
+	if len(cfg.Consumers) == 0 {
+		return nil
+	}
+	return cfg
+}
+
+func credentialsSpecs(src credentials.CredentialsSource) ([]credentials.CredentialsSpec, error) {
+	switch s := src.(type) {
+	case credentials.CredentialsSpec:
+		return []credentials.CredentialsSpec{s}, nil
+	case credentials.Credentials:
+		return []credentials.CredentialsSpec{directcreds.NewCredentials(s.Properties())}, nil
+	case credentials.CredentialsChain:
+		var specs []credentials.CredentialsSpec
+		for _, e := range s {
+			sub, err := credentialsSpecs(e)
+			if err != nil {
+				return nil, err
+			}
+			specs = append(specs, sub...)
+		}
+		return specs, nil
+	default:
+		return nil, fmt.Errorf("credentials source of type %T cannot be exported", src)
+	}
+}
+
+// ExportStateData provides the exported context state
+// as serialized generic config object.
+func ExportStateData(ctx config.ContextProvider, opts ...ExportOption) ([]byte, error) {
+	state, err := ExportState(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(state)
+}
+
+// ExportStateEnv provides the exported context state as environment
+// setting (<name>=<value>) for the variable STATE_ENV, which can be passed
+// to a sub process.
+func ExportStateEnv(ctx config.ContextProvider, opts ...ExportOption) (string, error) {
+	data, err := ExportStateData(ctx, opts...)
+	if err != nil {
+		return "", err
+	}
+	return STATE_ENV + "=" + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// LoadState applies a context state exported by ExportStateData to a context.
+// In contrast to ConfigureByData, the data is not preprocessed by spiff,
+// because it is applied as it has been exported.
+func LoadState(ctx config.ContextProvider, data []byte) error {
+	cfg, err := ctx.ConfigContext().GetConfigForData(data, nil)
+	if err != nil {
+		return errors.Wrapf(err, "invalid context state")
+	}
+	return errors.Wrapf(ctx.ConfigContext().ApplyConfig(cfg, STATE_INFO), "cannot apply context state")
+}
+
+// LoadStateFromEnv applies a context state passed by the environment
+// variable STATE_ENV (see ExportStateEnv) to a context.
+// It returns false, if the variable is not set.
+func LoadStateFromEnv(ctx config.ContextProvider) (bool, error) {
+	value, ok := os.LookupEnv(STATE_ENV)
+	if !ok {
+		return false, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return true, errors.Wrapf(err, "invalid context state in %s", STATE_ENV)
+	}
+	return true, LoadState(ctx, data)
+}
@@ -0,0 +1,99 @@
+package cfgutils_test
+
+import (
+	"os"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/ctxmgmt"
+	"github.com/mandelsoft/ctxmgmt/attrs/tmpcache"
+	"github.com/mandelsoft/ctxmgmt/config"
+	me "github.com/mandelsoft/ctxmgmt/config/cfgutils"
+	"github.com/mandelsoft/ctxmgmt/credentials"
+	credcfg "github.com/mandelsoft/ctxmgmt/credentials/config"
+	"github.com/mandelsoft/ctxmgmt/credentials/extensions/repositories/directcreds"
+)
+
+var _ = Describe("context state export", func() {
+	var ctx credentials.Context
+
+	consumer := credentials.ConsumerIdentity{credentials.ID_TYPE: "test", "host": "explicit"}
+	configured := credentials.ConsumerIdentity{credentials.ID_TYPE: "test", "host": "configured"}
+
+	BeforeEach(func() {
+		ctx = credentials.New(ctxmgmt.MODE_DEFAULTED)
+
+		cfg := credcfg.New()
+		Expect(cfg.AddConsumer(configured, directcreds.NewCredentials(credentials.CredentialsFromList("user", "configured").Properties()))).To(Succeed())
+		Expect(ctx.ConfigContext().ApplyConfig(cfg, "test")).To(Succeed())
+
+		set := config.NewConfigSet("test set")
+		Expect(set.AddConfig(credcfg.New())).To(Succeed())
+		ctx.ConfigContext().AddConfigSet("optional", set)
+
+		tmpcache.Set(ctx.AttributesContext(), &tmpcache.Attribute{Path: "/tmp/cache"})
+		ctx.SetCredentialsForConsumer(consumer, credentials.NewCredentials(credentials.CredentialsFromList("user", "explicit").Properties()))
+	})
+
+	check := func(n credentials.Context) {
+		Expect(n.ConfigContext().ConfigSetNames()).To(Equal([]string{"optional"}))
+		Expect(tmpcache.Get(n).Path).To(Equal("/tmp/cache"))
+
+		creds, err := credentials.CredentialsForConsumer(n, consumer)
+		Expect(err).To(Succeed())
+		Expect(creds.GetProperty("user")).To(Equal("explicit"))
+
+		creds, err = credentials.CredentialsForConsumer(n, configured)
+		Expect(err).To(Succeed())
+		Expect(creds.GetProperty("user")).To(Equal("configured"))
+	}
+
+	It("exports and loads the state", func() {
+		data, err := me.ExportStateData(ctx)
+		Expect(err).To(Succeed())
+
+		n := credentials.New(ctxmgmt.MODE_DEFAULTED)
+		Expect(me.LoadState(n, data)).To(Succeed())
+		check(n)
+	})
+
+	It("passes the state via the environment", func() {
+		env, err := me.ExportStateEnv(ctx)
+		Expect(err).To(Succeed())
+		name, value, _ := strings.Cut(env, "=")
+		Expect(name).To(Equal(me.STATE_ENV))
+
+		n := credentials.New(ctxmgmt.MODE_DEFAULTED)
+		ok, err := me.LoadStateFromEnv(n)
+		Expect(ok, err).To(BeFalse())
+
+		os.Setenv(me.STATE_ENV, value)
+		DeferCleanup(os.Unsetenv, me.STATE_ENV)
+
+		ok, err = me.LoadStateFromEnv(n)
+		Expect(ok, err).To(BeTrue())
+		check(n)
+	})
+
+	It("reports unexportable credentials", func() {
+		ctx.SetCredentialsForConsumer(consumer, credentials.CredentialsChain{&unexportable{}})
+
+		_, err := me.ExportState(ctx)
+		Expect(err).To(MatchError(ContainSubstring("credentials source of type *cfgutils_test.unexportable cannot be exported")))
+
+		state, err := me.ExportState(ctx, me.SkipUnexportable())
+		Expect(err).To(Succeed())
+		// the consumer set by the applied credentials config is
+		// exported, too.
+		Expect(state.Configurations).To(HaveLen(3))
+		Expect(state.Configurations[2].Object["consumers"]).To(HaveLen(1))
+	})
+})
+
+type unexportable struct{}
+
+func (u *unexportable) Credentials(credentials.Context, ...credentials.CredentialsSource) (credentials.Credentials, error) {
+	return nil, nil
+}
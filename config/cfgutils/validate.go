@@ -0,0 +1,49 @@
+package cfgutils
+
+import (
+	"github.com/mandelsoft/goutils/general"
+	"github.com/mandelsoft/goutils/ioutils"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	"github.com/mandelsoft/ctxmgmt/config"
+	"github.com/mandelsoft/ctxmgmt/config/validation"
+)
+
+// ValidateFile validates a config file against the config types
+// known by a config context (see validation.Validate). Like with
+// Configure, the file is preprocessed with spiff and encrypted
+// values are decrypted.
+// An error is returned if the file cannot be read.
+func ValidateFile(ctx config.ContextProvider, path string, fss ...vfs.FileSystem) (*validation.Report, error) {
+	fs := general.OptionalDefaulted[vfs.FileSystem](osfs.OsFs, fss...)
+	path, err := ioutils.ResolvePath(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := vfs.ReadFile(fs, path)
+	if err != nil {
+		return nil, err
+	}
+	return ValidateData(ctx, data, path), nil
+}
+
+// ValidateData validates config data against the config
+// types known by a config context (see validation.Validate).
+func ValidateData(ctx config.ContextProvider, data []byte, info string) *validation.Report {
+	if ctx == nil {
+		ctx = config.DefaultContext()
+	}
+	processed, src, err := ProcessConfigData(data, info)
+	if err == nil {
+		processed, err = DecryptConfigData(ctx, processed, info)
+	}
+	if err != nil {
+		return &validation.Report{Findings: []validation.Finding{{
+			Severity: validation.ERROR,
+			Source:   info,
+			Message:  err.Error(),
+		}}}
+	}
+	return validation.Validate(ctx, processed, src)
+}
@@ -0,0 +1,100 @@
+package cfgutils_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	me "github.com/mandelsoft/ctxmgmt/config/cfgutils"
+	"github.com/mandelsoft/ctxmgmt/config/validation"
+	"github.com/mandelsoft/ctxmgmt/credentials"
+)
+
+const invalidConfig = `type: generic.config.mandelsoft.de/v1
+configurations:
+  - type: credentails.config.mandelsoft.de
+  - type: credentials.config.mandelsoft.de
+    repositories:
+      - repository:
+          type: DockerConfg
+    consumers:
+      - identity:
+          type: OCIRegistri
+          hostname: ghcr.io
+        credentials: []
+sets:
+  test:
+    configurations:
+      - type: attributes.config.mandelsoft.de
+        attributes:
+          blobcach: /tmp
+`
+
+var _ = Describe("config validation", func() {
+	var ctx credentials.Context
+
+	BeforeEach(func() {
+		ctx = credentials.New()
+	})
+
+	It("reports unknown names with suggestions", func() {
+		r := me.ValidateData(ctx, []byte(invalidConfig), "config.yaml")
+		Expect(r.Findings).To(Equal([]validation.Finding{
+			{
+				Severity:    validation.ERROR,
+				Source:      "config.yaml",
+				Element:     "configurations[0].type",
+				Line:        3,
+				Column:      11,
+				Message:     `unknown config type "credentails.config.mandelsoft.de"`,
+				Suggestions: []string{"credentials.config.mandelsoft.de", "credentials.config.mandelsoft.de/v1"},
+			},
+			{
+				Severity:    validation.ERROR,
+				Source:      "config.yaml",
+				Element:     "configurations[1].repositories[0].repository.type",
+				Line:        7,
+				Column:      17,
+				Message:     `unknown repository type "DockerConfg"`,
+				Suggestions: []string{"DockerConfig"},
+			},
+			{
+				Severity:    validation.WARNING,
+				Source:      "config.yaml",
+				Element:     "configurations[1].consumers[0].identity.type",
+				Line:        10,
+				Column:      17,
+				Message:     `unknown identity matcher type "OCIRegistri"`,
+				Suggestions: []string{"OCIRegistry"},
+			},
+			{
+				Severity:    validation.ERROR,
+				Source:      "config.yaml",
+				Element:     "sets.test.configurations[0].attributes.blobcach",
+				Line:        18,
+				Column:      21,
+				Message:     `unknown attribute "blobcach"`,
+				Suggestions: []string{"blobcache"},
+			},
+		}))
+		Expect(r.HasErrors()).To(BeTrue())
+		Expect(r.Findings[1].String()).To(Equal(`config.yaml:7:17: error: unknown repository type "DockerConfg" (configurations[1].repositories[0].repository.type), did you mean "DockerConfig"?`))
+	})
+
+	It("accepts valid config", func() {
+		r := me.ValidateData(ctx, []byte(nestedConfig), "config.yaml")
+		Expect(r.Findings).To(BeEmpty())
+		Expect(r.HasErrors(true)).To(BeFalse())
+	})
+
+	It("considers warnings in strict mode", func() {
+		r := me.ValidateData(ctx, []byte(`
+type: credentials.config.mandelsoft.de
+consumers:
+  - identity:
+      type: OCIRegistri
+    credentials: []
+`), "config.yaml")
+		Expect(r.HasErrors()).To(BeFalse())
+		Expect(r.HasErrors(true)).To(BeTrue())
+	})
+})
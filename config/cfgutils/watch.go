@@ -0,0 +1,488 @@
+package cfgutils
+
+import (
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/mandelsoft/goutils/errors"
+	"github.com/mandelsoft/goutils/ioutils"
+	"github.com/mandelsoft/goutils/optionutils"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	"github.com/mandelsoft/ctxmgmt"
+	"github.com/mandelsoft/ctxmgmt/config"
+	"github.com/mandelsoft/ctxmgmt/config/diff"
+	configcfg "github.com/mandelsoft/ctxmgmt/config/extensions/config"
+	"github.com/mandelsoft/ctxmgmt/credentials"
+	credcfg "github.com/mandelsoft/ctxmgmt/credentials/config"
+)
+
+// DEFAULT_DEBOUNCE is the default time to wait for further
+// file modifications before a watched config file is reloaded.
+const DEFAULT_DEBOUNCE = 500 * time.Millisecond
+
+// DEFAULT_POLL_INTERVAL is the default interval used to poll
+// for file modifications, if file system notifications are
+// not available.
+const DEFAULT_POLL_INTERVAL = time.Second
+
+// FileReferenceProvider is implemented by config objects or
+// credential repository specifications referring to local files.
+// Modifications of those files trigger a reload of a watched
+// config file.
+type FileReferenceProvider interface {
+	GetReferencedFiles() []string
+}
+
+type WatchOption = optionutils.Option[*WatchOptions]
+
+type WatchOptions struct {
+	// FileSystem is the file system used to read the watched files.
+	// The default is the OS filesystem. File system notifications
+	// are only available for the OS filesystem, for other file systems
+	// modifications are polled (see PollInterval).
+	FileSystem vfs.FileSystem
+	// Debounce is the time to wait for further file modifications
+	// before reloading. The default is DEFAULT_DEBOUNCE.
+	Debounce time.Duration
+	// PollInterval enables polling for file modifications
+	// instead of using file system notifications.
+	PollInterval time.Duration
+	// Files are additional files triggering a reload.
+	Files []string
+	// Updaters are triggered after a successful reload.
+	Updaters []ctxmgmt.Updater
+	// ErrorHandler is called for failed reloads.
+	// By default, errors are logged.
+	ErrorHandler func(error)
+}
+
+var _ WatchOption = (*WatchOptions)(nil)
+
+func (o *WatchOptions) ApplyTo(opts *WatchOptions) {
+	optionutils.Transfer(&opts.FileSystem, o.FileSystem)
+	if o.Debounce > 0 {
+		opts.Debounce = o.Debounce
+	}
+	if o.PollInterval > 0 {
+		opts.PollInterval = o.PollInterval
+	}
+	opts.Files = append(opts.Files, o.Files...)
+	opts.Updaters = append(opts.Updaters, o.Updaters...)
+	if o.ErrorHandler != nil {
+		opts.ErrorHandler = o.ErrorHandler
+	}
+}
+
+// WithWatchFileSystem sets the file system used to read
+// the watched files.
+func WithWatchFileSystem(fs vfs.FileSystem) WatchOption {
+	return &WatchOptions{FileSystem: fs}
+}
+
+// WithDebounce sets the time to wait for further file
+// modifications before reloading.
+func WithDebounce(d time.Duration) WatchOption {
+	return &WatchOptions{Debounce: d}
+}
+
+// WithPolling requests to poll for file modifications
+// with the given interval instead of using file system
+// notifications.
+func WithPolling(interval time.Duration) WatchOption {
+	return &WatchOptions{PollInterval: interval}
+}
+
+// WithFiles adds files triggering a reload.
+func WithFiles(paths ...string) WatchOption {
+	return &WatchOptions{Files: paths}
+}
+
+// WithUpdaters adds updaters triggered after a successful reload.
+func WithUpdaters(u ...ctxmgmt.Updater) WatchOption {
+	return &WatchOptions{Updaters: u}
+}
+
+// WithErrorHandler sets a handler called for failed reloads.
+func WithErrorHandler(h func(error)) WatchOption {
+	return &WatchOptions{ErrorHandler: h}
+}
+
+// Watcher watches a config file and the files referenced by it.
+// On modifications the config file is reloaded like with Configure
+// and applied to the config context, replacing (see Context.Retract)
+// the config object applied for the previous load. Config objects
+// applied by other sources are kept.
+// If the reload fails, the last good configuration is kept.
+// The changes of the last reload are provided by Changes.
+type Watcher struct {
+	lock     sync.Mutex
+	ctx      config.ContextProvider
+	path     string
+	opts     WatchOptions
+	config   config.Config
+	previous config.Config
+	handle   config.ConfigHandle
+	err      error
+	files    []string
+	stamps   map[string]time.Time
+	dirs     map[string]struct{}
+	notify   *fsnotify.Watcher
+	done     chan struct{}
+	stopped  chan struct{}
+}
+
+// Watch configures a config context from a config file (see Configure)
+// and watches the file for modifications.
+// The initial configuration must succeed.
+func Watch(ctx config.ContextProvider, path string, opts ...WatchOption) (*Watcher, error) {
+	if ctx == nil {
+		ctx = config.DefaultContext()
+	}
+	w := &Watcher{
+		ctx:     ctx,
+		opts:    *optionutils.EvalOptions(append([]WatchOption{WithDebounce(DEFAULT_DEBOUNCE)}, opts...)...),
+		dirs:    map[string]struct{}{},
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	if w.opts.FileSystem == nil {
+		w.opts.FileSystem = osfs.OsFs
+	}
+	if w.opts.PollInterval <= 0 && !osfs.IsOsFileSystem(w.opts.FileSystem) {
+		w.opts.PollInterval = DEFAULT_POLL_INTERVAL
+	}
+	path, err := w.resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	w.path = path
+	if w.opts.PollInterval <= 0 {
+		w.notify, err = fsnotify.NewWatcher()
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot watch config file %q", path)
+		}
+	}
+	err = w.load()
+	if err != nil {
+		if w.notify != nil {
+			w.notify.Close()
+		}
+		return nil, err
+	}
+	go w.run()
+	return w, nil
+}
+
+// Config provides the last successfully applied config.
+func (w *Watcher) Config() config.Config {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.config
+}
+
+// Previous provides the config replaced by the last successful
+// reload, or nil if the config file has not been reloaded, yet.
+func (w *Watcher) Previous() config.Config {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.previous
+}
+
+// Changes describes the changes of the last successful reload
+// by comparing the previous and the current config (see diff.Configs).
+// Before the first reload, all config objects are reported as added.
+func (w *Watcher) Changes() (*diff.Diff, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return diff.Configs(w.ctx, w.previous, w.config)
+}
+
+// Error provides the error of the last reload,
+// or nil if it succeeded.
+func (w *Watcher) Error() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.err
+}
+
+// Files provides the watched files.
+func (w *Watcher) Files() []string {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return slices.Clone(w.files)
+}
+
+// AddUpdaters adds updaters triggered after a successful reload.
+func (w *Watcher) AddUpdaters(u ...ctxmgmt.Updater) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.opts.Updaters = append(w.opts.Updaters, u...)
+}
+
+// Reload reloads the config file. A failed reload
+// is reported and the last good configuration is kept.
+func (w *Watcher) Reload() error {
+	err := w.load()
+	if err != nil {
+		if w.opts.ErrorHandler != nil {
+			w.opts.ErrorHandler(err)
+		} else {
+			config.Logger.LogError(err, "config reload failed", "path", w.path)
+		}
+	}
+	return err
+}
+
+// Close stops watching.
+func (w *Watcher) Close() error {
+	w.lock.Lock()
+	select {
+	case <-w.done:
+		w.lock.Unlock()
+		return nil
+	default:
+		close(w.done)
+	}
+	w.lock.Unlock()
+	<-w.stopped
+	if w.notify != nil {
+		return w.notify.Close()
+	}
+	return nil
+}
+
+func (w *Watcher) load() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	err := w.apply()
+	w.err = err
+	return err
+}
+
+func (w *Watcher) apply() error {
+	cctx := w.ctx.ConfigContext()
+	data, err := vfs.ReadFile(w.opts.FileSystem, w.path)
+	if err != nil {
+		return errors.Wrapf(err, "cannot read config file %q", w.path)
+	}
+	cfg, src, err := prepareConfig(w.ctx, data, w.path)
+	if err != nil {
+		return err
+	}
+
+	// the new config object replaces the config object
+	// applied for the last load. Config objects applied
+	// by other sources are kept.
+	h, err := cctx.ApplyConfigWithHandle(cfg, w.path, config.WithSource(src))
+	if err != nil {
+		// keep last good configuration
+		if h != 0 {
+			cctx.Retract(config.ByHandle(h))
+		}
+		return errors.Wrapf(err, "cannot apply ocm config %q", w.path)
+	}
+	if w.handle != 0 {
+		cctx.Retract(config.ByHandle(w.handle))
+	}
+	w.handle = h
+	w.previous = w.config
+	w.config = cfg
+
+	list := errors.ErrListf("config reload")
+	for _, u := range w.opts.Updaters {
+		list.Add(u.Update())
+	}
+	list.Add(w.watch(w.referencedFiles(cfg)))
+	return list.Result()
+}
+
+// watch updates the set of watched files.
+func (w *Watcher) watch(files []string) error {
+	list := errors.ErrListf("watching files")
+	w.files = nil
+	for _, f := range append(append([]string{w.path}, w.opts.Files...), files...) {
+		p, err := w.resolve(f)
+		if err != nil {
+			list.Add(err)
+			continue
+		}
+		if !slices.Contains(w.files, p) {
+			w.files = append(w.files, p)
+		}
+	}
+
+	if w.notify == nil {
+		w.stamps = w.modifications()
+		return list.Result()
+	}
+
+	dirs := map[string]struct{}{}
+	for _, f := range w.files {
+		dirs[filepath.Dir(f)] = struct{}{}
+	}
+	for d := range w.dirs {
+		if _, ok := dirs[d]; !ok {
+			w.notify.Remove(d)
+			delete(w.dirs, d)
+		}
+	}
+	for d := range dirs {
+		if _, ok := w.dirs[d]; !ok {
+			if err := w.notify.Add(d); err != nil {
+				list.Add(errors.Wrapf(err, "cannot watch %q", d))
+				continue
+			}
+			w.dirs[d] = struct{}{}
+		}
+	}
+	return list.Result()
+}
+
+// resolve provides the absolute path of a watched file.
+func (w *Watcher) resolve(path string) (string, error) {
+	if osfs.IsOsFileSystem(w.opts.FileSystem) {
+		p, err := ioutils.ResolvePath(path)
+		if err != nil {
+			return "", err
+		}
+		return filepath.Clean(p), nil
+	}
+	return vfs.Abs(w.opts.FileSystem, path)
+}
+
+// modifications provides the modification times of the watched files.
+// Missing files are reported with the zero time.
+func (w *Watcher) modifications() map[string]time.Time {
+	stamps := map[string]time.Time{}
+	for _, f := range w.files {
+		if fi, err := w.opts.FileSystem.Stat(f); err == nil {
+			stamps[f] = fi.ModTime()
+		} else {
+			stamps[f] = time.Time{}
+		}
+	}
+	return stamps
+}
+
+func (w *Watcher) modified() bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	stamps := w.modifications()
+	for f, t := range stamps {
+		if !t.Equal(w.stamps[f]) {
+			w.stamps = stamps
+			return true
+		}
+	}
+	return false
+}
+
+func (w *Watcher) isWatched(path string) bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return slices.Contains(w.files, filepath.Clean(path))
+}
+
+func (w *Watcher) run() {
+	defer close(w.stopped)
+
+	var (
+		fire   <-chan time.Time
+		tick   <-chan time.Time
+		events chan fsnotify.Event
+		errs   chan error
+	)
+	if w.notify != nil {
+		events = w.notify.Events
+		errs = w.notify.Errors
+	} else {
+		ticker := time.NewTicker(w.opts.PollInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-tick:
+			if w.modified() {
+				fire = time.After(w.opts.Debounce)
+			}
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			if w.isWatched(e.Name) {
+				// a burst of events only triggers a single reload
+				fire = time.After(w.opts.Debounce)
+			}
+		case err, ok := <-errs:
+			if !ok {
+				return
+			}
+			config.Logger.LogError(err, "watching config files", "path", w.path)
+		case <-fire:
+			fire = nil
+			w.Reload()
+		}
+	}
+}
+
+// referencedFiles determines the local files referenced
+// by a config object.
+func (w *Watcher) referencedFiles(cfg config.Config) []string {
+	var files []string
+	cctx := w.ctx.ConfigContext()
+	crctx := credentials.DefaultContext()
+	if p, ok := w.ctx.(credentials.ContextProvider); ok {
+		crctx = p.CredentialsContext()
+	}
+
+	var walk func(cfg config.Config)
+	walk = func(cfg config.Config) {
+		if g, ok := cfg.(*config.GenericConfig); ok {
+			eff, err := g.Evaluate(cctx)
+			if err != nil {
+				return
+			}
+			cfg = eff
+		}
+		if p, ok := cfg.(FileReferenceProvider); ok {
+			files = append(files, p.GetReferencedFiles()...)
+		}
+		switch c := cfg.(type) {
+		case *configcfg.Config:
+			for _, e := range c.Configurations {
+				walk(e)
+			}
+			for _, s := range c.Sets {
+				for _, e := range s.Configurations {
+					walk(e)
+				}
+			}
+		case *credcfg.Config:
+			specs := slices.Clone(c.Repositories)
+			for _, a := range c.Aliases {
+				specs = append(specs, a)
+			}
+			for _, r := range specs {
+				spec, err := r.Repository.Evaluate(crctx)
+				if err != nil {
+					continue
+				}
+				if p, ok := spec.(FileReferenceProvider); ok {
+					files = append(files, p.GetReferencedFiles()...)
+				}
+			}
+		}
+	}
+	walk(cfg)
+	return files
+}
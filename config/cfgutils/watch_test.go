@@ -0,0 +1,247 @@
+package cfgutils_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	"github.com/mandelsoft/ctxmgmt"
+	"github.com/mandelsoft/ctxmgmt/config"
+	me "github.com/mandelsoft/ctxmgmt/config/cfgutils"
+	"github.com/mandelsoft/ctxmgmt/credentials"
+)
+
+const consumerConfig = `
+type: credentials.config.mandelsoft.de
+consumers:
+  - identity:
+      type: test
+      host: watched
+    credentials:
+      - type: Credentials
+        properties:
+          user: %s
+`
+
+const dockerConfig = `
+type: credentials.config.mandelsoft.de
+repositories:
+  - repository:
+      type: DockerConfig
+      dockerConfigFile: %s
+      propagateConsumerIdentity: true
+`
+
+const dockerAuth = `{"auths":{"ghcr.io":{"auth":"%s"}}}`
+
+var _ = Describe("config file watcher", func() {
+	consumer := credentials.ConsumerIdentity{credentials.ID_TYPE: "test", "host": "watched"}
+
+	var (
+		dir  string
+		path string
+		ctx  credentials.Context
+	)
+
+	write := func(p string, format string, args ...interface{}) {
+		Expect(os.WriteFile(p, []byte(fmt.Sprintf(format, args...)), 0o600)).To(Succeed())
+	}
+
+	user := func() string {
+		creds, err := credentials.CredentialsForConsumer(ctx, consumer)
+		if err != nil || creds == nil {
+			return ""
+		}
+		return creds.GetProperty("user")
+	}
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		path = filepath.Join(dir, ".appconfig")
+		ctx = credentials.New(ctxmgmt.MODE_DEFAULTED)
+	})
+
+	for _, mode := range []string{"notifications", "polling"} {
+		opts := []me.WatchOption{me.WithDebounce(10 * time.Millisecond)}
+		if mode == "polling" {
+			opts = append(opts, me.WithPolling(10*time.Millisecond))
+		}
+
+		Context(mode, func() {
+			It("reloads a modified config file", func() {
+				write(path, consumerConfig, "alice")
+				w, err := me.Watch(ctx, path, opts...)
+				Expect(err).To(Succeed())
+				defer w.Close()
+				Expect(user()).To(Equal("alice"))
+				gen := ctx.ConfigContext().Generation()
+
+				write(path, consumerConfig, "bob")
+				Eventually(user).Should(Equal("bob"))
+				Expect(ctx.ConfigContext().Generation()).To(BeNumerically(">", gen))
+			})
+
+			It("keeps the last good config", func() {
+				write(path, consumerConfig, "alice")
+
+				var lock sync.Mutex
+				var reported []error
+				w, err := me.Watch(ctx, path, append(opts, me.WithErrorHandler(func(err error) {
+					lock.Lock()
+					defer lock.Unlock()
+					reported = append(reported, err)
+				}))...)
+				Expect(err).To(Succeed())
+				defer w.Close()
+				good := w.Config()
+
+				write(path, "type: credentials.config.mandelsoft.de\nconsumers: invalid\n")
+				Eventually(w.Error).Should(HaveOccurred())
+				Expect(w.Config()).To(BeIdenticalTo(good))
+				Expect(user()).To(Equal("alice"))
+				lock.Lock()
+				Expect(reported).NotTo(BeEmpty())
+				lock.Unlock()
+
+				write(path, consumerConfig, "bob")
+				Eventually(user).Should(Equal("bob"))
+				Expect(w.Error()).To(Succeed())
+			})
+		})
+	}
+
+	It("removes consumers dropped from the config file", func() {
+		other := credentials.ConsumerIdentity{credentials.ID_TYPE: "test", "host": "other"}
+		write(path, consumerConfig+`
+  - identity:
+      type: test
+      host: other
+    credentials:
+      - type: Credentials
+        properties:
+          user: charlie
+`, "alice")
+		w, err := me.Watch(ctx, path, me.WithDebounce(time.Hour))
+		Expect(err).To(Succeed())
+		defer w.Close()
+		Expect(credentials.CredentialsForConsumer(ctx, other)).NotTo(BeNil())
+
+		write(path, consumerConfig, "bob")
+		Expect(w.Reload()).To(Succeed())
+		Expect(user()).To(Equal("bob"))
+		Expect(credentials.CredentialsForConsumer(ctx, other)).To(BeNil())
+	})
+
+	It("keeps config applied by other sources", func() {
+		other := credentials.ConsumerIdentity{credentials.ID_TYPE: "test", "host": "other"}
+		ctx.SetCredentialsForConsumer(other, credentials.DirectCredentials{"user": "explicit"})
+		_, err := ctx.ConfigContext().ApplyData([]byte(strings.ReplaceAll(fmt.Sprintf(consumerConfig, "charlie"), "watched", "configured")), nil, "other")
+		Expect(err).To(Succeed())
+
+		write(path, consumerConfig, "alice")
+		w, err := me.Watch(ctx, path, me.WithDebounce(time.Hour))
+		Expect(err).To(Succeed())
+		defer w.Close()
+
+		write(path, consumerConfig, "bob")
+		Expect(w.Reload()).To(Succeed())
+		Expect(user()).To(Equal("bob"))
+		_, cfgs := ctx.ConfigContext().GetConfig(config.AllGenerations, nil)
+		Expect(cfgs).To(HaveLen(2))
+
+		creds, err := credentials.CredentialsForConsumer(ctx, other)
+		Expect(err).To(Succeed())
+		Expect(creds.GetProperty("user")).To(Equal("explicit"))
+		creds, err = credentials.CredentialsForConsumer(ctx, credentials.ConsumerIdentity{credentials.ID_TYPE: "test", "host": "configured"})
+		Expect(err).To(Succeed())
+		Expect(creds.GetProperty("user")).To(Equal("charlie"))
+	})
+
+	It("provides the changes of the last reload", func() {
+		write(path, consumerConfig, "alice")
+		w, err := me.Watch(ctx, path, me.WithDebounce(time.Hour))
+		Expect(err).To(Succeed())
+		defer w.Close()
+		Expect(w.Previous()).To(BeNil())
+
+		write(path, consumerConfig, "bob")
+		Expect(w.Reload()).To(Succeed())
+		Expect(w.Previous()).NotTo(BeNil())
+
+		d, err := w.Changes()
+		Expect(err).To(Succeed())
+		Expect(d.String()).To(Equal("~ credentials.config.mandelsoft.de[0] consumers[0].credentials[0].properties.user: \"***\" -> \"***\"\n"))
+	})
+
+	It("fails for an invalid initial config", func() {
+		write(path, "type: credentials.config.mandelsoft.de\nconsumers: invalid\n")
+		_, err := me.Watch(ctx, path)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("watches files of a virtual filesystem", func() {
+		fs := memoryfs.New()
+		Expect(vfs.WriteFile(fs, "/config", []byte(fmt.Sprintf(consumerConfig, "alice")), 0o600)).To(Succeed())
+		w, err := me.Watch(ctx, "/config", me.WithWatchFileSystem(fs), me.WithDebounce(10*time.Millisecond))
+		Expect(err).To(Succeed())
+		defer w.Close()
+		Expect(user()).To(Equal("alice"))
+		Expect(w.Files()).To(Equal([]string{"/config"}))
+
+		Expect(vfs.WriteFile(fs, "/config", []byte(fmt.Sprintf(consumerConfig, "bob")), 0o600)).To(Succeed())
+		Eventually(user).WithTimeout(5 * time.Second).Should(Equal("bob"))
+	})
+
+	It("triggers updaters", func() {
+		write(path, consumerConfig, "alice")
+		var lock sync.Mutex
+		count := 0
+		w, err := me.Watch(ctx, path, me.WithDebounce(10*time.Millisecond), me.WithUpdaters(ctxmgmt.UpdateFunc(func() error {
+			lock.Lock()
+			defer lock.Unlock()
+			count++
+			return nil
+		})))
+		Expect(err).To(Succeed())
+		defer w.Close()
+
+		Expect(w.Reload()).To(Succeed())
+		lock.Lock()
+		defer lock.Unlock()
+		Expect(count).To(Equal(2))
+	})
+
+	It("watches referenced docker config files", func() {
+		docker := filepath.Join(dir, "config.json")
+		write(docker, dockerAuth, "YWxpY2U6cGFzc3dvcmQ=") // alice:password
+		write(path, dockerConfig, docker)
+
+		w, err := me.Watch(ctx, path, me.WithDebounce(10*time.Millisecond))
+		Expect(err).To(Succeed())
+		defer w.Close()
+		Expect(w.Files()).To(Equal([]string{path, docker}))
+
+		id := credentials.ConsumerIdentity{credentials.ID_TYPE: "OCIRegistry", "hostname": "ghcr.io"}
+		username := func() string {
+			creds, err := credentials.CredentialsForConsumer(ctx, id)
+			if err != nil || creds == nil {
+				return ""
+			}
+			return creds.GetProperty(credentials.ATTR_USERNAME)
+		}
+		Expect(username()).To(Equal("alice"))
+
+		time.Sleep(10 * time.Millisecond)             // assure new modification time
+		write(docker, dockerAuth, "Ym9iOnBhc3N3b3Jk") // bob:password
+		Eventually(username).Should(Equal("bob"))
+	})
+})
@@ -1,6 +1,7 @@
 package cpi
 
 import (
+	"reflect"
 	"strings"
 
 	"github.com/mandelsoft/ctxmgmt/config/internal"
@@ -56,3 +57,13 @@ func NewConfigTypeByFormatVersion(name string, fmt runtime.FormatVersion[Config]
 func (t *configType) Usage() string {
 	return t.usage
 }
+
+// Description provides the usage as description
+// for the JSON schema of the config type.
+func (t *configType) Description() string {
+	return t.usage
+}
+
+func (t *configType) SerializedType() reflect.Type {
+	return runtime.SerializedType(t.VersionedTypedObjectType)
+}
@@ -22,10 +22,23 @@ type (
 
 	ConfigSet         = internal.ConfigSet
 	ConfigurationList = internal.ConfigurationList
+	Activation        = internal.Activation
+
+	ActivationEnvironment         = internal.ActivationEnvironment
+	ActivationEnvironmentProvider = internal.ActivationEnvironmentProvider
 
 	ConfigApplier         = internal.ConfigApplier
 	ConfigApplierFunction = internal.ConfigApplierFunction
 	ConfigApplierRegistry = internal.ConfigApplierRegistry
+
+	ApplyOption  = internal.ApplyOption
+	ApplyOptions = internal.ApplyOptions
+	ConfigSource = internal.ConfigSource
+
+	ConfigDataProcessor         = internal.ConfigDataProcessor
+	ConfigDataProcessorFunction = internal.ConfigDataProcessorFunction
+	Provenance                  = internal.Provenance
+	Provenances                 = internal.Provenances
 )
 
 var DefaultContext = internal.DefaultContext
@@ -42,6 +55,59 @@ func ToGenericConfig(c Config) (*GenericConfig, error) {
 	return internal.ToGenericConfig(c)
 }
 
+// JoinElementPath joins element paths used to describe
+// fields of config documents (see ConfigSource).
+func JoinElementPath(path string, elems ...string) string {
+	return internal.JoinElementPath(path, elems...)
+}
+
+// ProcessConfigData preprocesses config data with spiff and
+// provides the processed data together with the description of
+// the source document.
+func ProcessConfigData(data []byte, info string) ([]byte, *ConfigSource, error) {
+	return internal.ProcessConfigData(data, info)
+}
+
+// DecodeConfigData preprocesses config data like ProcessConfigData,
+// applies the registered ConfigDataProcessors and decodes the
+// resulting config object together with the description of the
+// source document.
+func DecodeConfigData(ctx ContextProvider, data []byte, info string) (Config, *ConfigSource, error) {
+	return internal.DecodeConfigData(ctx, data, info)
+}
+
+// RegisterConfigDataProcessor registers a processor for config data
+// used by DecodeConfigData. Processors are applied in the order of
+// their names.
+func RegisterConfigDataProcessor(name string, p ConfigDataProcessor) {
+	internal.RegisterConfigDataProcessor(name, p)
+}
+
+// RegisterActivationEnvironmentProvider registers the provider used
+// to determine the environment for the evaluation of activation
+// conditions of config sets.
+func RegisterActivationEnvironmentProvider(p ActivationEnvironmentProvider) {
+	internal.RegisterActivationEnvironmentProvider(p)
+}
+
+// GetActivationEnvironment provides the environment used to evaluate
+// activation conditions for a config context.
+func GetActivationEnvironment(ctx Context) ActivationEnvironment {
+	return internal.GetActivationEnvironment(ctx)
+}
+
+// WithSource sets the source document of an applied config object.
+func WithSource(src *ConfigSource) ApplyOption {
+	return internal.WithSource(src)
+}
+
+// WithElement sets the element path of an applied config object.
+// Without a source, it is relative to the element path of the
+// config object applying it.
+func WithElement(path string) ApplyOption {
+	return internal.WithElement(path)
+}
+
 func NewConfigTypeScheme() ConfigTypeScheme {
 	return internal.NewConfigTypeScheme(nil)
 }
@@ -0,0 +1,310 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/mandelsoft/goutils/errors"
+	"github.com/mandelsoft/goutils/maputils"
+
+	"github.com/mandelsoft/ctxmgmt/config"
+	configcfg "github.com/mandelsoft/ctxmgmt/config/extensions/config"
+	"github.com/mandelsoft/ctxmgmt/utils"
+)
+
+type ChangeKind string
+
+const (
+	ADDED   ChangeKind = "added"
+	REMOVED ChangeKind = "removed"
+	CHANGED ChangeKind = "changed"
+)
+
+// Change describes a modification of a config object.
+type Change struct {
+	Kind ChangeKind `json:"kind"`
+	// Object identifies the config object by its kind and its
+	// index among the config objects of this kind, for example
+	// credentials.config.mandelsoft.de[0]. Objects from config sets
+	// are prefixed by sets.<name>/.
+	Object string `json:"object"`
+	// Field is the path of the modified field or empty
+	// if the complete object is added or removed.
+	Field string `json:"field,omitempty"`
+	// Old and New are the normalized old and new values
+	// with redacted sensitive information.
+	Old interface{} `json:"old,omitempty"`
+	New interface{} `json:"new,omitempty"`
+}
+
+// Diff describes the modifications between two configurations.
+type Diff struct {
+	Changes []Change `json:"changes,omitempty"`
+}
+
+// IsEmpty returns true if there are no modifications.
+func (d *Diff) IsEmpty() bool {
+	return len(d.Changes) == 0
+}
+
+// Print prints a human-readable form of the diff.
+func (d *Diff) Print(p utils.Printer) {
+	if d.IsEmpty() {
+		p.Printf("no changes\n")
+		return
+	}
+	for _, c := range d.Changes {
+		switch c.Kind {
+		case ADDED:
+			if c.Field == "" {
+				p.Printf("+ %s\n", c.Object)
+			} else {
+				p.Printf("+ %s %s: %s\n", c.Object, c.Field, format(c.New))
+			}
+		case REMOVED:
+			if c.Field == "" {
+				p.Printf("- %s\n", c.Object)
+			} else {
+				p.Printf("- %s %s: %s\n", c.Object, c.Field, format(c.Old))
+			}
+		case CHANGED:
+			p.Printf("~ %s %s: %s -> %s\n", c.Object, c.Field, format(c.Old), format(c.New))
+		}
+	}
+}
+
+func (d *Diff) String() string {
+	p, buf := utils.NewBufferedPrinter()
+	d.Print(p)
+	return buf.String()
+}
+
+func format(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
+
+// Configs compares two config objects, typically generic.config
+// documents. The config objects are decoded with the config types
+// known by the config context and normalized. The configurations of
+// generic.config objects are compared individually.
+// Sensitive values are redacted (see RegisterRedactor).
+// A nil config describes an empty configuration.
+func Configs(ctx config.ContextProvider, old, new config.Config) (*Diff, error) {
+	var o, n []config.Config
+	if old != nil {
+		o = append(o, old)
+	}
+	if new != nil {
+		n = append(n, new)
+	}
+	return compareLists(ctx.ConfigContext(), o, n)
+}
+
+// Generations compares the configuration of a config context
+// effective at two generations. Only config objects still kept
+// by the config context are considered, config objects removed by a
+// reset or retraction are not visible anymore.
+// Therefore, a config object replaced by a reload (for example by
+// cfgutils.Watcher, which retracts the previously applied config object)
+// is reported as added, only, and config objects removed by a reset
+// appear as added for the objects applied afterwards. To compare the
+// configurations before and after a reload, use Configs with the old
+// and the new config object (see cfgutils.Watcher.Changes).
+func Generations(ctx config.ContextProvider, from, to int64) (*Diff, error) {
+	cctx := ctx.ConfigContext()
+	_, cfgs := cctx.GetAppliedConfigs(config.AllConfigs)
+
+	effective := func(gen int64) []config.Config {
+		var list []config.Config
+		for _, c := range cfgs {
+			// nested config objects are handled by the config object applying them.
+			if c.Origin() == 0 && c.Generation() <= gen {
+				list = append(list, c.Config())
+			}
+		}
+		return list
+	}
+	return compareLists(cctx, effective(from), effective(to))
+}
+
+func compareLists(ctx config.Context, old, new []config.Config) (*Diff, error) {
+	o, err := objects(ctx, old)
+	if err != nil {
+		return nil, errors.Wrapf(err, "old config")
+	}
+	n, err := objects(ctx, new)
+	if err != nil {
+		return nil, errors.Wrapf(err, "new config")
+	}
+
+	d := &Diff{}
+	keys := maputils.OrderedKeys(o)
+	for _, k := range maputils.OrderedKeys(n) {
+		if _, ok := o[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		oo, ook := o[k]
+		no, nok := n[k]
+		switch {
+		case !ook:
+			d.Changes = append(d.Changes, Change{Kind: ADDED, Object: k, New: redact(no.redactor, nil, no.value)})
+		case !nok:
+			d.Changes = append(d.Changes, Change{Kind: REMOVED, Object: k, Old: redact(oo.redactor, nil, oo.value)})
+		default:
+			d.Changes = compare(d.Changes, k, no.redactor, nil, oo.value, no.value)
+		}
+	}
+	return d, nil
+}
+
+type object struct {
+	value    interface{}
+	redactor Redactor
+}
+
+// objects provides the normalized config objects
+// indexed by their object key.
+func objects(ctx config.Context, cfgs []config.Config) (map[string]*object, error) {
+	result := map[string]*object{}
+	counts := map[string]int{}
+
+	add := func(prefix string, cfg config.Config) error {
+		data, err := json.Marshal(cfg)
+		if err != nil {
+			return err
+		}
+		var value interface{}
+		if err = json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		kind := cfg.GetKind()
+		key := prefix + kind
+		result[fmt.Sprintf("%s[%d]", key, counts[key])] = &object{value: value, redactor: redactorFor(kind)}
+		counts[key]++
+		return nil
+	}
+
+	var walk func(prefix string, cfg config.Config) error
+	walk = func(prefix string, cfg config.Config) error {
+		if g, ok := cfg.(*config.GenericConfig); ok {
+			eff, err := g.Evaluate(ctx)
+			if err != nil && !errors.IsErrUnknownKind(err, config.KIND_CONFIGTYPE) {
+				return err
+			}
+			if err == nil {
+				cfg = eff
+			}
+		}
+		c, ok := cfg.(*configcfg.Config)
+		if !ok {
+			return add(prefix, cfg)
+		}
+		for _, e := range c.Configurations {
+			if err := walk(prefix, e); err != nil {
+				return err
+			}
+		}
+		for _, name := range maputils.OrderedKeys(c.Sets) {
+			for _, e := range c.Sets[name].Configurations {
+				if err := walk(prefix+"sets."+name+"/", e); err != nil {
+					return err
+				}
+			}
+		}
+		if len(c.SetActivations) > 0 {
+			// keep set activations, configurations are compared individually.
+			return add(prefix, &configcfg.Config{ObjectVersionedType: c.ObjectVersionedType, SetActivations: c.SetActivations})
+		}
+		return nil
+	}
+
+	for _, c := range cfgs {
+		if err := walk("", c); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// compare compares normalized values field by field.
+func compare(changes []Change, obj string, r Redactor, path []string, old, new interface{}) []Change {
+	field := func() string {
+		s := ""
+		for _, e := range path {
+			if s != "" && !strings.HasPrefix(e, "[") {
+				s += "."
+			}
+			s += e
+		}
+		return s
+	}
+	sub := func(e string) []string {
+		return append(slices.Clone(path), e)
+	}
+
+	switch o := old.(type) {
+	case map[string]interface{}:
+		if n, ok := new.(map[string]interface{}); ok {
+			keys := maputils.OrderedKeys(o)
+			for _, k := range maputils.OrderedKeys(n) {
+				if _, ok := o[k]; !ok {
+					keys = append(keys, k)
+				}
+			}
+			slices.Sort(keys)
+			for _, k := range keys {
+				ov, ook := o[k]
+				nv, nok := n[k]
+				switch {
+				case !ook:
+					changes = append(changes, Change{Kind: ADDED, Object: obj, Field: field() + sep(path) + k, New: redact(r, sub(k), nv)})
+				case !nok:
+					changes = append(changes, Change{Kind: REMOVED, Object: obj, Field: field() + sep(path) + k, Old: redact(r, sub(k), ov)})
+				default:
+					changes = compare(changes, obj, r, sub(k), ov, nv)
+				}
+			}
+			return changes
+		}
+	case []interface{}:
+		if n, ok := new.([]interface{}); ok {
+			for i := 0; i < max(len(o), len(n)); i++ {
+				e := index(i)
+				switch {
+				case i >= len(o):
+					changes = append(changes, Change{Kind: ADDED, Object: obj, Field: field() + e, New: redact(r, sub(e), n[i])})
+				case i >= len(n):
+					changes = append(changes, Change{Kind: REMOVED, Object: obj, Field: field() + e, Old: redact(r, sub(e), o[i])})
+				default:
+					changes = compare(changes, obj, r, sub(e), o[i], n[i])
+				}
+			}
+			return changes
+		}
+	}
+	if !reflect.DeepEqual(old, new) {
+		changes = append(changes, Change{Kind: CHANGED, Object: obj, Field: field(), Old: redact(r, path, old), New: redact(r, path, new)})
+	}
+	return changes
+}
+
+func sep(path []string) string {
+	if len(path) == 0 {
+		return ""
+	}
+	return "."
+}
+
+func index(i int) string {
+	return fmt.Sprintf("[%d]", i)
+}
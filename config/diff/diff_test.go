@@ -0,0 +1,113 @@
+package diff_test
+
+import (
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	me "github.com/mandelsoft/ctxmgmt/config/diff"
+	"github.com/mandelsoft/ctxmgmt/credentials"
+)
+
+const oldConfig = `
+type: generic.config.mandelsoft.de/v1
+configurations:
+  - type: credentials.config.mandelsoft.de
+    consumers:
+      - identity:
+          type: test
+          host: alice
+        credentials:
+          - type: Credentials
+            properties:
+              user: alice
+              password: old
+  - type: attributes.config.mandelsoft.de
+    attributes:
+      blobcache: /tmp/old
+`
+
+const newConfig = `
+type: generic.config.mandelsoft.de/v1
+configurations:
+  - type: credentials.config.mandelsoft.de/v1
+    consumers:
+      - identity:
+          type: test
+          host: alice
+        credentials:
+          - type: Credentials
+            properties:
+              user: alice
+              password: new
+  - type: attributes.config.mandelsoft.de
+    attributes:
+      blobcache: /tmp/new
+sets:
+  test:
+    configurations:
+      - type: attributes.config.mandelsoft.de
+        attributes:
+          blobcache: /tmp/set
+`
+
+var _ = Describe("config diff", func() {
+	var ctx credentials.Context
+
+	BeforeEach(func() {
+		ctx = credentials.New()
+	})
+
+	It("compares documents", func() {
+		o := Must(ctx.ConfigContext().GetConfigForData([]byte(oldConfig), nil))
+		n := Must(ctx.ConfigContext().GetConfigForData([]byte(newConfig), nil))
+
+		d := Must(me.Configs(ctx, o, n))
+		Expect(d.String()).To(Equal(`~ attributes.config.mandelsoft.de[0] attributes.blobcache: "/tmp/old" -> "/tmp/new"
+~ credentials.config.mandelsoft.de[0] consumers[0].credentials[0].properties.password: "***" -> "***"
+~ credentials.config.mandelsoft.de[0] type: "credentials.config.mandelsoft.de" -> "credentials.config.mandelsoft.de/v1"
++ sets.test/attributes.config.mandelsoft.de[0]
+`))
+		Expect(Must(me.Configs(ctx, o, o)).IsEmpty()).To(BeTrue())
+	})
+
+	It("redacts added objects", func() {
+		n := Must(ctx.ConfigContext().GetConfigForData([]byte(newConfig), nil))
+		d := Must(me.Configs(ctx, nil, n))
+		Expect(d.Changes).To(HaveLen(3))
+		Expect(d.Changes[1].Object).To(Equal("credentials.config.mandelsoft.de[0]"))
+		Expect(d.Changes[1].New).To(YAMLEqual(`
+type: credentials.config.mandelsoft.de/v1
+consumers:
+  - identity:
+      type: test
+      host: alice
+    credentials:
+      - type: Credentials
+        properties:
+          user: "***"
+          password: "***"
+`))
+	})
+
+	It("compares generations", func() {
+		cctx := ctx.ConfigContext()
+		Expect(cctx.ApplyConfig(Must(cctx.GetConfigForData([]byte(oldConfig), nil)), "old")).To(Succeed())
+		gen := cctx.Generation()
+		Expect(cctx.ApplyConfig(Must(cctx.GetConfigForData([]byte(newConfig), nil)), "new")).To(Succeed())
+
+		d := Must(me.Generations(ctx, gen, cctx.Generation()))
+		Expect(d.String()).To(Equal(`+ attributes.config.mandelsoft.de[1]
++ credentials.config.mandelsoft.de[1]
++ sets.test/attributes.config.mandelsoft.de[0]
+`))
+		Expect(Must(me.Generations(ctx, 0, gen)).Changes).To(HaveLen(2))
+	})
+
+	It("redacts sensitive field names", func() {
+		o := Must(ctx.ConfigContext().GetConfigForData([]byte(`{"type": "attributes.config.mandelsoft.de", "attributes": {"github.com/mandelsoft/rootcerts": {"auth": "a"}}}`), nil))
+		n := Must(ctx.ConfigContext().GetConfigForData([]byte(`{"type": "attributes.config.mandelsoft.de", "attributes": {"github.com/mandelsoft/rootcerts": {"auth": "b"}}}`), nil))
+		d := Must(me.Configs(ctx, o, n))
+		Expect(d.String()).To(Equal("~ attributes.config.mandelsoft.de[0] attributes.github.com/mandelsoft/rootcerts.auth: \"***\" -> \"***\"\n"))
+	})
+})
@@ -0,0 +1,115 @@
+package diff
+
+import (
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/mandelsoft/ctxmgmt/utils/runtime"
+)
+
+// REDACTED is the replacement for sensitive values.
+const REDACTED = "***"
+
+// Redactor decides whether a field of a config object contains
+// sensitive information, which must not be shown in a diff.
+// The path describes the field, list indices are given as
+// elements of the form [<index>].
+type Redactor interface {
+	IsSensitive(path []string) bool
+}
+
+type RedactorFunction func(path []string) bool
+
+func (f RedactorFunction) IsSensitive(path []string) bool {
+	return f(path)
+}
+
+// SensitiveKeys are field names (compared case-insensitively)
+// considered to contain sensitive information for all config types.
+var SensitiveKeys = []string{
+	"password", "passwd", "secret", "token", "identitytoken", "registrytoken",
+	"privatekey", "auth", "apikey", "accesskey", "secretkey", "clientsecret",
+}
+
+// DefaultRedactor redacts fields with names listed in SensitiveKeys.
+var DefaultRedactor = RedactKeys(SensitiveKeys...)
+
+// RedactKeys provides a redactor for fields with the given
+// names (compared case-insensitively).
+func RedactKeys(keys ...string) Redactor {
+	return RedactorFunction(func(path []string) bool {
+		for _, e := range path {
+			if slices.ContainsFunc(keys, func(k string) bool { return strings.EqualFold(k, e) }) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// RedactBelow provides a redactor for all fields
+// nested in a field with one of the given names.
+func RedactBelow(names ...string) Redactor {
+	return RedactorFunction(func(path []string) bool {
+		for _, e := range path[:max(len(path)-1, 0)] {
+			if slices.Contains(names, e) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+type redactorRegistry struct {
+	lock      sync.Mutex
+	redactors map[string][]Redactor
+}
+
+var redactors = &redactorRegistry{redactors: map[string][]Redactor{}}
+
+// RegisterRedactor registers an additional redactor for a config type.
+// A versioned type is registered for the unversioned kind.
+func RegisterRedactor(typ string, r Redactor) {
+	kind, _ := runtime.KindVersion(typ)
+	redactors.lock.Lock()
+	defer redactors.lock.Unlock()
+	redactors.redactors[kind] = append(redactors.redactors[kind], r)
+}
+
+// redactorFor provides the redactor used for a config type.
+func redactorFor(kind string) Redactor {
+	redactors.lock.Lock()
+	list := append([]Redactor{DefaultRedactor}, redactors.redactors[kind]...)
+	redactors.lock.Unlock()
+	return RedactorFunction(func(path []string) bool {
+		for _, r := range list {
+			if r.IsSensitive(path) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// redact replaces sensitive values in a normalized value.
+func redact(r Redactor, path []string, v interface{}) interface{} {
+	if r.IsSensitive(path) {
+		return REDACTED
+	}
+	switch t := v.(type) {
+	case map[string]interface{}:
+		n := map[string]interface{}{}
+		for k, e := range t {
+			n[k] = redact(r, append(slices.Clone(path), k), e)
+		}
+		return n
+	case []interface{}:
+		n := make([]interface{}, len(t))
+		for i, e := range t {
+			n[i] = redact(r, append(slices.Clone(path), index(i)), e)
+		}
+		return n
+	}
+	return v
+}
@@ -71,3 +71,8 @@ func (d *dummyContext) update() error {
 	d.lastGeneration = gen
 	return err
 }
+
+func (d *dummyContext) ResetConfig() error {
+	d.applied = nil
+	return nil
+}
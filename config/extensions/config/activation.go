@@ -0,0 +1,48 @@
+package config
+
+import (
+	"os"
+
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	"github.com/mandelsoft/ctxmgmt/attrs/activationattr"
+	"github.com/mandelsoft/ctxmgmt/attrs/vfsattr"
+	"github.com/mandelsoft/ctxmgmt/config/cpi"
+)
+
+func init() {
+	cpi.RegisterActivationEnvironmentProvider(NewActivationEnvironment)
+}
+
+// NewActivationEnvironment provides the environment used to evaluate
+// activation conditions of config sets for a config context.
+// It is based on the process environment and the activation
+// settings (see activationattr) and the filesystem (see vfsattr)
+// of the context.
+func NewActivationEnvironment(ctx cpi.Context) cpi.ActivationEnvironment {
+	return &environment{ctx}
+}
+
+type environment struct {
+	ctx cpi.Context
+}
+
+func (e *environment) LookupEnv(name string) (string, bool) {
+	return os.LookupEnv(name)
+}
+
+func (e *environment) Hostname() (string, error) {
+	return os.Hostname()
+}
+
+func (e *environment) FileSystem() vfs.FileSystem {
+	return vfsattr.Get(e.ctx)
+}
+
+func (e *environment) Version() string {
+	return activationattr.Get(e.ctx).Version
+}
+
+func (e *environment) Profiles() []string {
+	return activationattr.Get(e.ctx).Profiles
+}
@@ -0,0 +1,184 @@
+package config_test
+
+import (
+	"os"
+	"runtime"
+
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	"github.com/mandelsoft/ctxmgmt/attrs/activationattr"
+	"github.com/mandelsoft/ctxmgmt/attrs/vfsattr"
+	"github.com/mandelsoft/ctxmgmt/config"
+)
+
+const activationConfig = `type: generic.config.mandelsoft.de/v1
+sets:
+  ci:
+    activation:
+      env:
+        TEST_ACTIVATION_CI: "true|yes"
+      os: [ ` + runtime.GOOS + ` ]
+    configurations:
+      - type: Dummy
+        alice: ci
+  release:
+    activation:
+      version: ">= 1.2"
+      files: [ /etc/release ]
+    configurations:
+      - type: Dummy
+        alice: release
+  dev:
+    activation:
+      profiles: [ dev, local ]
+    configurations:
+      - type: Dummy
+        alice: dev
+  manual:
+    configurations:
+      - type: Dummy
+        alice: manual
+`
+
+var _ = Describe("config set activation", func() {
+	var cfgctx config.Context
+
+	BeforeEach(func() {
+		scheme := config.NewConfigTypeScheme()
+		scheme.AddKnownTypes(config.DefaultContext().ConfigTypes())
+		RegisterAt(scheme)
+		cfgctx = config.WithConfigTypeScheme(scheme).New()
+	})
+
+	apply := func() {
+		cfg := Must(cfgctx.GetConfigForData([]byte(activationConfig), nil))
+		MustBeSuccessful(cfgctx.ApplyConfig(cfg, "file"))
+	}
+
+	active := func() []string {
+		var sets []string
+		for _, p := range cfgctx.Provenance(config.ByType(DummyType)) {
+			sets = append(sets, p.ConfigSet)
+		}
+		return sets
+	}
+
+	It("does not activate sets with unmet conditions", func() {
+		apply()
+		Expect(active()).To(BeEmpty())
+		Expect(cfgctx.ConfigSetActivations().String()).To(Equal(`config set ci: inactive
+  env TEST_ACTIVATION_CI=~"true|yes": not met (not set)
+  os [` + runtime.GOOS + `]: met (` + runtime.GOOS + `)
+config set dev: inactive
+  profiles [dev local]: not met (selected: none)
+config set release: inactive
+  file /etc/release: not met (not found)
+  version >= 1.2: not met (no application version)
+`))
+	})
+
+	It("activates sets by environment", func() {
+		os.Setenv("TEST_ACTIVATION_CI", "yes")
+		DeferCleanup(os.Unsetenv, "TEST_ACTIVATION_CI")
+
+		apply()
+		Expect(active()).To(Equal([]string{"ci"}))
+		Expect(cfgctx.ConfigSetActivations()[0].Active).To(BeTrue())
+	})
+
+	It("activates sets by profile", func() {
+		MustBeSuccessful(activationattr.SetProfiles(cfgctx, "local"))
+		apply()
+		Expect(active()).To(Equal([]string{"dev"}))
+	})
+
+	It("activates sets by version and files", func() {
+		fs := memoryfs.New()
+		MustBeSuccessful(fs.MkdirAll("/etc", 0o700))
+		MustBeSuccessful(vfs.WriteFile(fs, "/etc/release", nil, 0o600))
+		vfsattr.Set(cfgctx, fs)
+		MustBeSuccessful(activationattr.SetVersion(cfgctx, "1.1.0"))
+		apply()
+		Expect(active()).To(BeEmpty())
+
+		MustBeSuccessful(activationattr.SetVersion(cfgctx, "1.3.0"))
+		apply()
+		Expect(active()).To(Equal([]string{"release"}))
+		Expect(cfgctx.ConfigSetActivations()[2].String()).To(Equal(`config set release: active
+  file /etc/release: met (exists)
+  version >= 1.2: met (1.3.0)
+`))
+	})
+
+	It("reports invalid conditions", func() {
+		cfgctx.AddConfigSet("invalid", &config.ConfigSet{Activation: &config.Activation{Version: "no version"}})
+		ok, err := cfgctx.ActivateConfigSet("invalid")
+		Expect(ok).To(BeFalse())
+		Expect(err).To(MatchError(ContainSubstring(`config set "invalid": invalid activation conditions: version no version:`)))
+		Expect(cfgctx.ConfigSetActivations()[0].Error).NotTo(BeEmpty())
+	})
+
+	It("does not activate sets without conditions", func() {
+		apply()
+		Expect(Must(cfgctx.ActivateConfigSet("manual"))).To(BeFalse())
+		MustBeSuccessful(cfgctx.ApplyConfigSet("manual"))
+		Expect(active()).To(Equal([]string{"manual"}))
+	})
+
+	It("evaluates conditions for a given environment", func() {
+		env := &testEnvironment{
+			env:      map[string]string{"STAGE": "prod"},
+			hostname: "build-01",
+			profiles: []string{"dev"},
+		}
+		a := &config.Activation{
+			Env:      map[string]string{"STAGE": "prod|staging"},
+			Hostname: []string{"build-*"},
+			Profiles: []string{"dev"},
+			Files:    []string{"/etc/release"},
+		}
+		r, err := a.Evaluate(env)
+		MustBeSuccessful(err)
+		Expect(r.Active).To(BeFalse())
+		Expect(r.String()).To(Equal(`config set : inactive
+  env STAGE=~"prod|staging": met ("prod")
+  hostname [build-*]: met ("build-01")
+  file /etc/release: not met (no filesystem)
+  profiles [dev]: met (selected: dev)
+`))
+	})
+})
+
+type testEnvironment struct {
+	env      map[string]string
+	hostname string
+	profiles []string
+}
+
+var _ config.ActivationEnvironment = (*testEnvironment)(nil)
+
+func (e *testEnvironment) LookupEnv(name string) (string, bool) {
+	v, ok := e.env[name]
+	return v, ok
+}
+
+func (e *testEnvironment) Hostname() (string, error) {
+	return e.hostname, nil
+}
+
+func (e *testEnvironment) FileSystem() vfs.FileSystem {
+	return nil
+}
+
+func (e *testEnvironment) Version() string {
+	return ""
+}
+
+func (e *testEnvironment) Profiles() []string {
+	return e.profiles
+}
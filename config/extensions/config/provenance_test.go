@@ -0,0 +1,103 @@
+package config_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/ctxmgmt/config"
+)
+
+const provenanceConfig = `type: generic.config.mandelsoft.de/v1
+configurations:
+  - type: Dummy
+    alice: a
+  - type: generic.config.mandelsoft.de/v1
+    configurations:
+      - type: Dummy
+        alice: nested
+sets:
+  test:
+    configurations:
+      - type: Dummy
+        alice: set
+`
+
+var _ = Describe("config provenance", func() {
+	var cfgctx config.Context
+
+	BeforeEach(func() {
+		scheme := config.NewConfigTypeScheme()
+		scheme.AddKnownTypes(config.DefaultContext().ConfigTypes())
+		RegisterAt(scheme)
+		cfgctx = config.WithConfigTypeScheme(scheme).New()
+
+		cfg, err := cfgctx.GetConfigForData([]byte(provenanceConfig), nil)
+		Expect(err).To(Succeed())
+		src := config.NewConfigSourceForData("/etc/app/config.yaml", []byte(provenanceConfig))
+		Expect(cfgctx.ApplyConfig(cfg, "file", config.WithSource(src))).To(Succeed())
+	})
+
+	It("records nested config objects", func() {
+		Expect(cfgctx.Provenance(config.ByType(DummyType))).To(Equal(config.Provenances{
+			{
+				Generation:  2,
+				Type:        DummyType,
+				Description: "config entry 0--file",
+				Path:        []string{"file", "config entry 0"},
+				Source:      "/etc/app/config.yaml",
+				Element:     "configurations[0]",
+				Line:        3,
+				Column:      5,
+				Origin:      1,
+			},
+			{
+				Generation:  4,
+				Type:        DummyType,
+				Description: "config entry 0--config entry 1--file",
+				Path:        []string{"file", "config entry 1", "config entry 0"},
+				Source:      "/etc/app/config.yaml",
+				Element:     "configurations[1].configurations[0]",
+				Line:        7,
+				Column:      9,
+				Origin:      3,
+			},
+		}))
+	})
+
+	It("keeps descriptions containing the separator", func() {
+		cctx := config.WithConfigTypeScheme(cfgctx.ConfigTypes()).New()
+		cfg, err := cctx.GetConfigForData([]byte(provenanceConfig), nil)
+		Expect(err).To(Succeed())
+		Expect(cctx.ApplyConfig(cfg, "my--file")).To(Succeed())
+
+		p := cctx.Provenance(config.ByType(DummyType))
+		Expect(p).To(HaveLen(2))
+		Expect(p[0].Path).To(Equal([]string{"my--file", "config entry 0"}))
+		Expect(p[1].Path).To(Equal([]string{"my--file", "config entry 1", "config entry 0"}))
+	})
+
+	It("records config sets", func() {
+		Expect(cfgctx.ApplyConfigSet("test")).To(Succeed())
+		p := cfgctx.Provenance(config.ByConfigSet("test"))
+		Expect(p).To(HaveLen(1))
+		Expect(p[0].Location()).To(Equal("/etc/app/config.yaml:12:9 (sets.test.configurations[0])"))
+		Expect(p[0].Origin).To(BeZero())
+	})
+
+	It("explains config objects", func() {
+		Expect(config.Explain(cfgctx, config.ByDescription("config entry 0--file"))).To(Equal(`generation 2: Dummy
+  source:      /etc/app/config.yaml:3:5 (configurations[0])
+  applied by:  file -> config entry 0
+  origin:      generation 1
+`))
+		Expect(config.Explain(cfgctx, config.ByType("unknown"))).To(Equal("no config objects\n"))
+	})
+
+	It("keeps provenance for inherited config objects", func() {
+		overlay := config.NewOverlay(cfgctx)
+		p := overlay.Provenance(config.BySource("/etc/app/config.yaml"))
+		Expect(p).To(HaveLen(4))
+		Expect(p[0].Inherited).To(BeTrue())
+		Expect(p[0].Line).To(Equal(1))
+	})
+})
@@ -2,8 +2,10 @@ package config
 
 import (
 	"fmt"
+	"slices"
 
 	"github.com/mandelsoft/goutils/errors"
+	"github.com/mandelsoft/goutils/maputils"
 	"github.com/mandelsoft/goutils/sliceutils"
 
 	"github.com/mandelsoft/ctxmgmt/config/cpi"
@@ -74,13 +76,21 @@ func (c *Config) ApplyTo(ctx cpi.Context, target interface{}) error {
 		list := errors.ErrListf("applying generic config list")
 		for i, cfg := range c.Configurations {
 			sub := fmt.Sprintf("config entry %d", i)
-			list.Add(cctx.ApplyConfig(cfg, ctx.WithInfo(sub).Info()))
+			list.Add(cctx.ApplyConfig(cfg, ctx.WithInfo(sub).Info(), cpi.WithElement(fmt.Sprintf("configurations[%d]", i))))
 		}
 
 		for _, s := range c.SetActivations {
 			err := cctx.ApplyConfigSet(s)
 			list.Add(errors.Wrapf(err, "applying config set %q", s))
 		}
+
+		// explicitly activated sets are not evaluated, again.
+		for _, n := range maputils.OrderedKeys(c.Sets) {
+			if c.Sets[n].Activation != nil && !slices.Contains(c.SetActivations, n) {
+				_, err := cctx.ActivateConfigSet(n)
+				list.Add(errors.Wrapf(err, "activating config set %q", n))
+			}
+		}
 		return list.Result()
 	}
 	return cpi.ErrNoContext(ConfigType)
@@ -109,4 +119,27 @@ Configurations are directly applied. Configuration sets are
 just stored in the configuration context and can be applied
 on-demand. On the CLI, this can be done using the main command option
 <code>--config-set &lt;name></code>.
+
+Sets listed in <code>setActivations</code> are applied directly.
+Sets may declare activation conditions, which are evaluated when the
+config is applied. The set is applied if all conditions are met:
+
+<pre>
+    sets:
+       ci:
+          activation:
+            env:              # variables must be set (and match the regular expression)
+              CI: "true"
+            hostname: [ "build-*" ] # glob patterns for the host name
+            os: [ linux ]
+            arch: [ amd64, arm64 ]
+            files: [ /etc/ci.conf ] # files must exist
+            version: ">= 1.2"       # semver constraint for the application version
+            profiles: [ ci ]        # one of the profiles must be selected
+          configurations:
+            ...
+</pre>
+
+The application version and the selected profiles are taken from the
+attribute <code>github.com/mandelsoft/ctxmgmt/activation</code>.
 `
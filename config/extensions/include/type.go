@@ -0,0 +1,289 @@
+package include
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/mandelsoft/goutils/errors"
+	"github.com/mandelsoft/goutils/ioutils"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	"github.com/mandelsoft/ctxmgmt/attrs/vfsattr"
+	"github.com/mandelsoft/ctxmgmt/config/cpi"
+	"github.com/mandelsoft/ctxmgmt/utils/runtime"
+)
+
+const (
+	ConfigType   = "include" + cpi.CONFIG_TYPE_SUFFIX
+	ConfigTypeV1 = ConfigType + runtime.VersionSeparator + "v1"
+)
+
+func init() {
+	cpi.RegisterConfigType(cpi.NewConfigType[*Config](ConfigType, usage))
+	cpi.RegisterConfigType(cpi.NewConfigType[*Config](ConfigTypeV1, usage))
+}
+
+// Extensions are the file extensions of config files
+// included from a directory.
+var Extensions = []string{".yaml", ".yml", ".json"}
+
+// Include describes a config file, a directory or a glob pattern
+// for config files to include.
+type Include struct {
+	// Path is the path of a config file or directory, or a
+	// glob pattern. Relative paths are resolved relative to
+	// the directory of the including config file.
+	Path string `json:"path"`
+	// Optional accepts missing files, directories and
+	// patterns without matches.
+	Optional bool `json:"optional,omitempty"`
+}
+
+// Config describes the inclusion of config files.
+type Config struct {
+	runtime.ObjectVersionedType `json:",inline"`
+	Includes                    []Include `json:"includes,omitempty"`
+}
+
+// New creates a new include config object.
+func New() *Config {
+	return &Config{
+		ObjectVersionedType: runtime.NewVersionedTypedObject(ConfigType),
+	}
+}
+
+// AddInclude adds a required include.
+func (c *Config) AddInclude(path string) {
+	c.Includes = append(c.Includes, Include{Path: path})
+}
+
+// AddOptionalInclude adds an optional include.
+func (c *Config) AddOptionalInclude(path string) {
+	c.Includes = append(c.Includes, Include{Path: path, Optional: true})
+}
+
+func (c *Config) GetType() string {
+	return ConfigType
+}
+
+func (c *Config) ApplyTo(ctx cpi.Context, target interface{}) error {
+	cctx, ok := target.(cpi.Context)
+	if !ok {
+		return cpi.ErrNoContext(ConfigType)
+	}
+	fs := vfsattr.Get(ctx)
+
+	// the source documents of the config objects applying this
+	// include are used to resolve relative paths and detect cycles.
+	var chain []string
+	included := map[string]bool{}
+	for _, o := range cctx.Origins() {
+		if o.Source == "" {
+			continue
+		}
+		if id := canonical(fs, o.Source); !included[id] {
+			chain = append([]string{o.Source}, chain...)
+			included[id] = true
+		}
+	}
+	dir := ""
+	if len(chain) > 0 {
+		dir = vfs.Dir(fs, chain[len(chain)-1])
+	}
+
+	list := errors.ErrListf("applying includes")
+	for i, inc := range c.Includes {
+		files, err := inc.resolve(fs, dir)
+		if err != nil {
+			list.Add(errors.Wrapf(err, "includes[%d]", i))
+			continue
+		}
+		for _, f := range files {
+			if included[canonical(fs, f)] {
+				list.Add(errors.Newf("include cycle: %s -> %s", strings.Join(chain, " -> "), f))
+				continue
+			}
+			list.Add(apply(ctx, cctx, fs, f))
+		}
+	}
+	return list.Result()
+}
+
+// apply processes an included config file like cfgutils.ConfigureByData
+// and applies it with the file as source.
+func apply(ctx, cctx cpi.Context, fs vfs.FileSystem, file string) error {
+	data, err := vfs.ReadFile(fs, file)
+	if err != nil {
+		return errors.Wrapf(err, "cannot read config file %q", file)
+	}
+	cfg, src, err := cpi.DecodeConfigData(cctx, data, file)
+	if err != nil {
+		return err
+	}
+	return cctx.ApplyConfig(cfg, ctx.WithInfo("include "+file).Info(), cpi.WithSource(src))
+}
+
+// resolve determines the config files described by an include.
+func (i *Include) resolve(fs vfs.FileSystem, dir string) ([]string, error) {
+	if i.Path == "" {
+		return nil, errors.ErrInvalid("include path", "")
+	}
+	p, err := ioutils.ResolvePath(i.Path)
+	if err != nil {
+		return nil, err
+	}
+	if dir != "" && !vfs.IsAbs(fs, p) {
+		p = vfs.Clean(fs, vfs.Join(fs, dir, p))
+	}
+
+	var paths []string
+	if hasMeta(p) {
+		paths, err = glob(fs, p)
+		if err != nil {
+			return nil, err
+		}
+		if len(paths) == 0 && !i.Optional {
+			return nil, errors.Newf("no config files found for pattern %q", p)
+		}
+	} else {
+		ok, err := vfs.Exists(fs, p)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			if i.Optional {
+				return nil, nil
+			}
+			return nil, errors.ErrNotFound("config file", p)
+		}
+		paths = []string{p}
+	}
+
+	var files []string
+	for _, p := range paths {
+		ok, err := vfs.IsDir(fs, p)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			files = append(files, p)
+			continue
+		}
+		entries, err := vfs.ReadDir(fs, p)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot read config directory %q", p)
+		}
+		var names []string
+		for _, e := range entries {
+			if !e.IsDir() && isConfigFile(e.Name()) {
+				names = append(names, e.Name())
+			}
+		}
+		sort.Strings(names)
+		for _, n := range names {
+			files = append(files, vfs.Join(fs, p, n))
+		}
+	}
+	return files, nil
+}
+
+func isConfigFile(name string) bool {
+	for _, e := range Extensions {
+		if strings.HasSuffix(name, e) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasMeta(p string) bool {
+	return strings.ContainsAny(p, "*?[")
+}
+
+// glob provides the paths matching a pattern, which may
+// use glob patterns (see path.Match) for any path element.
+func glob(fs vfs.FileSystem, pattern string) ([]string, error) {
+	vol, elems, rooted := vfs.SplitPath(fs, pattern)
+	base := vol
+	if rooted {
+		base += vfs.PathSeparatorString
+	}
+	paths := []string{base}
+	for _, e := range elems {
+		var next []string
+		for _, p := range paths {
+			if !hasMeta(e) {
+				next = append(next, join(fs, p, e))
+				continue
+			}
+			d := p
+			if d == "" {
+				d = "."
+			}
+			if ok, _ := vfs.DirExists(fs, d); !ok {
+				continue
+			}
+			entries, err := vfs.ReadDir(fs, d)
+			if err != nil {
+				return nil, err
+			}
+			for _, entry := range entries {
+				ok, err := path.Match(e, entry.Name())
+				if err != nil {
+					return nil, errors.Wrapf(err, "invalid pattern %q", pattern)
+				}
+				if ok {
+					next = append(next, join(fs, p, entry.Name()))
+				}
+			}
+		}
+		paths = next
+	}
+
+	var result []string
+	for _, p := range paths {
+		if ok, _ := vfs.Exists(fs, p); ok {
+			result = append(result, p)
+		}
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+func join(fs vfs.FileSystem, dir, name string) string {
+	if dir == "" {
+		return name
+	}
+	return vfs.Join(fs, dir, name)
+}
+
+// canonical provides a unique name for a file used to detect cycles.
+func canonical(fs vfs.FileSystem, file string) string {
+	p, err := vfs.Canonical(fs, file, true)
+	if err != nil {
+		return vfs.Clean(fs, file)
+	}
+	return p
+}
+
+const usage = `
+The config type <code>` + ConfigType + `</code> can be used to include
+other config files:
+
+<pre>
+    type: ` + ConfigType + `
+    includes:
+      - path: common.yaml       # a config file
+      - path: conf.d            # all *.yaml, *.yml and *.json files of a directory
+      - path: teams/*/config.yaml # glob pattern
+      - path: local.yaml
+        optional: true          # ignore missing files or patterns without matches
+</pre>
+
+Relative paths are resolved relative to the directory of the including
+config file. Included files are processed like the including file
+(including spiff processing) and applied in the given order, files found
+in directories or for glob patterns in lexical order. Include cycles
+are rejected.
+`
@@ -0,0 +1,167 @@
+package include_test
+
+import (
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	"github.com/mandelsoft/ctxmgmt/attrs/encryptionattr"
+	"github.com/mandelsoft/ctxmgmt/attrs/vfsattr"
+	"github.com/mandelsoft/ctxmgmt/config"
+	"github.com/mandelsoft/ctxmgmt/config/cfgutils"
+	"github.com/mandelsoft/ctxmgmt/config/cpi"
+	me "github.com/mandelsoft/ctxmgmt/config/extensions/include"
+	"github.com/mandelsoft/ctxmgmt/utils/encryption"
+	"github.com/mandelsoft/ctxmgmt/utils/runtime"
+)
+
+const DummyType = "Dummy"
+
+type Dummy struct {
+	runtime.ObjectVersionedType `json:",inline"`
+	Value                       string `json:"value,omitempty"`
+}
+
+func (d *Dummy) ApplyTo(ctx config.Context, target interface{}) error {
+	return cpi.ErrNoContext(DummyType)
+}
+
+var _ = Describe("include config", func() {
+	var cfgctx config.Context
+	var fs vfs.FileSystem
+
+	BeforeEach(func() {
+		scheme := config.NewConfigTypeScheme()
+		scheme.AddKnownTypes(config.DefaultContext().ConfigTypes())
+		scheme.Register(cpi.NewConfigType[*Dummy](DummyType))
+		cfgctx = config.WithConfigTypeScheme(scheme).New()
+		fs = memoryfs.New()
+		vfsattr.Set(cfgctx, fs)
+	})
+
+	write := func(path, data string) {
+		MustBeSuccessful(fs.MkdirAll(vfs.Dir(fs, path), 0o700))
+		MustBeSuccessful(vfs.WriteFile(fs, path, []byte(data), 0o600))
+	}
+
+	dummy := func(value string) string {
+		return "type: " + DummyType + "\nvalue: " + value + "\n"
+	}
+
+	applied := func() []string {
+		var result []string
+		_, list := cfgctx.GetConfigForType(0, DummyType)
+		for _, c := range list {
+			result = append(result, c.(*Dummy).Value)
+		}
+		return result
+	}
+
+	sources := func() []string {
+		var result []string
+		for _, p := range cfgctx.Provenance(config.ByType(DummyType)) {
+			result = append(result, p.Source)
+		}
+		return result
+	}
+
+	It("includes files, directories and patterns", func() {
+		write("/config/main.yaml", `
+type: `+me.ConfigType+`
+includes:
+  - path: base.yaml
+  - path: conf.d
+  - path: teams/*/config.yaml
+  - path: missing.yaml
+    optional: true
+  - path: missing/*.yaml
+    optional: true
+`)
+		write("/config/base.yaml", dummy(`(( "ba" "se" ))`))
+		write("/config/conf.d/b.yaml", dummy("b"))
+		write("/config/conf.d/a.json", `{"type": "`+DummyType+`", "value": "a"}`)
+		write("/config/conf.d/README.md", "ignored")
+		write("/config/teams/x/config.yaml", `
+type: `+me.ConfigType+`
+includes:
+  - path: local.yaml
+`)
+		write("/config/teams/x/local.yaml", dummy("team-x"))
+		write("/config/teams/y/config.yaml", dummy("team-y"))
+
+		MustBeSuccessful(cfgutils.Configure(cfgctx, "/config/main.yaml", fs))
+		Expect(applied()).To(Equal([]string{"base", "a", "b", "team-y", "team-x"}))
+		Expect(sources()).To(Equal([]string{
+			"/config/base.yaml",
+			"/config/conf.d/a.json",
+			"/config/conf.d/b.yaml",
+			"/config/teams/y/config.yaml",
+			"/config/teams/x/local.yaml",
+		}))
+		p := cfgctx.Provenance(config.BySource("/config/teams/x/local.yaml"))
+		Expect(p).To(HaveLen(1))
+		Expect(p[0].Line).To(Equal(1))
+		Expect(cfgctx.Provenance(config.BySource("/config/teams/x/config.yaml"))[0].Type).To(Equal(me.ConfigType))
+	})
+
+	It("rejects missing files", func() {
+		write("/config/main.yaml", `
+type: `+me.ConfigType+`
+includes:
+  - path: missing.yaml
+  - path: missing/*.yaml
+`)
+		err := cfgutils.Configure(cfgctx, "/config/main.yaml", fs)
+		Expect(err).To(MatchError(ContainSubstring(`includes[0]: config file "/config/missing.yaml" not found`)))
+		Expect(err).To(MatchError(ContainSubstring(`includes[1]: no config files found for pattern "/config/missing/*.yaml"`)))
+	})
+
+	It("detects include cycles", func() {
+		write("/config/a.yaml", `
+type: `+me.ConfigType+`
+includes:
+  - path: sub/b.yaml
+`)
+		write("/config/sub/b.yaml", `
+type: generic.config.mandelsoft.de
+configurations:
+  - type: `+DummyType+`
+    value: b
+  - type: `+me.ConfigType+`
+    includes:
+      - path: ../a.yaml
+`)
+		err := cfgutils.Configure(cfgctx, "/config/a.yaml", fs)
+		Expect(err).To(MatchError(ContainSubstring("include cycle: /config/a.yaml -> /config/sub/b.yaml -> /config/a.yaml")))
+		Expect(applied()).To(Equal([]string{"b"}))
+	})
+
+	It("decrypts included files", func() {
+		key := Must(encryption.GenerateKey())
+		MustBeSuccessful(encryption.WriteKeyFile(fs, "/key", key))
+		MustBeSuccessful(encryptionattr.AddKeyFiles(cfgctx, "/key"))
+
+		write("/config/main.yaml", `
+type: `+me.ConfigType+`
+includes:
+  - path: secret.yaml
+`)
+		write("/config/secret.yaml", dummy("secret"))
+		MustBeSuccessful(cfgutils.EncryptFile("/config/secret.yaml", key, []string{"value"}, fs))
+
+		MustBeSuccessful(cfgutils.Configure(cfgctx, "/config/main.yaml", fs))
+		Expect(applied()).To(Equal([]string{"secret"}))
+	})
+
+	It("resolves paths relative to the working directory without source", func() {
+		write("/base.yaml", dummy("base"))
+		cfg := me.New()
+		cfg.AddInclude("base.yaml")
+		cfg.AddOptionalInclude("other.yaml")
+		MustBeSuccessful(cfgctx.ApplyConfig(cfg, "test"))
+		Expect(applied()).To(Equal([]string{"base"}))
+	})
+})
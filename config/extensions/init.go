@@ -3,4 +3,5 @@ package extensions
 import (
 	_ "github.com/mandelsoft/ctxmgmt/config/extensions/config"
 	_ "github.com/mandelsoft/ctxmgmt/config/extensions/data"
+	_ "github.com/mandelsoft/ctxmgmt/config/extensions/include"
 )
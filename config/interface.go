@@ -14,6 +14,12 @@ const CONFIG_TYPE_SUFFIX = internal.CONFIG_TYPE_SUFFIX
 
 const CONTEXT_TYPE = internal.CONTEXT_TYPE
 
+const (
+	ELEMENT_ATTRIBUTES  = internal.ELEMENT_ATTRIBUTES
+	ELEMENT_CONFIGTYPES = internal.ELEMENT_CONFIGTYPES
+	ELEMENT_APPLIERS    = internal.ELEMENT_APPLIERS
+)
+
 var AllConfigs = internal.AllConfigs
 
 const AllGenerations = internal.AllGenerations
@@ -29,6 +35,34 @@ type (
 	GenericConfig          = internal.GenericConfig
 	ConfigSelector         = internal.ConfigSelector
 	ConfigSelectorFunction = internal.ConfigSelectorFunction
+	ConfigHandle           = internal.ConfigHandle
+	ConfigResetter         = internal.ConfigResetter
+
+	AppliedConfig                 = internal.AppliedConfig
+	AppliedConfigs                = internal.AppliedConfigs
+	AppliedConfigSelector         = internal.AppliedConfigSelector
+	AppliedConfigSelectorFunction = internal.AppliedConfigSelectorFunction
+
+	ApplyOption  = internal.ApplyOption
+	ApplyOptions = internal.ApplyOptions
+	ConfigSource = internal.ConfigSource
+
+	ConfigDataProcessor         = internal.ConfigDataProcessor
+	ConfigDataProcessorFunction = internal.ConfigDataProcessorFunction
+	Position                    = internal.Position
+	Provenance                  = internal.Provenance
+	Provenances                 = internal.Provenances
+
+	Activation        = internal.Activation
+	ActivationResult  = internal.ActivationResult
+	ActivationResults = internal.ActivationResults
+	ConditionResult   = internal.ConditionResult
+
+	ActivationEnvironment         = internal.ActivationEnvironment
+	ActivationEnvironmentProvider = internal.ActivationEnvironmentProvider
+
+	Description              = internal.Description
+	AppliedConfigDescription = internal.AppliedConfigDescription
 
 	ConfigApplier         = internal.ConfigApplier
 	ConfigApplierFunction = internal.ConfigApplierFunction
@@ -82,3 +116,111 @@ func IsErrConfigNotApplicable(err error) bool {
 func NewConfigSet(desc string) *ConfigSet {
 	return internal.NewConfigSet(desc)
 }
+
+// ByHandle selects applied config objects by their handles.
+func ByHandle(handles ...ConfigHandle) AppliedConfigSelector {
+	return internal.AppliedHandleSelector(handles...)
+}
+
+// ByDescription selects config objects applied with the given description.
+func ByDescription(desc string) AppliedConfigSelector {
+	return internal.AppliedDescriptionSelector(desc)
+}
+
+// ByGenerationRange selects config objects applied with a generation
+// in the range [from, to].
+func ByGenerationRange(from, to int64) AppliedConfigSelector {
+	return internal.AppliedGenerationRangeSelector(from, to)
+}
+
+// ByType selects config objects with the given config type.
+// A type without version matches all versions of the type.
+func ByType(typ string) AppliedConfigSelector {
+	return internal.AppliedTypeSelector(typ)
+}
+
+// ByConfigSet selects config objects provided by the given config set.
+func ByConfigSet(name string) AppliedConfigSelector {
+	return internal.AppliedConfigSetSelector(name)
+}
+
+// BySource selects config objects read from the given source document.
+func BySource(name string) AppliedConfigSelector {
+	return internal.AppliedSourceSelector(name)
+}
+
+// JoinElementPath joins element paths used to describe
+// fields of config documents (see ConfigSource).
+func JoinElementPath(path string, elems ...string) string {
+	return internal.JoinElementPath(path, elems...)
+}
+
+// NewConfigSource creates a source description without
+// position information.
+func NewConfigSource(name string) *ConfigSource {
+	return internal.NewConfigSource(name)
+}
+
+// NewConfigSourceForData creates a source description for
+// YAML or JSON data including the positions of its elements.
+func NewConfigSourceForData(name string, data []byte) *ConfigSource {
+	return internal.NewConfigSourceForData(name, data)
+}
+
+// ProcessConfigData preprocesses config data with spiff and
+// provides the processed data together with the description of
+// the source document.
+func ProcessConfigData(data []byte, info string) ([]byte, *ConfigSource, error) {
+	return internal.ProcessConfigData(data, info)
+}
+
+// DecodeConfigData preprocesses config data like ProcessConfigData,
+// applies the registered ConfigDataProcessors and decodes the
+// resulting config object together with the description of the
+// source document.
+func DecodeConfigData(ctx ContextProvider, data []byte, info string) (Config, *ConfigSource, error) {
+	return internal.DecodeConfigData(ctx, data, info)
+}
+
+// RegisterConfigDataProcessor registers a processor for config data
+// used by DecodeConfigData. Processors are applied in the order of
+// their names.
+func RegisterConfigDataProcessor(name string, p ConfigDataProcessor) {
+	internal.RegisterConfigDataProcessor(name, p)
+}
+
+// RegisterActivationEnvironmentProvider registers the provider used
+// to determine the environment for the evaluation of activation
+// conditions of config sets.
+func RegisterActivationEnvironmentProvider(p ActivationEnvironmentProvider) {
+	internal.RegisterActivationEnvironmentProvider(p)
+}
+
+// GetActivationEnvironment provides the environment used to evaluate
+// activation conditions for a config context.
+func GetActivationEnvironment(ctx Context) ActivationEnvironment {
+	return internal.GetActivationEnvironment(ctx)
+}
+
+// WithSource sets the source document of an applied config object.
+func WithSource(src *ConfigSource) ApplyOption {
+	return internal.WithSource(src)
+}
+
+// WithElement sets the element path of an applied config object.
+// Without a source, it is relative to the element path of the
+// config object applying it.
+func WithElement(path string) ApplyOption {
+	return internal.WithElement(path)
+}
+
+// WithConfigSet sets the config set providing an applied config object.
+func WithConfigSet(name string) ApplyOption {
+	return internal.WithConfigSet(name)
+}
+
+// Explain provides a human-readable explanation of where the
+// selected config objects applied to a config context come from.
+func Explain(ctx ContextProvider, selector AppliedConfigSelector) string {
+	return ctx.ConfigContext().Provenance(selector).String()
+}
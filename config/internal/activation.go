@@ -0,0 +1,302 @@
+package internal
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	goruntime "runtime"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/mandelsoft/goutils/errors"
+	"github.com/mandelsoft/goutils/maputils"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	"github.com/mandelsoft/ctxmgmt/utils"
+)
+
+// Activation describes the conditions for the automatic activation
+// of a config set, when the generic config object defining the set
+// is applied. All given conditions must be met.
+type Activation struct {
+	// Env requires environment variables to be set. If a non-empty
+	// value is given, it is a regular expression the complete
+	// variable value must match.
+	Env map[string]string `json:"env,omitempty"`
+	// Hostname requires the host name to match one of the
+	// given glob patterns.
+	Hostname []string `json:"hostname,omitempty"`
+	// OS requires the operating system to be one of the given ones.
+	OS []string `json:"os,omitempty"`
+	// Arch requires the architecture to be one of the given ones.
+	Arch []string `json:"arch,omitempty"`
+	// Files requires the given files to exist in the file system
+	// of the context.
+	Files []string `json:"files,omitempty"`
+	// Version is a semver constraint the application version
+	// configured for the context must match.
+	Version string `json:"version,omitempty"`
+	// Profiles requires one of the given profiles
+	// to be selected for the context.
+	Profiles []string `json:"profiles,omitempty"`
+}
+
+// ConditionResult describes the evaluation of a single
+// activation condition.
+type ConditionResult struct {
+	Condition string `json:"condition"`
+	Met       bool   `json:"met"`
+	// Reason describes the found situation.
+	Reason string `json:"reason,omitempty"`
+}
+
+func (r ConditionResult) String() string {
+	s := "not met"
+	if r.Met {
+		s = "met"
+	}
+	if r.Reason != "" {
+		s += " (" + r.Reason + ")"
+	}
+	return r.Condition + ": " + s
+}
+
+// ActivationResult describes the evaluation of the activation
+// conditions of a config set.
+type ActivationResult struct {
+	ConfigSet string `json:"configSet"`
+	// Generation is the config generation the conditions
+	// have been evaluated for.
+	Generation int64             `json:"generation"`
+	Active     bool              `json:"active"`
+	Conditions []ConditionResult `json:"conditions,omitempty"`
+	// Error describes invalid conditions.
+	Error string `json:"error,omitempty"`
+}
+
+func (r *ActivationResult) Print(p utils.Printer) {
+	state := "inactive"
+	if r.Active {
+		state = "active"
+	}
+	p.Printf("config set %s: %s\n", r.ConfigSet, state)
+	p = p.AddGap("  ")
+	if r.Error != "" {
+		p.Printf("error: %s\n", r.Error)
+	}
+	for _, c := range r.Conditions {
+		p.Printf("%s\n", c)
+	}
+}
+
+func (r *ActivationResult) String() string {
+	p, buf := utils.NewBufferedPrinter()
+	r.Print(p)
+	return buf.String()
+}
+
+// ActivationResults is a list of activation results
+// ordered by config set name.
+type ActivationResults []*ActivationResult
+
+func (r ActivationResults) Print(p utils.Printer) {
+	if len(r) == 0 {
+		p.Printf("no config set activations\n")
+		return
+	}
+	for _, e := range r {
+		e.Print(p)
+	}
+}
+
+func (r ActivationResults) String() string {
+	p, buf := utils.NewBufferedPrinter()
+	r.Print(p)
+	return buf.String()
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// ActivationEnvironment provides the information about the
+// environment of a config context required to evaluate
+// activation conditions.
+type ActivationEnvironment interface {
+	// LookupEnv provides the value of an environment variable.
+	LookupEnv(name string) (string, bool)
+	// Hostname provides the name of the host.
+	Hostname() (string, error)
+	// FileSystem provides the file system used to check for files.
+	FileSystem() vfs.FileSystem
+	// Version provides the application version.
+	Version() string
+	// Profiles provides the selected profiles.
+	Profiles() []string
+}
+
+// ActivationEnvironmentProvider provides the activation
+// environment for a config context.
+type ActivationEnvironmentProvider func(ctx Context) ActivationEnvironment
+
+var activationEnvironment struct {
+	lock     sync.Mutex
+	provider ActivationEnvironmentProvider
+}
+
+// RegisterActivationEnvironmentProvider registers the provider used
+// to determine the activation environment of config contexts.
+func RegisterActivationEnvironmentProvider(p ActivationEnvironmentProvider) {
+	activationEnvironment.lock.Lock()
+	defer activationEnvironment.lock.Unlock()
+	activationEnvironment.provider = p
+}
+
+// GetActivationEnvironment provides the activation environment
+// for a config context. Without registered provider, an empty
+// environment is provided.
+func GetActivationEnvironment(ctx Context) ActivationEnvironment {
+	activationEnvironment.lock.Lock()
+	p := activationEnvironment.provider
+	activationEnvironment.lock.Unlock()
+	if p == nil {
+		return emptyEnvironment{}
+	}
+	return p(ctx)
+}
+
+type emptyEnvironment struct{}
+
+func (emptyEnvironment) LookupEnv(name string) (string, bool) { return "", false }
+func (emptyEnvironment) Hostname() (string, error) {
+	return "", errors.New("no activation environment")
+}
+func (emptyEnvironment) FileSystem() vfs.FileSystem { return nil }
+func (emptyEnvironment) Version() string            { return "" }
+func (emptyEnvironment) Profiles() []string         { return nil }
+
+// Evaluate evaluates the activation conditions for the given
+// environment (see GetActivationEnvironment).
+// Invalid conditions are never met and reported as error.
+func (a *Activation) Evaluate(env ActivationEnvironment) (*ActivationResult, error) {
+	result := &ActivationResult{Active: true}
+	list := errors.ErrListf("invalid activation conditions")
+
+	add := func(cond string, met bool, reason string, args ...interface{}) {
+		result.Conditions = append(result.Conditions, ConditionResult{
+			Condition: cond,
+			Met:       met,
+			Reason:    fmt.Sprintf(reason, args...),
+		})
+		result.Active = result.Active && met
+	}
+	invalid := func(cond string, err error) {
+		add(cond, false, "invalid: %s", err)
+		list.Add(errors.Wrapf(err, "%s", cond))
+	}
+
+	for _, n := range maputils.OrderedKeys(a.Env) {
+		cond := "env " + n
+		pattern := a.Env[n]
+		if pattern != "" {
+			cond += fmt.Sprintf("=~%q", pattern)
+		}
+		v, ok := env.LookupEnv(n)
+		switch {
+		case !ok:
+			add(cond, false, "not set")
+		case pattern == "":
+			add(cond, true, "set")
+		default:
+			exp, err := regexp.Compile("^(?:" + pattern + ")$")
+			if err != nil {
+				invalid(cond, err)
+				continue
+			}
+			add(cond, exp.MatchString(v), "%q", v)
+		}
+	}
+
+	if len(a.Hostname) > 0 {
+		cond := fmt.Sprintf("hostname %v", a.Hostname)
+		host, err := env.Hostname()
+		if err != nil {
+			add(cond, false, "%s", err)
+		} else {
+			met := false
+			var err error
+			for _, p := range a.Hostname {
+				var ok bool
+				if ok, err = path.Match(p, host); err != nil {
+					break
+				}
+				met = met || ok
+			}
+			if err != nil {
+				invalid(cond, err)
+			} else {
+				add(cond, met, "%q", host)
+			}
+		}
+	}
+
+	if len(a.OS) > 0 {
+		add(fmt.Sprintf("os %v", a.OS), slices.Contains(a.OS, goruntime.GOOS), "%s", goruntime.GOOS)
+	}
+	if len(a.Arch) > 0 {
+		add(fmt.Sprintf("arch %v", a.Arch), slices.Contains(a.Arch, goruntime.GOARCH), "%s", goruntime.GOARCH)
+	}
+
+	if len(a.Files) > 0 {
+		fs := env.FileSystem()
+		for _, f := range a.Files {
+			if fs == nil {
+				add("file "+f, false, "no filesystem")
+				continue
+			}
+			ok, err := vfs.Exists(fs, f)
+			if err != nil {
+				add("file "+f, false, "%s", err)
+			} else if ok {
+				add("file "+f, true, "exists")
+			} else {
+				add("file "+f, false, "not found")
+			}
+		}
+	}
+
+	if a.Version != "" {
+		version := env.Version()
+		cond := "version " + a.Version
+		c, err := semver.NewConstraint(a.Version)
+		switch {
+		case err != nil:
+			invalid(cond, err)
+		case version == "":
+			add(cond, false, "no application version")
+		default:
+			v, err := semver.NewVersion(version)
+			if err != nil {
+				invalid(cond, errors.Wrapf(err, "application version %q", version))
+			} else {
+				add(cond, c.Check(v), "%s", version)
+			}
+		}
+	}
+
+	if len(a.Profiles) > 0 {
+		profiles := env.Profiles()
+		met := slices.ContainsFunc(a.Profiles, func(p string) bool { return slices.Contains(profiles, p) })
+		selected := "none"
+		if len(profiles) > 0 {
+			selected = strings.Join(profiles, ", ")
+		}
+		add(fmt.Sprintf("profiles %v", a.Profiles), met, "selected: %s", selected)
+	}
+
+	err := list.Result()
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result, err
+}
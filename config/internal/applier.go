@@ -48,7 +48,7 @@ func (r *_ConfigApplierRegistry) AddKnown(o ConfigApplierRegistry) {
 	defer r.lock.Unlock()
 
 	for _, n := range o.Names() {
-		r.Register(n, o.Get(n))
+		r.appliers[n] = o.Get(n)
 	}
 }
 
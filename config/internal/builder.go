@@ -7,11 +7,22 @@ import (
 	"github.com/mandelsoft/ctxmgmt/attributes"
 )
 
+// Builder elements, which can be created with a dedicated
+// builder mode (see Builder.WithElementMode).
+const (
+	ELEMENT_ATTRIBUTES  = "attributes"
+	ELEMENT_CONFIGTYPES = "configtypes"
+	ELEMENT_APPLIERS    = "appliers"
+)
+
+var elements = []string{ELEMENT_ATTRIBUTES, ELEMENT_CONFIGTYPES, ELEMENT_APPLIERS}
+
 type Builder struct {
 	ctx        context.Context
 	shared     attributes.AttributesContext
 	reposcheme ConfigTypeScheme
 	appliers   ConfigApplierRegistry
+	modes      ctxmgmt.ElementModes
 }
 
 func (b *Builder) getContext() context.Context {
@@ -41,24 +52,64 @@ func (b Builder) WithConfigAppliers(r ConfigApplierRegistry) Builder {
 	return b
 }
 
+// WithElementMode sets a builder mode for a dedicated builder element
+// overriding the general mode given for the context creation.
+func (b Builder) WithElementMode(element string, mode ctxmgmt.BuilderMode) Builder {
+	b.modes = b.modes.With(element, mode)
+	return b
+}
+
+// Validate validates the builder settings for the given
+// general builder mode.
+func (b Builder) Validate(m ...ctxmgmt.BuilderMode) error {
+	var explicit []string
+	if b.shared != nil {
+		explicit = append(explicit, ELEMENT_ATTRIBUTES)
+	}
+	if b.reposcheme != nil {
+		explicit = append(explicit, ELEMENT_CONFIGTYPES)
+	}
+	if b.appliers != nil {
+		explicit = append(explicit, ELEMENT_APPLIERS)
+	}
+	return b.modes.Validate(ctxmgmt.Mode(m...), elements, nil, explicit...)
+}
+
 func (b Builder) Bound() (Context, context.Context) {
 	c := b.New()
 	return c, context.WithValue(b.getContext(), key, c)
 }
 
+// New creates a new config context.
+// It panics for invalid builder settings, use Build
+// to handle such errors.
 func (b Builder) New(m ...ctxmgmt.BuilderMode) Context {
+	c, err := b.Build(m...)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Build creates a new config context.
+func (b Builder) Build(m ...ctxmgmt.BuilderMode) (Context, error) {
+	err := b.Validate(m...)
+	if err != nil {
+		return nil, err
+	}
+
 	mode := ctxmgmt.Mode(m...)
 	ctx := b.getContext()
 
 	if b.shared == nil {
-		if mode == ctxmgmt.MODE_SHARED {
+		if b.modes.Get(ELEMENT_ATTRIBUTES, mode) == ctxmgmt.MODE_SHARED {
 			b.shared = attributes.ForContext(ctx)
 		} else {
 			b.shared = attributes.New(nil)
 		}
 	}
 	if b.reposcheme == nil {
-		switch mode {
+		switch b.modes.Get(ELEMENT_CONFIGTYPES, mode) {
 		case ctxmgmt.MODE_INITIAL:
 			b.reposcheme = NewConfigTypeScheme(nil)
 		case ctxmgmt.MODE_CONFIGURED:
@@ -74,7 +125,7 @@ func (b Builder) New(m ...ctxmgmt.BuilderMode) Context {
 	}
 
 	if b.appliers == nil {
-		switch mode {
+		switch b.modes.Get(ELEMENT_APPLIERS, mode) {
 		case ctxmgmt.MODE_INITIAL:
 			b.appliers = NewConfigApplierRegistry()
 		case ctxmgmt.MODE_CONFIGURED:
@@ -89,5 +140,5 @@ func (b Builder) New(m ...ctxmgmt.BuilderMode) Context {
 		}
 	}
 
-	return ctxmgmt.SetupContext(mode, newContext(b.shared, b.reposcheme, b.appliers, b.shared))
+	return ctxmgmt.SetupContext(mode, newContext(b.shared, b.reposcheme, b.appliers, b.shared)), nil
 }
@@ -35,4 +35,46 @@ var _ = Describe("builder test", func() {
 		Expect(ctx.ConfigTypes()).NotTo(BeIdenticalTo(local.DefaultConfigTypeScheme))
 		Expect(len(ctx.ConfigTypes().KnownTypeNames())).To(Equal(0))
 	})
+
+	Context("element modes", func() {
+		It("overrides the mode for a dedicated element", func() {
+			ctx := local.Builder{}.WithElementMode(local.ELEMENT_CONFIGTYPES, ctxmgmt.MODE_SHARED).New(ctxmgmt.MODE_INITIAL)
+
+			Expect(ctx.AttributesContext()).NotTo(BeIdenticalTo(attributes.DefaultContext))
+			Expect(ctx.ConfigTypes()).To(BeIdenticalTo(local.DefaultConfigTypeScheme))
+			Expect(ctx.ConfigAppliers()).NotTo(BeIdenticalTo(local.DefaultConfigApplierRegistry))
+		})
+
+		It("shares attributes only", func() {
+			ctx := local.Builder{}.WithElementMode(local.ELEMENT_ATTRIBUTES, ctxmgmt.MODE_SHARED).New(ctxmgmt.MODE_CONFIGURED)
+
+			Expect(ctx.AttributesContext()).To(BeIdenticalTo(attributes.DefaultContext))
+			Expect(ctx.ConfigTypes()).NotTo(BeIdenticalTo(local.DefaultConfigTypeScheme))
+			Expect(ctx.ConfigTypes().KnownTypeNames()).To(Equal(local.DefaultConfigTypeScheme.KnownTypeNames()))
+		})
+
+		It("rejects unknown elements", func() {
+			_, err := local.Builder{}.WithElementMode("other", ctxmgmt.MODE_SHARED).Build()
+			Expect(err).To(MatchError(ContainSubstring(`builder element "other" is unknown`)))
+		})
+
+		It("rejects invalid modes", func() {
+			_, err := local.Builder{}.WithElementMode(local.ELEMENT_APPLIERS, ctxmgmt.BuilderMode(17)).Build()
+			Expect(err).To(MatchError(ContainSubstring(`invalid context creation mode (invalid 17) for element "appliers"`)))
+		})
+
+		It("rejects modes for explicitly set elements", func() {
+			_, err := local.Builder{}.
+				WithConfigTypeScheme(local.NewConfigTypeScheme(nil)).
+				WithElementMode(local.ELEMENT_CONFIGTYPES, ctxmgmt.MODE_SHARED).
+				Build()
+			Expect(err).To(MatchError(ContainSubstring(`mode shared given for explicitly set element "configtypes"`)))
+			Expect(func() {
+				local.Builder{}.
+					WithConfigTypeScheme(local.NewConfigTypeScheme(nil)).
+					WithElementMode(local.ELEMENT_CONFIGTYPES, ctxmgmt.MODE_SHARED).
+					New()
+			}).To(Panic())
+		})
+	})
 })
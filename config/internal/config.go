@@ -23,7 +23,10 @@ func (f ConfigApplierFunction) ApplyConfigTo(ctx Context, cfg, tgt interface{})
 }
 
 type ConfigSet struct {
-	Description       string `json:"description,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Activation describes conditions for an automatic activation
+	// of the set, when the generic config defining it is applied.
+	Activation        *Activation `json:"activation,omitempty"`
 	ConfigurationList `json:",inline"`
 }
 
@@ -2,11 +2,14 @@ package internal
 
 import (
 	"context"
+	"fmt"
 	"reflect"
 
 	"github.com/mandelsoft/ctxmgmt/attributes"
 	"github.com/mandelsoft/goutils/errors"
 	"github.com/mandelsoft/goutils/general"
+	"github.com/mandelsoft/goutils/maputils"
+	"github.com/mandelsoft/goutils/optionutils"
 
 	"github.com/mandelsoft/ctxmgmt"
 	"github.com/mandelsoft/ctxmgmt/utils/runtime"
@@ -21,6 +24,22 @@ type ConfigSelector interface {
 }
 type ConfigSelectorFunction func(Config) bool
 
+// ConfigHandle identifies a config object applied to a config context.
+// It can be used to retract the config object (see Context.Retract).
+type ConfigHandle int64
+
+// Generation provides the generation of the applied config object.
+func (h ConfigHandle) Generation() int64 {
+	return int64(h)
+}
+
+// ConfigResetter is an optional interface for configuration targets.
+// It is used to reset the state established by applied config objects
+// before the target is re-evaluated after a retraction.
+type ConfigResetter interface {
+	ResetConfig() error
+}
+
 func (f ConfigSelectorFunction) Select(cfg Config) bool { return f(cfg) }
 
 var AllConfigs = AppliedConfigSelectorFunction(func(*AppliedConfig) bool { return true })
@@ -29,6 +48,19 @@ const AllGenerations int64 = 0
 
 const CONTEXT_TYPE = "config" + ctxmgmt.CONTEXT_SUFFIX
 
+func init() {
+	ctxmgmt.RegisterContextType(ctxmgmt.NewContextType(CONTEXT_TYPE,
+		"context managing configuration objects applied to contexts",
+		func(mode ...ctxmgmt.BuilderMode) ctxmgmt.Context {
+			return Builder{}.New(mode...)
+		},
+		func(ctx context.Context) ctxmgmt.Context {
+			return FromContext(ctx)
+		},
+		attributes.CONTEXT_TYPE,
+	))
+}
+
 type ContextProvider interface {
 	ConfigContext() Context
 }
@@ -69,8 +101,39 @@ type Context interface {
 	// If the config type is not known, a generic config is stored and returned.
 	// In this case an unknown error for kind KIND_CONFIGTYPE is returned.
 	ApplyData(data []byte, unmarshaler runtime.Unmarshaler, desc string) (Config, error)
-	// ApplyConfig applies the config to the config store
-	ApplyConfig(spec Config, desc string) error
+	// ApplyConfig applies the config to the config store.
+	// Options may be used to describe the source of the config
+	// object (see Provenance).
+	ApplyConfig(spec Config, desc string, opts ...ApplyOption) error
+	// ApplyConfigWithHandle applies the config to the config store
+	// and provides a handle usable to retract the config, again.
+	// If the config could be stored, the handle is returned even
+	// if an error occurs.
+	ApplyConfigWithHandle(spec Config, desc string, opts ...ApplyOption) (ConfigHandle, error)
+	// Retract removes the selected config objects from the config
+	// store together with the config objects applied by them (for
+	// example by a generic config object). Afterward, configuration
+	// targets are re-evaluated from scratch. Targets implementing
+	// ConfigResetter are reset before all remaining config objects
+	// are applied again. Config sets defined by a retracted config
+	// object are kept.
+	// It returns the removed config objects.
+	Retract(selector AppliedConfigSelector) AppliedConfigs
+
+	// Provenance describes where the selected applied config
+	// objects come from. It can be used to explain where
+	// some configuration setting originates from.
+	Provenance(selector AppliedConfigSelector) Provenances
+	// Origins describes the config object applied to the config
+	// context via this context view, followed by the config objects
+	// (transitively) applying it. Config objects get such a view
+	// as target when applied to the config context. For other
+	// views it is empty.
+	Origins() Provenances
+
+	// GetAppliedConfigs provides the selected applied config objects
+	// together with the actual generation.
+	GetAppliedConfigs(selector AppliedConfigSelector) (int64, AppliedConfigs)
 
 	GetConfigForType(generation int64, typ string) (int64, []Config)
 	GetConfigForName(generation int64, name string) (int64, []Config)
@@ -78,6 +141,19 @@ type Context interface {
 
 	AddConfigSet(name string, set *ConfigSet)
 	ApplyConfigSet(name string) error
+	// GetConfigSet provides the config set with the given name,
+	// or nil if not defined.
+	GetConfigSet(name string) *ConfigSet
+	// ConfigSetNames provides the names of the defined config sets.
+	ConfigSetNames() []string
+	// ActivateConfigSet evaluates the activation conditions of a config
+	// set and applies the set, if they are met. The result of the
+	// evaluation is recorded (see ConfigSetActivations). Sets without
+	// activation conditions are never activated.
+	ActivateConfigSet(name string) (bool, error)
+	// ConfigSetActivations provides the recorded results of the
+	// evaluations of config set activation conditions.
+	ConfigSetActivations() ActivationResults
 
 	// Reset all configs applied so far, subsequent calls to ApplyTo will
 	// only see configs applied after the last reset.
@@ -95,6 +171,10 @@ type Context interface {
 	ApplyTo(gen int64, target interface{}) (int64, error)
 
 	ApplyAllTo(target interface{}) error
+
+	// Parent provides the parent context of an overlay
+	// context (see NewOverlay) or nil for a regular context.
+	Parent() Context
 }
 
 var key = reflect.TypeOf(_context{})
@@ -140,11 +220,16 @@ type coreContext struct {
 
 	configs           *ConfigStore
 	skipUnknownConfig bool
+
+	// parent is the parent context of an overlay context.
+	parent Context
 }
 
 type _context struct {
 	*coreContext
 	description string
+	// origin is the config object applied via this view.
+	origin *applying
 }
 
 var (
@@ -201,15 +286,46 @@ func (c *_context) Update() error {
 
 var _ ctxmgmt.Updater = (*_context)(nil)
 
+// InUpdate implements ctxmgmt.UpdateState.
+func (c *_context) InUpdate() bool {
+	_, inupdate := c.updater.State()
+	return inupdate
+}
+
+// ResetConfig prepares the re-evaluation of the config context
+// after a retraction. Config objects applied by other config objects
+// are removed, because they are applied again by the re-evaluation,
+// and the attributes set by configuration are reset.
+func (c *_context) ResetConfig() error {
+	c.configs.dropApplied()
+	ctxmgmt.ResetConfiguredAttributes(c.GetAttributes())
+	return nil
+}
+
 func (c *_context) Info() string {
 	return c.description
 }
 
+// infoSeparator separates the nested descriptions
+// of a context view (see Context.WithInfo).
+const infoSeparator = "--"
+
 func (c *_context) WithInfo(desc string) Context {
 	if c.description != "" {
-		desc = desc + "--" + c.description
+		desc = desc + infoSeparator + c.description
 	}
-	return newView(&_context{c.coreContext, desc})
+	return newView(&_context{c.coreContext, desc, c.origin})
+}
+
+// applyingView provides a view used to apply a config object to
+// the config context. Config objects applied via this view are
+// recorded as applied by this config object.
+func (c *_context) applyingView(cfg *AppliedConfig) Context {
+	desc := cfg.description
+	if c.description != "" {
+		desc = desc + infoSeparator + c.description
+	}
+	return newView(&_context{c.coreContext, desc, &applying{cfg, desc}})
 }
 
 func (c *_context) AttributesContext() attributes.AttributesContext {
@@ -243,14 +359,26 @@ func (c *_context) GetConfigForData(data []byte, unmarshaler runtime.Unmarshaler
 	return spec, nil
 }
 
-func (c *_context) ApplyConfig(spec Config, desc string) error {
+func (c *_context) ApplyConfig(spec Config, desc string, opts ...ApplyOption) error {
+	_, err := c.ApplyConfigWithHandle(spec, desc, opts...)
+	return err
+}
+
+func (c *_context) ApplyConfigWithHandle(spec Config, desc string, opts ...ApplyOption) (ConfigHandle, error) {
+	eff := optionutils.EvalOptions(opts...)
+	return c.apply(spec, desc, func(spec Config) int64 {
+		return c.configs.apply(spec, desc, eff, c.origin)
+	})
+}
+
+func (c *_context) apply(spec Config, desc string, store func(Config) int64) (ConfigHandle, error) {
 	var unknown error
 
 	// use temporary view for outbound calls
 	spec, err := (&AppliedConfig{config: spec}).eval(newView(c))
 	if err != nil {
 		if !errors.IsErrUnknownKind(err, KIND_CONFIGTYPE) {
-			return errors.Wrapf(err, "%s", desc)
+			return 0, errors.Wrapf(err, "%s", desc)
 		}
 		if !c.skipUnknownConfig {
 			unknown = err
@@ -258,7 +386,7 @@ func (c *_context) ApplyConfig(spec Config, desc string) error {
 		err = nil
 	}
 
-	c.configs.Apply(spec, desc)
+	h := ConfigHandle(store(spec))
 
 	for {
 		// apply directly and also indirectly described configurations
@@ -271,7 +399,18 @@ func (c *_context) ApplyConfig(spec Config, desc string) error {
 		}
 	}
 
-	return errors.Wrapf(err, "%s", desc)
+	return h, errors.Wrapf(err, "%s", desc)
+}
+
+func (c *_context) Retract(selector AppliedConfigSelector) AppliedConfigs {
+	if selector == nil {
+		return nil
+	}
+	removed := c.configs.Retract(selector)
+	if len(removed) > 0 {
+		c.Update()
+	}
+	return removed
 }
 
 func (c *_context) ApplyData(data []byte, unmarshaler runtime.Unmarshaler, desc string) (Config, error) {
@@ -310,11 +449,35 @@ func (c *_context) ApplyTo(gen int64, target interface{}) (int64, error) {
 	if cur <= gen {
 		return gen, nil
 	}
+	if gen > 0 && gen < c.configs.Retraction() {
+		// config objects have been retracted after the last update,
+		// the target must be re-evaluated from scratch.
+		if r, ok := target.(ConfigResetter); ok {
+			if err := r.ResetConfig(); err != nil {
+				return gen, errors.Wrapf(err, "cannot reset configuration target")
+			}
+		}
+		gen = 0
+	}
 	cur, cfgs := c.configs.GetConfigForSelector(c, AppliedGenerationSelector(gen))
 
+	self := c.isSelf(target)
 	list := errors.ErrListf("config apply errors")
 	for _, cfg := range cfgs {
-		err := cfg.config.ApplyTo(c.WithInfo(cfg.description), target)
+		if cfg.IsInherited() && self {
+			// the effect of inherited config objects on the config
+			// context is already visible via the parent context.
+			continue
+		}
+		var err error
+		if self {
+			// keep track of config objects applied by other ones
+			// by using a dedicated view as target.
+			view := c.applyingView(cfg)
+			err = cfg.config.ApplyTo(view, view)
+		} else {
+			err = cfg.config.ApplyTo(c.WithInfo(cfg.description), target)
+		}
 		if c.skipUnknownConfig && errors.IsErrUnknownKind(err, KIND_CONFIGTYPE) {
 			err = nil
 		}
@@ -338,7 +501,15 @@ func (c *_context) Validate() error {
 }
 
 func (c *_context) AddConfigSet(name string, set *ConfigSet) {
-	c.configs.AddSet(name, set)
+	c.configs.addSet(name, set, c.origin)
+}
+
+func (c *_context) GetConfigSet(name string) *ConfigSet {
+	return c.configs.GetSet(name)
+}
+
+func (c *_context) ConfigSetNames() []string {
+	return c.configs.SetNames()
 }
 
 func (c *_context) ApplyConfigSet(name string) error {
@@ -347,13 +518,61 @@ func (c *_context) ApplyConfigSet(name string) error {
 		return errors.ErrUnknown(KIND_CONFIGSET, name)
 	}
 	desc := "config set " + name
+	def := c.configs.getSetOrigin(name)
 	list := errors.ErrListf("applying %s", desc)
-	for _, cfg := range set.Configurations {
-		list.Add(c.ApplyConfig(cfg, desc))
+	for i, cfg := range set.Configurations {
+		o := def
+		o.element = JoinElementPath(def.element, fmt.Sprintf("configurations[%d]", i))
+		o.configSet = name
+		_, err := c.apply(cfg, desc, func(spec Config) int64 {
+			return c.configs.applyWithOrigin(spec, desc, o, c.origin)
+		})
+		list.Add(err)
 	}
 	return list.Result()
 }
 
+func (c *_context) ActivateConfigSet(name string) (bool, error) {
+	set := c.configs.GetSet(name)
+	if set == nil {
+		return false, errors.ErrUnknown(KIND_CONFIGSET, name)
+	}
+	if set.Activation == nil {
+		return false, nil
+	}
+	r, err := set.Activation.Evaluate(GetActivationEnvironment(c))
+	r.ConfigSet = name
+	r.Generation = c.configs.Generation()
+	c.configs.setActivation(r)
+	if err != nil || !r.Active {
+		return false, errors.Wrapf(err, "config set %q", name)
+	}
+	Logger.Debug("activating config set", "name", name, "id", c.GetId())
+	return true, c.ApplyConfigSet(name)
+}
+
+func (c *_context) ConfigSetActivations() ActivationResults {
+	activations := c.configs.getActivations()
+	result := ActivationResults{}
+	for _, n := range maputils.OrderedKeys(activations) {
+		result = append(result, activations[n])
+	}
+	return result
+}
+
+func (c *_context) Origins() Provenances {
+	return c.configs.origins(c.origin).Provenances()
+}
+
+func (c *_context) GetAppliedConfigs(selector AppliedConfigSelector) (int64, AppliedConfigs) {
+	return c.configs.GetConfigForSelector(c, selector)
+}
+
+func (c *_context) Provenance(selector AppliedConfigSelector) Provenances {
+	_, cfgs := c.configs.GetConfigForSelector(c, selector)
+	return cfgs.Provenances()
+}
+
 func (c *_context) GetConfig(gen int64, selector ConfigSelector) (int64, []Config) {
 	gen, cfgs := c.configs.GetConfigForSelector(c, c.selector(gen, selector))
 	return gen, cfgs.Configs()
@@ -0,0 +1,56 @@
+package internal
+
+import (
+	"github.com/mandelsoft/ctxmgmt"
+)
+
+// Description describes the config specific state
+// of a configuration context.
+type Description struct {
+	Parent            ctxmgmt.ContextIdentity    `json:"parent,omitempty"`
+	Generation        int64                      `json:"generation"`
+	SkipUnknownConfig bool                       `json:"skipUnknownConfig,omitempty"`
+	AppliedConfigs    []AppliedConfigDescription `json:"appliedConfigs,omitempty"`
+	ConfigSets        []string                   `json:"configSets,omitempty"`
+	ConfigTypes       []string                   `json:"configTypes,omitempty"`
+	ConfigAppliers    []string                   `json:"configAppliers,omitempty"`
+}
+
+// AppliedConfigDescription describes a config object
+// applied to a configuration context.
+type AppliedConfigDescription struct {
+	Generation  int64  `json:"generation"`
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	Inherited   bool   `json:"inherited,omitempty"`
+}
+
+var _ ctxmgmt.DescriptionProvider = (*_context)(nil)
+
+func (c *_context) DescribeSection() (string, interface{}) {
+	return "config", c.Describe()
+}
+
+// Describe provides a description of the config specific state.
+func (c *_context) Describe() *Description {
+	gen, cfgs := c.configs.GetConfigForSelector(c, AllAppliedConfigs)
+	d := &Description{
+		Generation:        gen,
+		SkipUnknownConfig: c.skipUnknownConfig,
+		ConfigSets:        c.configs.SetNames(),
+		ConfigTypes:       c.knownConfigTypes.KnownTypeNames(),
+		ConfigAppliers:    c.appliers.Names(),
+	}
+	if c.parent != nil {
+		d.Parent = c.parent.GetId()
+	}
+	for _, cfg := range cfgs {
+		d.AppliedConfigs = append(d.AppliedConfigs, AppliedConfigDescription{
+			Generation:  cfg.generation,
+			Type:        cfg.config.GetType(),
+			Description: cfg.description,
+			Inherited:   cfg.IsInherited(),
+		})
+	}
+	return d
+}
@@ -0,0 +1,45 @@
+package internal
+
+import (
+	"github.com/mandelsoft/ctxmgmt"
+)
+
+// NewOverlay creates a copy-on-write overlay context for a parent
+// config context. It shares the type registries and the attributes
+// context with its parent. Config objects and config sets of the parent
+// are visible in the overlay, including those applied to the parent
+// after the overlay has been created. Config objects, config sets and
+// attributes set for the overlay are kept local and are never visible
+// in the parent context. The parent does not refer to the overlay,
+// so it can be discarded without affecting the parent.
+func NewOverlay(parent Context) Context {
+	pc := ctxmgmt.PersistentContextRef(parent)
+	p := ctxmgmt.InternalContextRef(pc).(*_context)
+
+	c := &_context{
+		coreContext: &coreContext{
+			sharedAttributes:  p.sharedAttributes,
+			knownConfigTypes:  p.knownConfigTypes,
+			appliers:          p.appliers,
+			configs:           NewOverlayConfigStore(pc, p.configs),
+			skipUnknownConfig: p.skipUnknownConfig,
+			parent:            pc,
+		},
+	}
+	c._InternalContext = ctxmgmt.NewContextBase(c, CONTEXT_TYPE, key, p.GetAttributes(), p.sharedAttributes)
+	c.updater = NewUpdaterForFactory(c, c.ConfigContext)
+	return newView(c, true)
+}
+
+// Parent provides the parent context of an overlay context
+// or nil for a regular context.
+func (c *_context) Parent() Context {
+	return c.parent
+}
+
+func (c *_context) isSelf(target interface{}) bool {
+	if t, ok := target.(Context); ok {
+		return t.GetId() == c.GetId()
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/mandelsoft/goutils/errors"
+	"github.com/mandelsoft/goutils/maputils"
+	"github.com/mandelsoft/spiff/features"
+	"github.com/mandelsoft/spiff/spiffing"
+	"sigs.k8s.io/yaml"
+)
+
+// ProcessConfigData preprocesses config data with
+// [github.com/mandelsoft/spiff/spiffing.Spiff] and provides the
+// processed data together with the description of the source document.
+func ProcessConfigData(data []byte, info string) ([]byte, *ConfigSource, error) {
+	sctx := spiffing.New().WithFeatures(features.INTERPOLATION, features.CONTROL)
+	processed, err := spiffing.Process(sctx, spiffing.NewSourceData(info, data))
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "processing ocm config %q", info)
+	}
+	return processed, configSource(info, data, processed), nil
+}
+
+// configSource provides the source description for config data.
+// Positions refer to the original data, if it is not modified by
+// spiff processing. Otherwise, they refer to the processed data.
+func configSource(info string, data, processed []byte) *ConfigSource {
+	var orig, eff interface{}
+	if yaml.Unmarshal(data, &orig) == nil && yaml.Unmarshal(processed, &eff) == nil && reflect.DeepEqual(orig, eff) {
+		return NewConfigSourceForData(info, data)
+	}
+	return NewConfigSourceForData(info, processed)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// ConfigDataProcessor processes config data read from a source
+// document after the spiff processing, before the config object
+// is decoded (see DecodeConfigData). The context provider is
+// the one passed to DecodeConfigData, which might be a context
+// based on the config context.
+type ConfigDataProcessor interface {
+	ProcessConfigData(ctx ContextProvider, data []byte, info string) ([]byte, error)
+}
+
+type ConfigDataProcessorFunction func(ctx ContextProvider, data []byte, info string) ([]byte, error)
+
+func (f ConfigDataProcessorFunction) ProcessConfigData(ctx ContextProvider, data []byte, info string) ([]byte, error) {
+	return f(ctx, data, info)
+}
+
+type configDataProcessorRegistry struct {
+	lock       sync.Mutex
+	processors map[string]ConfigDataProcessor
+}
+
+var configDataProcessors = &configDataProcessorRegistry{processors: map[string]ConfigDataProcessor{}}
+
+// RegisterConfigDataProcessor registers a config data processor
+// under a name. Processors are applied in the order of their names.
+func RegisterConfigDataProcessor(name string, p ConfigDataProcessor) {
+	configDataProcessors.lock.Lock()
+	defer configDataProcessors.lock.Unlock()
+	configDataProcessors.processors[name] = p
+}
+
+func (r *configDataProcessorRegistry) get() []ConfigDataProcessor {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var list []ConfigDataProcessor
+	for _, n := range maputils.OrderedKeys(r.processors) {
+		list = append(list, r.processors[n])
+	}
+	return list
+}
+
+// DecodeConfigData preprocesses config data read from a source
+// document like ProcessConfigData, applies the registered
+// ConfigDataProcessors and decodes the resulting config object.
+// Additionally, it provides the description of the source document.
+func DecodeConfigData(ctx ContextProvider, data []byte, info string) (Config, *ConfigSource, error) {
+	processed, src, err := ProcessConfigData(data, info)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, p := range configDataProcessors.get() {
+		processed, err = p.ProcessConfigData(ctx, processed, info)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "processing ocm config %q", info)
+		}
+	}
+	cfg, err := ctx.ConfigContext().GetConfigForData(processed, nil)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "invalid ocm config file %q", info)
+	}
+	return cfg, src, nil
+}
@@ -0,0 +1,304 @@
+package internal
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mandelsoft/goutils/optionutils"
+	"gopkg.in/yaml.v3"
+
+	"github.com/mandelsoft/ctxmgmt/utils"
+)
+
+// Position describes a location in a source document.
+type Position struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// ConfigSource describes the source document config objects
+// have been read from. Positions maps element paths to their
+// location in the document. The document itself has the
+// element path "". Map entries use the path of the enclosing
+// element followed by "." and the key, list entries use the
+// path of the list followed by "[<index>]", for example
+// "configurations[1].sets.test".
+type ConfigSource struct {
+	Name      string
+	Positions map[string]Position
+}
+
+// NewConfigSource creates a source description for a source name
+// without position information.
+func NewConfigSource(name string) *ConfigSource {
+	return &ConfigSource{Name: name}
+}
+
+// NewConfigSourceForData creates a source description for
+// YAML or JSON data, including the positions of its elements.
+// If the data cannot be parsed, no positions are provided.
+func NewConfigSourceForData(name string, data []byte) *ConfigSource {
+	s := NewConfigSource(name)
+	var doc yaml.Node
+	if yaml.Unmarshal(data, &doc) != nil {
+		return s
+	}
+	s.Positions = map[string]Position{}
+	s.addPositions("", &doc)
+	return s
+}
+
+func (s *ConfigSource) addPositions(path string, n *yaml.Node) {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) > 0 {
+			s.addPositions(path, n.Content[0])
+		}
+		return
+	}
+	s.Positions[path] = Position{n.Line, n.Column}
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			s.addPositions(JoinElementPath(path, n.Content[i].Value), n.Content[i+1])
+		}
+	case yaml.SequenceNode:
+		for i, e := range n.Content {
+			s.addPositions(fmt.Sprintf("%s[%d]", path, i), e)
+		}
+	}
+}
+
+// Position provides the position of an element
+// in the source document.
+func (s *ConfigSource) Position(element string) (Position, bool) {
+	if s == nil || s.Positions == nil {
+		return Position{}, false
+	}
+	p, ok := s.Positions[element]
+	return p, ok
+}
+
+// JoinElementPath joins element paths.
+func JoinElementPath(path string, elems ...string) string {
+	for _, e := range elems {
+		switch {
+		case e == "":
+		case path == "" || strings.HasPrefix(e, "["):
+			path += e
+		default:
+			path += "." + e
+		}
+	}
+	return path
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// ApplyOptions describe where a config object applied
+// to a config context originates from.
+type ApplyOptions struct {
+	// Source is the source document the config object has been read from.
+	// If given, Element is the absolute element path of the config
+	// object in the source. Otherwise, the source is inherited
+	// from the config object applying the config object and Element
+	// is relative to the element path of this config object.
+	Source *ConfigSource
+	// Element is the element path of the config object.
+	Element string
+	// ConfigSet is the name of the config set providing the config object.
+	ConfigSet string
+}
+
+type ApplyOption = optionutils.Option[*ApplyOptions]
+
+var _ ApplyOption = (*ApplyOptions)(nil)
+
+func (o *ApplyOptions) ApplyTo(opts *ApplyOptions) {
+	if o.Source != nil {
+		opts.Source = o.Source
+	}
+	if o.Element != "" {
+		opts.Element = o.Element
+	}
+	if o.ConfigSet != "" {
+		opts.ConfigSet = o.ConfigSet
+	}
+}
+
+// WithSource sets the source document of an applied config object.
+func WithSource(src *ConfigSource) ApplyOption {
+	return &ApplyOptions{Source: src}
+}
+
+// WithElement sets the element path of an applied config object.
+func WithElement(path string) ApplyOption {
+	return &ApplyOptions{Element: path}
+}
+
+// WithConfigSet sets the config set providing an applied config object.
+func WithConfigSet(name string) ApplyOption {
+	return &ApplyOptions{ConfigSet: name}
+}
+
+// origin describes the source of an applied config object
+// or a config set definition.
+type origin struct {
+	source    *ConfigSource
+	element   string
+	configSet string
+	// path is the nesting path of the descriptions of the
+	// config objects applying this config object.
+	path []string
+}
+
+// originFor determines the origin of a config object applied
+// by the config object described by base.
+func originFor(base *origin, opts *ApplyOptions) origin {
+	var o origin
+	if base != nil {
+		o = *base
+	}
+	if opts.Source != nil {
+		o.source = opts.Source
+		o.element = opts.Element
+	} else {
+		o.element = JoinElementPath(o.element, opts.Element)
+	}
+	if opts.ConfigSet != "" {
+		o.configSet = opts.ConfigSet
+	}
+	return o
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Provenance describes where an applied config object comes from.
+type Provenance struct {
+	// Generation is the generation the config object has been applied with.
+	Generation int64 `json:"generation"`
+	// Type is the config type.
+	Type string `json:"type"`
+	// Description is the description given for applying the config object.
+	Description string `json:"description,omitempty"`
+	// Path is the nesting path of the description (see Context.WithInfo),
+	// starting with the outermost description.
+	Path []string `json:"path,omitempty"`
+	// Source is the name of the source document, typically a file path.
+	Source string `json:"source,omitempty"`
+	// Element is the element path of the config object in the source document.
+	Element string `json:"element,omitempty"`
+	// Line and Column describe the position of the config object in
+	// the source document as far as known. If the source document
+	// has been preprocessed (for example by spiff), the position refers
+	// to the processed document if it differs from the original one.
+	Line   int `json:"line,omitempty"`
+	Column int `json:"column,omitempty"`
+	// ConfigSet is the name of the config set providing the config object.
+	ConfigSet string `json:"configSet,omitempty"`
+	// Origin is the generation of the config object whose
+	// application applied this config object.
+	Origin int64 `json:"origin,omitempty"`
+	// Inherited is set for config objects inherited from
+	// a parent context.
+	Inherited bool `json:"inherited,omitempty"`
+}
+
+// Location provides a human-readable location of
+// the config object in its source document.
+func (p *Provenance) Location() string {
+	if p.Source == "" {
+		return p.Element
+	}
+	loc := p.Source
+	if p.Line > 0 {
+		loc = fmt.Sprintf("%s:%d:%d", loc, p.Line, p.Column)
+	}
+	if p.Element != "" {
+		loc += " (" + p.Element + ")"
+	}
+	return loc
+}
+
+// Print prints a human-readable explanation of the provenance.
+func (p *Provenance) Print(pr utils.Printer) {
+	pr.Printf("generation %d: %s\n", p.Generation, p.Type)
+	g := pr.AddGap("  ")
+	if loc := p.Location(); loc != "" {
+		g.Printf("source:      %s\n", loc)
+	}
+	if p.ConfigSet != "" {
+		g.Printf("config set:  %s\n", p.ConfigSet)
+	}
+	if len(p.Path) > 0 {
+		g.Printf("applied by:  %s\n", strings.Join(p.Path, " -> "))
+	}
+	if p.Origin > 0 {
+		g.Printf("origin:      generation %d\n", p.Origin)
+	}
+	if p.Inherited {
+		g.Printf("inherited from parent context\n")
+	}
+}
+
+func (p *Provenance) String() string {
+	pr, buf := utils.NewBufferedPrinter()
+	p.Print(pr)
+	return buf.String()
+}
+
+// Provenances is a list of provenance descriptions.
+type Provenances []*Provenance
+
+// Print prints a human-readable explanation of the provenances.
+func (l Provenances) Print(p utils.Printer) {
+	if len(l) == 0 {
+		p.Printf("no config objects\n")
+		return
+	}
+	for _, e := range l {
+		e.Print(p)
+	}
+}
+
+func (l Provenances) String() string {
+	p, buf := utils.NewBufferedPrinter()
+	l.Print(p)
+	return buf.String()
+}
+
+// Provenance provides the provenance of the applied config object.
+func (c *AppliedConfig) Provenance() *Provenance {
+	p := &Provenance{
+		Generation:  c.generation,
+		Type:        c.config.GetType(),
+		Description: c.description,
+		Origin:      c.origin,
+		Inherited:   c.IsInherited(),
+		ConfigSet:   c.source.configSet,
+		Element:     c.source.element,
+	}
+	p.Path = slices.Clone(c.source.path)
+	if c.source.source != nil {
+		p.Source = c.source.source.Name
+		if pos, ok := c.source.source.Position(c.source.element); ok {
+			p.Line = pos.Line
+			p.Column = pos.Column
+		}
+	}
+	return p
+}
+
+// Provenances provides the provenance descriptions of applied config objects.
+func (l AppliedConfigs) Provenances() Provenances {
+	r := make(Provenances, len(l))
+	for i, c := range l {
+		r[i] = c.Provenance()
+	}
+	return r
+}
@@ -1,9 +1,16 @@
 package internal
 
 import (
+	"maps"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
+
+	"github.com/mandelsoft/goutils/maputils"
+	"github.com/mandelsoft/goutils/set"
+
+	"github.com/mandelsoft/ctxmgmt/utils/runtime"
 )
 
 type AppliedConfigSelector interface {
@@ -22,6 +29,55 @@ func AppliedGenerationSelector(gen int64) AppliedConfigSelector {
 	})
 }
 
+// AppliedHandleSelector selects the config objects applied
+// with the given handles.
+func AppliedHandleSelector(handles ...ConfigHandle) AppliedConfigSelector {
+	return AppliedConfigSelectorFunction(func(cfg *AppliedConfig) bool {
+		return slices.Contains(handles, ConfigHandle(cfg.generation))
+	})
+}
+
+// AppliedDescriptionSelector selects the config objects
+// applied with the given description.
+func AppliedDescriptionSelector(desc string) AppliedConfigSelector {
+	return AppliedConfigSelectorFunction(func(cfg *AppliedConfig) bool {
+		return cfg.description == desc
+	})
+}
+
+// AppliedGenerationRangeSelector selects the config objects
+// applied with a generation in the range [from, to].
+func AppliedGenerationRangeSelector(from, to int64) AppliedConfigSelector {
+	return AppliedConfigSelectorFunction(func(cfg *AppliedConfig) bool {
+		return cfg.generation >= from && cfg.generation <= to
+	})
+}
+
+// AppliedTypeSelector selects the config objects with the given
+// config type. A type without version matches all versions.
+func AppliedTypeSelector(typ string) AppliedConfigSelector {
+	return AppliedConfigSelectorFunction(func(cfg *AppliedConfig) bool {
+		t := cfg.config.GetType()
+		return t == typ || (!strings.Contains(typ, runtime.VersionSeparator) && cfg.config.GetKind() == typ)
+	})
+}
+
+// AppliedConfigSetSelector selects the config objects
+// provided by the given config set.
+func AppliedConfigSetSelector(name string) AppliedConfigSelector {
+	return AppliedConfigSelectorFunction(func(cfg *AppliedConfig) bool {
+		return cfg.source.configSet == name
+	})
+}
+
+// AppliedSourceSelector selects the config objects read
+// from the given source document.
+func AppliedSourceSelector(name string) AppliedConfigSelector {
+	return AppliedConfigSelectorFunction(func(cfg *AppliedConfig) bool {
+		return cfg.source.source != nil && cfg.source.source.Name == name
+	})
+}
+
 func AppliedVersionSelector(v string) AppliedConfigSelector {
 	return AppliedConfigSelectorFunction(func(cfg *AppliedConfig) bool {
 		return cfg.config.GetVersion() == v
@@ -66,6 +122,44 @@ type AppliedConfig struct {
 	generation  int64
 	config      Config
 	description string
+	// origin is the generation of the config object,
+	// whose application applied this config object.
+	origin int64
+	// inherited is the generation of a config object taken
+	// over from the store of a parent context.
+	inherited int64
+	// source describes the source document of the config object.
+	source origin
+}
+
+// Generation provides the generation the config object
+// has been applied with.
+func (c *AppliedConfig) Generation() int64 {
+	return c.generation
+}
+
+// Config provides the applied config object.
+func (c *AppliedConfig) Config() Config {
+	return c.config
+}
+
+// Description provides the description given for
+// applying the config object.
+func (c *AppliedConfig) Description() string {
+	return c.description
+}
+
+// Origin provides the generation of the config object, whose
+// application applied this config object, or 0 if it has been
+// applied directly.
+func (c *AppliedConfig) Origin() int64 {
+	return c.origin
+}
+
+// IsInherited returns true for config objects
+// taken over from a parent context.
+func (c *AppliedConfig) IsInherited() bool {
+	return c.inherited > 0
 }
 
 func (c *AppliedConfig) eval(ctx Context) (Config, error) {
@@ -84,39 +178,266 @@ type ConfigStore struct {
 	generation int64
 	types      map[string]AppliedConfigs
 	configs    AppliedConfigs
+	// retraction is the generation of the last retraction.
+	retraction int64
 
 	sets map[string]*ConfigSet
+	// setOrigins describe the source of the config set definitions.
+	setOrigins map[string]origin
+	// activations are the results of the evaluation of
+	// the activation conditions of config sets.
+	activations map[string]*ActivationResult
+
+	// parent is the store of the parent context for an overlay store.
+	parent    *ConfigStore
+	parentCtx Context
+	// inherited is the generation of the parent store already
+	// taken over into this store.
+	inherited int64
 }
 
 func NewConfigStore() *ConfigStore {
 	return &ConfigStore{
-		types: map[string]AppliedConfigs{},
-		sets:  map[string]*ConfigSet{},
+		types:       map[string]AppliedConfigs{},
+		sets:        map[string]*ConfigSet{},
+		setOrigins:  map[string]origin{},
+		activations: map[string]*ActivationResult{},
+	}
+}
+
+// NewOverlayConfigStore creates a store layered on the store of
+// a parent context. Config objects applied to the parent are taken
+// over with a local generation, when the store is accessed. Config
+// objects applied to the overlay store are never propagated to the
+// parent.
+func NewOverlayConfigStore(parentCtx Context, parent *ConfigStore) *ConfigStore {
+	s := NewConfigStore()
+	s.parent = parent
+	s.parentCtx = parentCtx
+	return s
+}
+
+// sync takes over the config objects applied to the parent
+// store since the last call. Config objects retracted from the
+// parent are retracted, also. The caller must hold the write lock.
+func (s *ConfigStore) sync() {
+	if s.parent == nil {
+		return
+	}
+	if s.parent.Retraction() > s.inherited {
+		_, cfgs := s.parent.GetConfigForSelector(s.parentCtx, AllAppliedConfigs)
+		present := map[int64]bool{}
+		for _, c := range cfgs {
+			present[c.generation] = true
+		}
+		s.retract(AppliedConfigSelectorFunction(func(c *AppliedConfig) bool {
+			return c.IsInherited() && !present[c.inherited]
+		}))
 	}
+	gen, cfgs := s.parent.GetConfigForSelector(s.parentCtx, AppliedGenerationSelector(s.inherited))
+	for _, c := range cfgs {
+		s.add(c.config, c.description, c.source, c.generation, nil)
+	}
+	s.inherited = gen
+}
+
+func (s *ConfigStore) add(c Config, desc string, src origin, inherited int64, by *applying) *AppliedConfig {
+	s.generation++
+	a := &AppliedConfig{
+		generation:  s.generation,
+		config:      c,
+		description: desc,
+		inherited:   inherited,
+		source:      src,
+	}
+	if inherited == 0 {
+		if by != nil {
+			a.origin = by.config.generation
+		}
+		a.source.path = by.nestingPath(desc)
+	}
+	configs := s.types[c.GetKind()]
+	s.types[c.GetKind()] = append(configs, a)
+	s.configs = append(s.configs, a)
+	return a
 }
 
 func (s *ConfigStore) Generation() int64 {
-	s.lock.RLock()
-	defer s.lock.RUnlock()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.sync()
 	return s.generation
 }
 
+// Retraction provides the generation of the last retraction.
+func (s *ConfigStore) Retraction() int64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.retraction
+}
+
 func (s *ConfigStore) Reset() int64 {
 	s.lock.Lock()
 	defer s.lock.Unlock()
+	s.sync()
 	s.configs = nil
 	s.types = map[string]AppliedConfigs{}
 	return s.generation
 }
 
-func (s *ConfigStore) Apply(c Config, desc string) {
+// Apply stores a config object and provides its generation.
+// The source of the config object is described by the given options.
+// If no source is given, it is inherited from the applying config
+// object, if the config object is applied by another one.
+func (s *ConfigStore) Apply(c Config, desc string, opts *ApplyOptions) int64 {
+	return s.apply(c, desc, opts, nil)
+}
+
+func (s *ConfigStore) apply(c Config, desc string, opts *ApplyOptions, by *applying) int64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.sync()
+	return s.add(c, desc, originFor(by.source(), opts), 0, by).generation
+}
+
+// applyWithOrigin stores a config object with an explicit origin.
+func (s *ConfigStore) applyWithOrigin(c Config, desc string, src origin, by *applying) int64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.sync()
+	return s.add(c, desc, src, 0, by).generation
+}
+
+// Retract removes the selected config objects together with the
+// config objects applied by them. If config objects are removed,
+// a new generation is started to trigger the re-evaluation of
+// configuration targets.
+// It returns the removed config objects.
+func (s *ConfigStore) Retract(selector AppliedConfigSelector) AppliedConfigs {
 	s.lock.Lock()
 	defer s.lock.Unlock()
+	s.sync()
+	return s.retract(selector)
+}
+
+func (s *ConfigStore) retract(selector AppliedConfigSelector) AppliedConfigs {
+	var removed AppliedConfigs
+	retracted := map[int64]bool{}
+
+	configs := s.configs[:0]
+	for _, c := range s.configs {
+		if selector.Select(c) || retracted[c.origin] {
+			retracted[c.generation] = true
+			removed = append(removed, c)
+		} else {
+			configs = append(configs, c)
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+	s.configs = configs
+	for k, list := range s.types {
+		var n AppliedConfigs
+		for _, c := range list {
+			if !retracted[c.generation] {
+				n = append(n, c)
+			}
+		}
+		s.types[k] = n
+	}
 	s.generation++
-	a := &AppliedConfig{s.generation, c, desc}
-	configs := s.types[c.GetKind()]
-	s.types[c.GetKind()] = append(configs, a)
-	s.configs = append(s.configs, a)
+	s.retraction = s.generation
+	return removed
+}
+
+// dropApplied removes the config objects applied by other
+// config objects. It is used before the config objects are
+// applied again to the config context, which re-creates them.
+func (s *ConfigStore) dropApplied() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.sync()
+
+	configs := s.configs[:0]
+	for _, c := range s.configs {
+		if c.origin == 0 {
+			configs = append(configs, c)
+		}
+	}
+	s.configs = configs
+	for k, list := range s.types {
+		var n AppliedConfigs
+		for _, c := range list {
+			if c.origin == 0 {
+				n = append(n, c)
+			}
+		}
+		s.types[k] = n
+	}
+}
+
+// applying describes the config object applied via a
+// context view (see _context.applyingView) together with
+// the description of the view.
+type applying struct {
+	config *AppliedConfig
+	info   string
+}
+
+// source provides the source of the applying config object.
+func (a *applying) source() *origin {
+	if a == nil {
+		return nil
+	}
+	return &a.config.source
+}
+
+// nestingPath determines the nesting path for a config object
+// applied with the given description. Config objects applied by
+// another config object use a description extended by the description
+// of the context view (see Context.WithInfo), which is replaced by the
+// nesting path of the applying config object.
+func (a *applying) nestingPath(desc string) []string {
+	var path []string
+	if a != nil {
+		path = slices.Clone(a.config.source.path)
+		if desc == a.info {
+			return path
+		}
+		desc = strings.TrimSuffix(desc, infoSeparator+a.info)
+	}
+	if desc == "" {
+		return path
+	}
+	return append(path, desc)
+}
+
+// origins provides the given applying config object
+// followed by the config objects applying it.
+func (s *ConfigStore) origins(by *applying) AppliedConfigs {
+	if by == nil {
+		return nil
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var result AppliedConfigs
+	for cur := by.config; cur != nil; {
+		result = append(result, cur)
+		if cur.origin == 0 {
+			break
+		}
+		gen := cur.origin
+		cur = nil
+		for _, c := range s.configs {
+			if c.generation == gen {
+				cur = c
+				break
+			}
+		}
+	}
+	return result
 }
 
 func (s *ConfigStore) appendCfg(ctx Context, result, configs AppliedConfigs, selector AppliedConfigSelector) AppliedConfigs {
@@ -137,6 +458,7 @@ func (c *ConfigStore) GetConfigForSelector(ctx Context, selector AppliedConfigSe
 	var result AppliedConfigs
 	c.lock.Lock()
 	defer c.lock.Unlock()
+	c.sync()
 
 	return c.generation, c.appendCfg(ctx, result, c.configs, selector)
 }
@@ -145,6 +467,7 @@ func (c *ConfigStore) GetConfigForName(ctx Context, name string, selector Applie
 	var result AppliedConfigs
 	c.lock.Lock()
 	defer c.lock.Unlock()
+	c.sync()
 
 	return c.generation, c.appendCfg(ctx, result, c.types[name], selector)
 }
@@ -153,6 +476,7 @@ func (c *ConfigStore) GetConfigForType(ctx Context, typ string, selector Applied
 	var result AppliedConfigs
 	c.lock.Lock()
 	defer c.lock.Unlock()
+	c.sync()
 
 	result = c.appendCfg(ctx, result, c.types[typ], selector)
 	idx := strings.LastIndex(typ, "/")
@@ -165,14 +489,81 @@ func (c *ConfigStore) GetConfigForType(ctx Context, typ string, selector Applied
 	return c.generation, result
 }
 
+// AddSet adds a config set.
 func (c *ConfigStore) AddSet(name string, set *ConfigSet) {
+	c.addSet(name, set, nil)
+}
+
+// addSet adds a config set. If it is added by a config
+// object applied to the config context, the source of
+// the config object is recorded for the set.
+func (c *ConfigStore) addSet(name string, set *ConfigSet, by *applying) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 	c.sets[name] = set
+	if cur := by.source(); cur != nil {
+		o := originFor(cur, &ApplyOptions{Element: JoinElementPath("sets", name)})
+		o.configSet = ""
+		c.setOrigins[name] = o
+	} else {
+		delete(c.setOrigins, name)
+	}
+}
+
+// getSetOrigin provides the source of the definition
+// of a config set.
+func (c *ConfigStore) getSetOrigin(name string) origin {
+	c.lock.Lock()
+	o, ok := c.setOrigins[name]
+	c.lock.Unlock()
+	if !ok && c.parent != nil {
+		return c.parent.getSetOrigin(name)
+	}
+	return o
 }
 
+// GetSet provides a locally defined config set or
+// the set inherited from a parent store.
 func (c *ConfigStore) GetSet(name string) *ConfigSet {
+	c.lock.Lock()
+	set := c.sets[name]
+	c.lock.Unlock()
+	if set == nil && c.parent != nil {
+		return c.parent.GetSet(name)
+	}
+	return set
+}
+
+// setActivation records the result of the evaluation of the
+// activation conditions of a config set.
+func (c *ConfigStore) setActivation(r *ActivationResult) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
-	return c.sets[name]
+	c.activations[r.ConfigSet] = r
+}
+
+// getActivations provides the recorded activation results
+// including those inherited from a parent store.
+func (c *ConfigStore) getActivations() map[string]*ActivationResult {
+	var result map[string]*ActivationResult
+	if c.parent != nil {
+		result = c.parent.getActivations()
+	} else {
+		result = map[string]*ActivationResult{}
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	maps.Copy(result, c.activations)
+	return result
+}
+
+func (c *ConfigStore) SetNames() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.parent == nil {
+		return maputils.OrderedKeys(c.sets)
+	}
+	names := set.KeySet(c.sets)
+	names.Add(c.parent.SetNames()...)
+	return maputils.OrderedKeys(names)
 }
@@ -76,6 +76,12 @@ func (u *updater) State() (int64, bool) {
 	return u.lastGeneration, u.inupdate
 }
 
+// InUpdate implements ctxmgmt.UpdateState.
+func (u *updater) InUpdate() bool {
+	_, inupdate := u.State()
+	return inupdate
+}
+
 func (u *updater) Update() error {
 	u.Lock()
 	if u.inupdate {
@@ -0,0 +1,94 @@
+package config_test
+
+import (
+	"runtime"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/ctxmgmt/config"
+	"github.com/mandelsoft/ctxmgmt/utils/runtimefinalizer"
+)
+
+var _ = Describe("overlay contexts", func() {
+	var scheme config.ConfigTypeScheme
+	var parent config.Context
+
+	BeforeEach(func() {
+		scheme = config.NewConfigTypeScheme()
+		RegisterAt(scheme)
+		parent = config.WithConfigTypeScheme(scheme).New()
+	})
+
+	It("inherits config from parent", func() {
+		cfg1 := NewConfig("a", "parent")
+		Expect(parent.ApplyConfig(cfg1, "parent")).To(Succeed())
+
+		overlay := config.NewOverlay(parent)
+		Expect(overlay.Parent().GetId()).To(Equal(parent.GetId()))
+		Expect(overlay.ConfigTypes()).To(BeIdenticalTo(parent.ConfigTypes()))
+
+		d := newDummy(overlay)
+		Expect(d.getApplied()).To(Equal([]*Config{cfg1}))
+
+		cfg2 := NewConfig("b", "overlay")
+		Expect(overlay.ApplyConfig(cfg2, "overlay")).To(Succeed())
+		cfg3 := NewConfig("c", "parent")
+		Expect(parent.ApplyConfig(cfg3, "parent")).To(Succeed())
+		Expect(d.getApplied()).To(Equal([]*Config{cfg1, cfg2, cfg3}))
+
+		_, cfgs := parent.GetConfig(config.AllGenerations, nil)
+		Expect(cfgs).To(Equal([]config.Config{cfg1, cfg3}))
+		Expect(newDummy(parent).getApplied()).To(Equal([]*Config{cfg1, cfg3}))
+	})
+
+	It("keeps config sets local", func() {
+		parent.AddConfigSet("parent", config.NewConfigSet("parent"))
+		overlay := config.NewOverlay(parent)
+		overlay.AddConfigSet("overlay", config.NewConfigSet("overlay"))
+
+		Expect(overlay.ConfigSetNames()).To(Equal([]string{"overlay", "parent"}))
+		Expect(overlay.GetConfigSet("parent")).NotTo(BeNil())
+		Expect(parent.ConfigSetNames()).To(Equal([]string{"parent"}))
+		Expect(parent.GetConfigSet("overlay")).To(BeNil())
+	})
+
+	It("keeps attributes local", func() {
+		Expect(parent.GetAttributes().SetAttribute("parent", "value")).To(Succeed())
+		overlay := config.NewOverlay(parent)
+		Expect(overlay.GetAttributes().SetAttribute("overlay", "value")).To(Succeed())
+
+		Expect(overlay.GetAttributes().GetAttribute("parent")).To(Equal("value"))
+		Expect(parent.GetAttributes().GetAttribute("overlay")).To(BeNil())
+	})
+
+	It("describes inherited config", func() {
+		Expect(parent.ApplyConfig(NewConfig("a", "parent"), "parent")).To(Succeed())
+		overlay := config.NewOverlay(parent)
+		Expect(overlay.ApplyConfig(NewConfig("b", "overlay"), "overlay")).To(Succeed())
+
+		desc := overlay.(interface{ Describe() *config.Description }).Describe()
+		Expect(desc.Parent).To(Equal(parent.GetId()))
+		Expect(desc.AppliedConfigs).To(Equal([]config.AppliedConfigDescription{
+			{Generation: 1, Type: DummyType, Description: "parent", Inherited: true},
+			{Generation: 2, Type: DummyType, Description: "overlay"},
+		}))
+	})
+
+	It("can discard an overlay", func() {
+		overlay := config.NewOverlay(parent)
+		r := runtimefinalizer.GetRuntimeFinalizationRecorder(overlay)
+		Expect(r).NotTo(BeNil())
+		id := overlay.GetId()
+
+		overlay = nil
+		for i := 0; i < 100; i++ {
+			runtime.GC()
+			time.Sleep(time.Millisecond)
+		}
+		Expect(r.Get()).To(ContainElement(runtimefinalizer.ObjectIdentity(id)))
+
+		Expect(parent.ApplyConfig(NewConfig("a", "parent"), "parent")).To(Succeed())
+	})
+})
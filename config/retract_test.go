@@ -0,0 +1,161 @@
+package config_test
+
+import (
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/ctxmgmt/config"
+	configcfg "github.com/mandelsoft/ctxmgmt/config/extensions/config"
+)
+
+var _ = Describe("retracting config", func() {
+	var cfgctx config.Context
+	var d *dummyContext
+
+	cfg1 := NewConfig("a", "1")
+	cfg2 := NewConfig("b", "2")
+	cfg3 := NewConfig("c", "3")
+
+	BeforeEach(func() {
+		scheme := config.NewConfigTypeScheme()
+		scheme.AddKnownTypes(config.DefaultContext().ConfigTypes())
+		RegisterAt(scheme)
+		cfgctx = config.WithConfigTypeScheme(scheme).New()
+		d = newDummy(cfgctx)
+	})
+
+	It("retracts by handle", func() {
+		h1, err := cfgctx.ApplyConfigWithHandle(cfg1, "first")
+		Expect(err).To(Succeed())
+		h2, err := cfgctx.ApplyConfigWithHandle(cfg2, "second")
+		Expect(err).To(Succeed())
+		Expect(h2.Generation()).To(BeNumerically(">", h1.Generation()))
+		Expect(d.getApplied()).To(Equal([]*Config{cfg1, cfg2}))
+		gen := cfgctx.Generation()
+
+		removed := cfgctx.Retract(config.ByHandle(h1))
+		Expect(removed.Configs()).To(Equal([]config.Config{cfg1}))
+		Expect(cfgctx.Generation()).To(BeNumerically(">", gen))
+
+		Expect(d.getApplied()).To(Equal([]*Config{cfg2}))
+		_, cfgs := cfgctx.GetConfig(config.AllGenerations, nil)
+		Expect(cfgs).To(Equal([]config.Config{cfg2}))
+		Expect(newDummy(cfgctx).getApplied()).To(Equal([]*Config{cfg2}))
+	})
+
+	It("retracts by description", func() {
+		Expect(cfgctx.ApplyConfig(cfg1, "file")).To(Succeed())
+		Expect(cfgctx.ApplyConfig(cfg2, "other")).To(Succeed())
+		Expect(cfgctx.ApplyConfig(cfg3, "file")).To(Succeed())
+		Expect(d.getApplied()).To(Equal([]*Config{cfg1, cfg2, cfg3}))
+
+		Expect(cfgctx.Retract(config.ByDescription("file"))).To(HaveLen(2))
+		Expect(d.getApplied()).To(Equal([]*Config{cfg2}))
+	})
+
+	It("retracts by generation range", func() {
+		h1, _ := cfgctx.ApplyConfigWithHandle(cfg1, "first")
+		h2, _ := cfgctx.ApplyConfigWithHandle(cfg2, "second")
+		Expect(cfgctx.ApplyConfig(cfg3, "third")).To(Succeed())
+
+		Expect(cfgctx.Retract(config.ByGenerationRange(h1.Generation(), h2.Generation()))).To(HaveLen(2))
+		Expect(d.getApplied()).To(Equal([]*Config{cfg3}))
+	})
+
+	It("retracts config objects applied by a retracted one", func() {
+		generic := configcfg.New()
+		Expect(generic.AddConfig(cfg1)).To(Succeed())
+		Expect(generic.AddConfig(cfg2)).To(Succeed())
+
+		h, err := cfgctx.ApplyConfigWithHandle(generic, "generic")
+		Expect(err).To(Succeed())
+		Expect(cfgctx.ApplyConfig(cfg3, "direct")).To(Succeed())
+		Expect(d.getApplied()).To(HaveLen(3))
+
+		Expect(cfgctx.Retract(config.ByHandle(h))).To(HaveLen(3))
+		Expect(d.getApplied()).To(Equal([]*Config{cfg3}))
+	})
+
+	It("retracts next to a generic config", func() {
+		generic := configcfg.New()
+		Expect(generic.AddConfig(cfg2)).To(Succeed())
+
+		h, err := cfgctx.ApplyConfigWithHandle(cfg1, "first")
+		Expect(err).To(Succeed())
+		Expect(cfgctx.ApplyConfig(generic, "generic")).To(Succeed())
+		Expect(d.getApplied()).To(Equal([]*Config{cfg1, cfg2}))
+
+		cfgctx.Retract(config.ByHandle(h))
+		Expect(d.getApplied()).To(Equal([]*Config{cfg2}))
+		_, cfgs := cfgctx.GetConfig(config.AllGenerations, nil)
+		Expect(cfgs).To(Equal([]config.Config{generic, cfg2}))
+
+		Expect(cfgctx.ApplyConfig(cfg3, "third")).To(Succeed())
+		_, cfgs = cfgctx.GetConfig(config.AllGenerations, nil)
+		Expect(cfgs).To(Equal([]config.Config{generic, cfg2, cfg3}))
+		Expect(d.getApplied()).To(Equal([]*Config{cfg2, cfg3}))
+	})
+
+	It("keeps config objects applied concurrently to the application of another one", func() {
+		blocking := &blockingConfig{Config: NewConfig("x", "blocking"), started: make(chan struct{}), release: make(chan struct{})}
+
+		done := make(chan error)
+		go func() {
+			done <- cfgctx.ApplyConfig(blocking, "blocking")
+		}()
+		Eventually(blocking.started).WithTimeout(5 * time.Second).Should(BeClosed())
+		Expect(cfgctx.ApplyConfig(cfg3, "direct")).To(Succeed())
+		close(blocking.release)
+		Eventually(done).WithTimeout(5 * time.Second).Should(Receive(BeNil()))
+
+		_, applied := cfgctx.GetAppliedConfigs(config.ByDescription("direct"))
+		Expect(applied).To(HaveLen(1))
+		Expect(applied[0].Origin()).To(BeZero())
+
+		Expect(cfgctx.Retract(config.ByDescription("blocking"))).To(HaveLen(1))
+		_, cfgs := cfgctx.GetConfig(config.AllGenerations, nil)
+		Expect(cfgs).To(Equal([]config.Config{cfg3}))
+		Expect(d.getApplied()).To(Equal([]*Config{cfg3}))
+	})
+
+	It("ignores unmatched selectors", func() {
+		Expect(cfgctx.ApplyConfig(cfg1, "first")).To(Succeed())
+		gen := cfgctx.Generation()
+
+		Expect(cfgctx.Retract(config.ByDescription("unknown"))).To(BeEmpty())
+		Expect(cfgctx.Generation()).To(Equal(gen))
+		Expect(d.getApplied()).To(Equal([]*Config{cfg1}))
+	})
+
+	It("propagates retractions to overlays", func() {
+		h, _ := cfgctx.ApplyConfigWithHandle(cfg1, "parent")
+		overlay := config.NewOverlay(cfgctx)
+		Expect(overlay.ApplyConfig(cfg2, "overlay")).To(Succeed())
+		od := newDummy(overlay)
+		Expect(od.getApplied()).To(Equal([]*Config{cfg1, cfg2}))
+
+		cfgctx.Retract(config.ByHandle(h))
+		Expect(od.getApplied()).To(Equal([]*Config{cfg2}))
+	})
+})
+
+// blockingConfig blocks its application to the config
+// context until it is released.
+type blockingConfig struct {
+	*Config
+	once    sync.Once
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingConfig) ApplyTo(ctx config.Context, target interface{}) error {
+	if _, ok := target.(config.Context); ok {
+		b.once.Do(func() { close(b.started) })
+		<-b.release
+		return nil
+	}
+	return b.Config.ApplyTo(ctx, target)
+}
@@ -0,0 +1,309 @@
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/mandelsoft/goutils/general"
+	"github.com/mandelsoft/goutils/maputils"
+	"github.com/texttheater/golang-levenshtein/levenshtein"
+	"sigs.k8s.io/yaml"
+
+	"github.com/mandelsoft/ctxmgmt/config/cpi"
+	configcfg "github.com/mandelsoft/ctxmgmt/config/extensions/config"
+	"github.com/mandelsoft/ctxmgmt/utils"
+	"github.com/mandelsoft/ctxmgmt/utils/runtime"
+)
+
+type Severity string
+
+const (
+	ERROR   Severity = "error"
+	WARNING Severity = "warning"
+)
+
+// MAX_SUGGESTIONS is the maximum number of suggestions
+// provided for an unknown name.
+const MAX_SUGGESTIONS = 3
+
+// Finding describes a problem found in a config document.
+type Finding struct {
+	Severity Severity `json:"severity"`
+	// Source is the name of the config document.
+	Source string `json:"source,omitempty"`
+	// Element is the element path of the problematic field.
+	Element string `json:"element,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+	// Suggestions are candidates for an unknown name.
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+func (f Finding) String() string {
+	var loc []string
+	if f.Source != "" {
+		loc = append(loc, f.Source)
+	}
+	if f.Line > 0 {
+		loc = append(loc, fmt.Sprintf("%d:%d", f.Line, f.Column))
+	}
+	s := ""
+	if len(loc) > 0 {
+		s = strings.Join(loc, ":") + ": "
+	}
+	s += fmt.Sprintf("%s: %s", f.Severity, f.Message)
+	if f.Element != "" {
+		s += " (" + f.Element + ")"
+	}
+	if len(f.Suggestions) > 0 {
+		s += fmt.Sprintf(", did you mean %s?", quoted(f.Suggestions))
+	}
+	return s
+}
+
+func quoted(list []string) string {
+	q := make([]string, len(list))
+	for i, e := range list {
+		q[i] = fmt.Sprintf("%q", e)
+	}
+	switch len(q) {
+	case 1:
+		return q[0]
+	default:
+		return strings.Join(q[:len(q)-1], ", ") + " or " + q[len(q)-1]
+	}
+}
+
+// Errorf provides an error finding for an element path
+// relative to the validated config object.
+func Errorf(element string, msg string, args ...interface{}) Finding {
+	return Finding{Severity: ERROR, Element: element, Message: fmt.Sprintf(msg, args...)}
+}
+
+// Warnf provides a warning finding for an element path
+// relative to the validated config object.
+func Warnf(element string, msg string, args ...interface{}) Finding {
+	return Finding{Severity: WARNING, Element: element, Message: fmt.Sprintf(msg, args...)}
+}
+
+// Unknown provides a finding for an unknown name of some kind
+// including suggestions from the given candidates.
+func Unknown(severity Severity, element string, kind, name string, candidates []string) Finding {
+	return Finding{
+		Severity:    severity,
+		Element:     element,
+		Message:     fmt.Sprintf("unknown %s %q", kind, name),
+		Suggestions: Suggest(name, candidates),
+	}
+}
+
+// Suggest provides the candidates most similar to an unknown
+// name (compared case-insensitively) ordered by similarity.
+func Suggest(name string, candidates []string) []string {
+	type candidate struct {
+		name string
+		dist int
+	}
+	lname := strings.ToLower(name)
+	limit := max(2, len(name)/5)
+	var found []candidate
+	for _, c := range candidates {
+		if c == name {
+			continue
+		}
+		d := levenshtein.DistanceForStrings([]rune(lname), []rune(strings.ToLower(c)), levenshtein.DefaultOptions)
+		if d <= limit {
+			found = append(found, candidate{c, d})
+		}
+	}
+	slices.SortFunc(found, func(a, b candidate) int {
+		if a.dist != b.dist {
+			return a.dist - b.dist
+		}
+		return strings.Compare(a.name, b.name)
+	})
+	var result []string
+	for _, c := range found[:min(len(found), MAX_SUGGESTIONS)] {
+		result = append(result, c.name)
+	}
+	return result
+}
+
+// Report is the result of a validation.
+type Report struct {
+	Findings []Finding `json:"findings,omitempty"`
+}
+
+// HasErrors returns true if the report contains errors.
+// If strict is set, warnings are considered as errors, also.
+func (r *Report) HasErrors(strict ...bool) bool {
+	for _, f := range r.Findings {
+		if f.Severity == ERROR || general.Optional(strict...) {
+			return true
+		}
+	}
+	return false
+}
+
+// Print prints the findings in a human-readable form.
+func (r *Report) Print(p utils.Printer) {
+	for _, f := range r.Findings {
+		p.Printf("%s\n", f)
+	}
+}
+
+func (r *Report) String() string {
+	p, buf := utils.NewBufferedPrinter()
+	r.Print(p)
+	return buf.String()
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Validator validates config objects of a dedicated config type.
+// Element paths of the findings are relative to the config object.
+type Validator interface {
+	Validate(ctx cpi.ContextProvider, cfg cpi.Config) []Finding
+}
+
+type ValidatorFunction func(ctx cpi.ContextProvider, cfg cpi.Config) []Finding
+
+func (f ValidatorFunction) Validate(ctx cpi.ContextProvider, cfg cpi.Config) []Finding {
+	return f(ctx, cfg)
+}
+
+type validatorRegistry struct {
+	lock       sync.Mutex
+	validators map[string][]Validator
+}
+
+var validators = &validatorRegistry{validators: map[string][]Validator{}}
+
+// RegisterValidator registers a validator for a config type.
+// A versioned type is registered for the unversioned kind.
+func RegisterValidator(typ string, v Validator) {
+	kind, _ := runtime.KindVersion(typ)
+	validators.lock.Lock()
+	defer validators.lock.Unlock()
+	validators.validators[kind] = append(validators.validators[kind], v)
+}
+
+func (r *validatorRegistry) get(kind string) []Validator {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return slices.Clone(r.validators[kind])
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Validate validates a config document against the config types
+// known by a config context. The document has to be preprocessed
+// already (see cfgutils.ValidateData). Unknown config types are
+// reported together with suggestions. Nested configurations of
+// generic.config objects are validated recursively and the config
+// objects are validated by the validators registered for their config
+// type (see RegisterValidator). The source is used to determine
+// the positions of the findings.
+func Validate(ctx cpi.ContextProvider, data []byte, src *cpi.ConfigSource) *Report {
+	v := &validation{
+		ctx:    ctx,
+		cctx:   ctx.ConfigContext(),
+		src:    src,
+		report: &Report{},
+	}
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		v.add("", Errorf("", "invalid config document: %s", err))
+		return v.report
+	}
+	v.validate("", doc)
+	return v.report
+}
+
+type validation struct {
+	ctx    cpi.ContextProvider
+	cctx   cpi.Context
+	src    *cpi.ConfigSource
+	report *Report
+}
+
+func (v *validation) add(base string, f Finding) {
+	f.Element = cpi.JoinElementPath(base, f.Element)
+	if v.src != nil {
+		f.Source = v.src.Name
+		// use the position of the nearest enclosing element.
+		for e := f.Element; ; {
+			if pos, ok := v.src.Position(e); ok {
+				f.Line, f.Column = pos.Line, pos.Column
+				break
+			}
+			i := strings.LastIndexAny(e, ".[")
+			if i < 0 {
+				if e == "" {
+					break
+				}
+				e = ""
+			} else {
+				e = e[:i]
+			}
+		}
+	}
+	v.report.Findings = append(v.report.Findings, f)
+}
+
+func (v *validation) validate(element string, value interface{}) {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		v.add(element, Errorf("", "config object expected"))
+		return
+	}
+	typ, ok := m["type"].(string)
+	if !ok || typ == "" {
+		v.add(element, Errorf("", "config type missing"))
+		return
+	}
+	types := v.cctx.ConfigTypes()
+	if _, ok := types.KnownTypes()[typ]; !ok {
+		v.add(element, Unknown(ERROR, "type", "config type", typ, types.KnownTypeNames()))
+		return
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		v.add(element, Errorf("", "invalid config object: %s", err))
+		return
+	}
+	cfg, err := v.cctx.GetConfigForData(data, runtime.DefaultJSONEncoding)
+	if err != nil {
+		v.add(element, Errorf("", "invalid config object: %s", err))
+		return
+	}
+
+	kind, _ := runtime.KindVersion(typ)
+	if kind == configcfg.ConfigType {
+		if list, ok := m["configurations"].([]interface{}); ok {
+			for i, e := range list {
+				v.validate(cpi.JoinElementPath(element, fmt.Sprintf("configurations[%d]", i)), e)
+			}
+		}
+		if sets, ok := m["sets"].(map[string]interface{}); ok {
+			for _, n := range maputils.OrderedKeys(sets) {
+				set, _ := sets[n].(map[string]interface{})
+				list, _ := set["configurations"].([]interface{})
+				for i, e := range list {
+					v.validate(cpi.JoinElementPath(element, "sets", n, fmt.Sprintf("configurations[%d]", i)), e)
+				}
+			}
+		}
+	}
+
+	for _, val := range validators.get(kind) {
+		for _, f := range val.Validate(v.ctx, cfg) {
+			v.add(element, f)
+		}
+	}
+}
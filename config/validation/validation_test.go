@@ -0,0 +1,29 @@
+package validation_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/ctxmgmt/config"
+	me "github.com/mandelsoft/ctxmgmt/config/validation"
+)
+
+var _ = Describe("validation", func() {
+	It("suggests similar names", func() {
+		candidates := []string{"DockerConfig", "DockerConfig/v1", "NPMConfig", "Memory"}
+		Expect(me.Suggest("dockerconfig", candidates)).To(Equal([]string{"DockerConfig"}))
+		Expect(me.Suggest("DockrConfig", candidates)).To(Equal([]string{"DockerConfig"}))
+		Expect(me.Suggest("Vault", candidates)).To(BeEmpty())
+	})
+
+	It("reports unknown config types", func() {
+		data := []byte("type: generic.config.mandelsoft.de\nconfigurations:\n  - type: generic.confg.mandelsoft.de\n")
+		r := me.Validate(config.DefaultContext(), data, config.NewConfigSourceForData("test", data))
+		Expect(r.String()).To(Equal(`test:3:11: error: unknown config type "generic.confg.mandelsoft.de" (configurations[0].type), did you mean "generic.config.mandelsoft.de" or "generic.config.mandelsoft.de/v1"?` + "\n"))
+	})
+
+	It("reports invalid documents", func() {
+		r := me.Validate(config.DefaultContext(), []byte("- type: x"), nil)
+		Expect(r.String()).To(Equal("error: config object expected\n"))
+	})
+})
@@ -0,0 +1,163 @@
+package ctxmgmt
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/mandelsoft/goutils/errors"
+	"github.com/mandelsoft/goutils/maputils"
+)
+
+const KIND_CONTEXTTYPE = "context type"
+
+// ContextType describes a data context type.
+type ContextType interface {
+	// Name returns the context type name as provided
+	// by the GetType method of the contexts.
+	Name() string
+	Description() string
+	// New creates a new context of this type.
+	New(mode ...BuilderMode) Context
+	// FromContext provides the context of this type bound to a
+	// context.Context or the default context.
+	FromContext(ctx context.Context) Context
+	// Dependencies provides the names of the context types
+	// used by contexts of this type.
+	Dependencies() []string
+}
+
+type contextType struct {
+	name         string
+	description  string
+	factory      func(mode ...BuilderMode) Context
+	from         func(ctx context.Context) Context
+	dependencies []string
+}
+
+// NewContextType provides a ContextType object for the given
+// metadata and access functions.
+func NewContextType(name, desc string, factory func(mode ...BuilderMode) Context, from func(ctx context.Context) Context, deps ...string) ContextType {
+	return &contextType{
+		name:         name,
+		description:  desc,
+		factory:      factory,
+		from:         from,
+		dependencies: slices.Clone(deps),
+	}
+}
+
+func (t *contextType) Name() string {
+	return t.name
+}
+
+func (t *contextType) Description() string {
+	return t.description
+}
+
+func (t *contextType) New(mode ...BuilderMode) Context {
+	return t.factory(mode...)
+}
+
+func (t *contextType) FromContext(ctx context.Context) Context {
+	if t.from == nil {
+		return nil
+	}
+	return t.from(ctx)
+}
+
+func (t *contextType) Dependencies() []string {
+	return slices.Clone(t.dependencies)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// ContextTypeRegistry is a registry for context types.
+type ContextTypeRegistry interface {
+	Register(t ContextType)
+	Get(name string) ContextType
+	Names() []string
+	Types() []ContextType
+	// DependencyClosure provides the names of the given context type
+	// and all context types it directly or indirectly depends on,
+	// ordered such that dependencies precede their dependents.
+	DependencyClosure(name string) ([]string, error)
+}
+
+type contextTypeRegistry struct {
+	lock  sync.RWMutex
+	types map[string]ContextType
+}
+
+// NewContextTypeRegistry creates a new empty context type registry.
+func NewContextTypeRegistry() ContextTypeRegistry {
+	return &contextTypeRegistry{types: map[string]ContextType{}}
+}
+
+func (r *contextTypeRegistry) Register(t ContextType) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.types[t.Name()] = t
+}
+
+func (r *contextTypeRegistry) Get(name string) ContextType {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.types[name]
+}
+
+func (r *contextTypeRegistry) Names() []string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return maputils.OrderedKeys(r.types)
+}
+
+func (r *contextTypeRegistry) Types() []ContextType {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return maputils.OrderedValues(r.types)
+}
+
+func (r *contextTypeRegistry) DependencyClosure(name string) ([]string, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	var result []string
+	return result, r.closure(name, &result, nil)
+}
+
+func (r *contextTypeRegistry) closure(name string, result *[]string, stack []string) error {
+	if slices.Contains(*result, name) {
+		return nil
+	}
+	if slices.Contains(stack, name) {
+		return errors.Newf("dependency cycle for context type %q", name)
+	}
+	t := r.types[name]
+	if t == nil {
+		return errors.ErrUnknown(KIND_CONTEXTTYPE, name)
+	}
+	for _, d := range t.Dependencies() {
+		err := r.closure(d, result, append(stack, name))
+		if err != nil {
+			return err
+		}
+	}
+	*result = append(*result, name)
+	return nil
+}
+
+// DefaultContextTypeRegistry is the registry used by data context types
+// to register themselves.
+var DefaultContextTypeRegistry = NewContextTypeRegistry()
+
+// RegisterContextType registers a context type at the DefaultContextTypeRegistry.
+func RegisterContextType(t ContextType) {
+	DefaultContextTypeRegistry.Register(t)
+}
+
+// GetContextType provides the context type object registered
+// at the DefaultContextTypeRegistry for the given context type name.
+func GetContextType(name string) ContextType {
+	return DefaultContextTypeRegistry.Get(name)
+}
@@ -0,0 +1,58 @@
+package ctxmgmt_test
+
+import (
+	"context"
+
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	me "github.com/mandelsoft/ctxmgmt"
+	"github.com/mandelsoft/ctxmgmt/attributes"
+	"github.com/mandelsoft/ctxmgmt/config"
+	"github.com/mandelsoft/ctxmgmt/credentials"
+)
+
+var _ = Describe("context type registry", func() {
+	It("provides the standard context types", func() {
+		Expect(me.DefaultContextTypeRegistry.Names()).To(ContainElements(attributes.CONTEXT_TYPE, config.CONTEXT_TYPE, credentials.CONTEXT_TYPE))
+
+		t := me.GetContextType(credentials.CONTEXT_TYPE)
+		Expect(t).NotTo(BeNil())
+		Expect(t.Description()).NotTo(BeEmpty())
+		Expect(t.Dependencies()).To(Equal([]string{config.CONTEXT_TYPE}))
+	})
+
+	It("creates contexts", func() {
+		for _, t := range me.DefaultContextTypeRegistry.Types() {
+			ctx := t.New(me.MODE_DEFAULTED)
+			Expect(ctx.GetType()).To(Equal(t.Name()))
+			Expect(me.IsPersistentContextRef(ctx)).To(BeTrue())
+		}
+	})
+
+	It("provides contexts for context.Context", func() {
+		Expect(me.GetContextType(config.CONTEXT_TYPE).FromContext(context.Background())).To(BeIdenticalTo(config.DefaultContext()))
+
+		ctx := credentials.New()
+		Expect(me.GetContextType(credentials.CONTEXT_TYPE).FromContext(ctx.BindTo(context.Background())).GetId()).To(Equal(ctx.GetId()))
+	})
+
+	It("resolves dependencies", func() {
+		Expect(Must(me.DefaultContextTypeRegistry.DependencyClosure(credentials.CONTEXT_TYPE))).To(Equal([]string{
+			attributes.CONTEXT_TYPE, config.CONTEXT_TYPE, credentials.CONTEXT_TYPE,
+		}))
+	})
+
+	It("detects unknown dependencies and cycles", func() {
+		r := me.NewContextTypeRegistry()
+		r.Register(me.NewContextType("a", "", nil, nil, "b"))
+		r.Register(me.NewContextType("b", "", nil, nil, "a"))
+		r.Register(me.NewContextType("c", "", nil, nil, "d"))
+
+		_, err := r.DependencyClosure("a")
+		Expect(err).To(MatchError(`dependency cycle for context type "a"`))
+		_, err = r.DependencyClosure("c")
+		Expect(err).To(MatchError(`context type "d" is unknown`))
+	})
+})
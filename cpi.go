@@ -10,6 +10,7 @@ import (
 	"github.com/modern-go/reflect2"
 
 	"github.com/mandelsoft/ctxmgmt/action/handlers"
+	"github.com/mandelsoft/ctxmgmt/utils/cleanup"
 	"github.com/mandelsoft/ctxmgmt/utils/refmgmt"
 	"github.com/mandelsoft/ctxmgmt/utils/refmgmt/finalized"
 	"github.com/mandelsoft/ctxmgmt/utils/runtimefinalizer"
@@ -46,6 +47,9 @@ var _ provider = (*GCWrapper)(nil) // wrapper provides access to internal contex
 func (w *GCWrapper) setSelf(a refmgmt.Allocatable, self Context, ictx Context, key interface{}) {
 	if a != nil {
 		w.ref, _ = finalized.NewPlainFinalizedView(a)
+		if t := tracking.Load(); t != nil && w.ref != nil {
+			t.addView(a, w.ref)
+		}
 	}
 	w.self = self
 	w.ctx = ictx
@@ -182,6 +186,7 @@ func FinalizedContext[W Context, C InternalContext, P finalizableContextWrapper[
 	p := (P)(&v)
 	p.SetContext(c)
 	p.setSelf(c.GetAllocatable(), p, c, c.GetKey()) // prepare for generic bind operation
+	notifyLifecycle(c, &LifecycleEvent{Type: LIFECYCLE_VIEW, Context: p})
 	return p
 }
 
@@ -197,7 +202,9 @@ type contextBase struct {
 	delegates
 
 	finalizer *finalizer.Finalizer
+	cleanups  *cleanup.Graph
 	recorder  *runtimefinalizer.RuntimeFinalizationRecoder
+	observers *LifecycleObservers
 }
 
 var _ Context = (*contextBase)(nil)
@@ -211,11 +218,16 @@ func newContextBase(eff Context, typ string, key interface{}, parentAttrs Attrib
 		key:        key,
 		effective:  eff,
 		finalizer:  &finalizer.Finalizer{},
+		cleanups:   &cleanup.Graph{},
 		attributes: newAttributes(eff, parentAttrs, updater),
 		delegates:  delegates,
 		recorder:   recorder,
+		observers:  &LifecycleObservers{},
 	}
 	c.allocatable = refmgmt.NewAllocatable(c.cleanup, true)
+	if t := tracking.Load(); t != nil {
+		t.addContext(c)
+	}
 	Debug(c, "create context", "id", c.GetId())
 	return c
 }
@@ -263,18 +275,81 @@ func (c *contextBase) cleanup() error {
 	if c.recorder != nil {
 		c.recorder.Record(c.id)
 	}
-	return c.Cleanup()
+	err := c.Cleanup()
+	notifyLifecycle(c, &LifecycleEvent{Type: LIFECYCLE_CLEANUP, Context: c.effective, Error: err})
+	return err
 }
 
+// Names of the implicit cleanup steps executed after
+// the cleanup of the resources registered in the cleanup graph.
+const (
+	CLEANUP_FINALIZERS = "finalizers"
+	CLEANUP_ATTRIBUTES = "attributes"
+)
+
 func (c *contextBase) Cleanup() error {
 	list := errors.ErrListf("cleanup %s", c.id)
-	list.Addf(nil, c.finalizer.Finalize(), "finalizers")
+	list.Add(c.cleanups.Cleanup())
+	list.Addf(nil, c.finalizer.Finalize(), CLEANUP_FINALIZERS)
 	list.Add(c.attributes.Finalize())
 	return list.Result()
 }
 
+// Cleanups provides the dependency graph for resources
+// cleaned up together with the context. They are cleaned up
+// before the finalizers and the attributes of the context.
+func (c *contextBase) Cleanups() *cleanup.Graph {
+	return c.cleanups
+}
+
+// CleanupPlan describes the cleanup order for the context.
+func (c *contextBase) CleanupPlan() cleanup.Plan {
+	return append(c.cleanups.Plan(),
+		&cleanup.Step{Name: CLEANUP_FINALIZERS},
+		&cleanup.Step{Name: CLEANUP_ATTRIBUTES},
+	)
+}
+
+// CleanupProvider is implemented by contexts supporting
+// a dependency graph for the cleanup of their resources.
+type CleanupProvider interface {
+	// Cleanups provides the dependency graph for resources
+	// cleaned up when the context is cleaned up.
+	Cleanups() *cleanup.Graph
+	// CleanupPlan describes the order used to clean up
+	// the context without executing the cleanup.
+	CleanupPlan() cleanup.Plan
+}
+
+// Cleanups provides the cleanup graph of a context.
+// It returns nil, if the context does not support a cleanup graph.
+func Cleanups(ctx Context) *cleanup.Graph {
+	if p, ok := ctx.(CleanupProvider); ok {
+		return p.Cleanups()
+	}
+	return nil
+}
+
+// CleanupPlan describes the cleanup order for a context without
+// executing the cleanup. It returns nil, if the context does not
+// support a cleanup graph.
+func CleanupPlan(ctx Context) cleanup.Plan {
+	if p, ok := ctx.(CleanupProvider); ok {
+		return p.CleanupPlan()
+	}
+	return nil
+}
+
 func (c *contextBase) Finalize() error {
-	return c.finalizer.Finalize()
+	err := c.finalizer.Finalize()
+	notifyLifecycle(c, &LifecycleEvent{Type: LIFECYCLE_FINALIZE, Context: c.effective, Error: err})
+	return err
+}
+
+// LifecycleObservers provides the registry for lifecycle
+// observers dedicated to this context.
+func (c *contextBase) LifecycleObservers() *LifecycleObservers {
+	return c.observers
 }
 
 func (c *contextBase) Finalizer() *finalizer.Finalizer {
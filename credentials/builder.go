@@ -24,6 +24,18 @@ func WithStandardConumerMatchers(matchers internal.IdentityMatcherRegistry) inte
 	return internal.Builder{}.WithStandardConumerMatchers(matchers)
 }
 
+func WithElementMode(element string, mode ctxmgmt.BuilderMode) internal.Builder {
+	return internal.Builder{}.WithElementMode(element, mode)
+}
+
 func New(mode ...ctxmgmt.BuilderMode) Context {
 	return internal.Builder{}.New(mode...)
 }
+
+// NewOverlay creates a copy-on-write overlay context for a parent
+// credentials context. Credential requests fall through to the parent,
+// while credentials, aliases and configuration set for the overlay
+// are kept local.
+func NewOverlay(parent Context) Context {
+	return internal.NewOverlay(parent)
+}
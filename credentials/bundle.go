@@ -0,0 +1,154 @@
+package credentials
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mandelsoft/goutils/errors"
+	"github.com/mandelsoft/goutils/optionutils"
+
+	"github.com/mandelsoft/ctxmgmt"
+	"github.com/mandelsoft/ctxmgmt/attributes"
+	"github.com/mandelsoft/ctxmgmt/config"
+)
+
+// Bundle is the set of contexts belonging to a credentials context:
+// the credentials context itself, its config context and its
+// attributes context.
+type Bundle struct {
+	Attributes  ctxmgmt.AttributesContext
+	Config      config.Context
+	Credentials Context
+}
+
+// BundleFor provides the context bundle for a credentials context.
+// The nested contexts are provided as persistent context references,
+// so they can be used and bound independently of the credentials context.
+func BundleFor(ctx Context) *Bundle {
+	return &Bundle{
+		Attributes:  ctxmgmt.PersistentContextRef(ctx.AttributesContext()),
+		Config:      ctxmgmt.PersistentContextRef(ctx.ConfigContext()),
+		Credentials: ctx,
+	}
+}
+
+// BindTo binds all contexts of the bundle to a context.Context.
+func (b *Bundle) BindTo(ctx context.Context) context.Context {
+	ctx = b.Attributes.BindTo(ctx)
+	ctx = b.Config.BindTo(ctx)
+	return b.Credentials.BindTo(ctx)
+}
+
+// BindBundle binds a credentials context together with its
+// config and attributes context to a context.Context.
+func BindBundle(ctx context.Context, c Context) context.Context {
+	return BundleFor(c).BindTo(ctx)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+const KIND_CONTEXT = "context"
+
+type BundleOption = optionutils.Option[*BundleOptions]
+
+type BundleOptions struct {
+	// Strict requires the requested contexts to be bound
+	// to the context.Context instead of falling back to the
+	// default contexts. Additionally, the bound contexts
+	// must belong together.
+	Strict bool
+}
+
+var _ BundleOption = (*BundleOptions)(nil)
+
+func (o *BundleOptions) ApplyTo(opts *BundleOptions) {
+	opts.Strict = o.Strict
+}
+
+type strict bool
+
+func (o strict) ApplyTo(opts *BundleOptions) {
+	opts.Strict = bool(o)
+}
+
+// Strict requests an error instead of a fallback to the
+// default contexts, if a context is not bound.
+func Strict(b ...bool) BundleOption {
+	return strict(optionutils.BoolOption(b...))
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// BundleFromContext retrieves the context bundle bound to a context.Context.
+// The contexts are derived from the most specific bound context,
+// a bound credentials context determines the config and attributes context.
+// If no credentials context is bound, the default credentials context
+// is used, or an error is returned if the Strict option is given.
+func BundleFromContext(ctx context.Context, opts ...BundleOption) (*Bundle, error) {
+	c, err := CredentialsForContext(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	b := BundleFor(c)
+	if optionutils.EvalOptions(opts...).Strict {
+		err = checkBound(ctx, b)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// CredentialsForContext retrieves the credentials context bound to a
+// context.Context. Without the Strict option, the default
+// context is used if no context is bound.
+func CredentialsForContext(ctx context.Context, opts ...BundleOption) (Context, error) {
+	c, ok := DefinedForContext(ctx)
+	if !ok && optionutils.EvalOptions(opts...).Strict {
+		return nil, errors.ErrNotFound(KIND_CONTEXT, CONTEXT_TYPE)
+	}
+	return c, nil
+}
+
+// ConfigForContext retrieves the config context bound to a
+// context.Context. A bound credentials context takes precedence
+// over a separately bound config context. Without the Strict
+// option, the default context is used if no context is bound.
+func ConfigForContext(ctx context.Context, opts ...BundleOption) (config.Context, error) {
+	if c, ok := DefinedForContext(ctx); ok {
+		return c.ConfigContext(), nil
+	}
+	c, ok := config.DefinedForContext(ctx)
+	if !ok && optionutils.EvalOptions(opts...).Strict {
+		return nil, errors.ErrNotFound(KIND_CONTEXT, config.CONTEXT_TYPE)
+	}
+	return c, nil
+}
+
+// AttributesForContext retrieves the attributes context bound to a
+// context.Context. Bound credentials or config contexts take precedence
+// over a separately bound attributes context. Without the Strict
+// option, the default context is used if no context is bound.
+func AttributesForContext(ctx context.Context, opts ...BundleOption) (ctxmgmt.AttributesContext, error) {
+	if c, ok := DefinedForContext(ctx); ok {
+		return c.AttributesContext(), nil
+	}
+	if c, ok := config.DefinedForContext(ctx); ok {
+		return c.AttributesContext(), nil
+	}
+	c, ok := attributes.DefinedForContext(ctx)
+	if !ok && optionutils.EvalOptions(opts...).Strict {
+		return nil, errors.ErrNotFound(KIND_CONTEXT, attributes.CONTEXT_TYPE)
+	}
+	return c, nil
+}
+
+func checkBound(ctx context.Context, b *Bundle) error {
+	if c, ok := config.DefinedForContext(ctx); ok && c.GetId() != b.Config.GetId() {
+		return fmt.Errorf("bound config context %s does not belong to credentials context %s", c.GetId(), b.Credentials.GetId())
+	}
+	if c, ok := attributes.DefinedForContext(ctx); ok && c.GetId() != b.Attributes.GetId() {
+		return fmt.Errorf("bound attributes context %s does not belong to credentials context %s", c.GetId(), b.Credentials.GetId())
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package credentials_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/ctxmgmt/attributes"
+	"github.com/mandelsoft/ctxmgmt/config"
+	me "github.com/mandelsoft/ctxmgmt/credentials"
+)
+
+var _ = Describe("context bundle", func() {
+	var ctx me.Context
+
+	BeforeEach(func() {
+		ctx = me.New()
+	})
+
+	It("binds and retrieves all contexts", func() {
+		bound := me.BindBundle(context.Background(), ctx)
+
+		b, err := me.BundleFromContext(bound, me.Strict())
+		Expect(err).To(Succeed())
+		Expect(b.Credentials.GetId()).To(Equal(ctx.GetId()))
+		Expect(b.Config.GetId()).To(Equal(ctx.ConfigContext().GetId()))
+		Expect(b.Attributes.GetId()).To(Equal(ctx.AttributesContext().GetId()))
+
+		Expect(config.ForContext(bound).GetId()).To(Equal(ctx.ConfigContext().GetId()))
+		Expect(attributes.ForContext(bound).GetId()).To(Equal(ctx.AttributesContext().GetId()))
+	})
+
+	It("derives contexts from the credentials context", func() {
+		bound := ctx.BindTo(context.Background())
+
+		c, err := me.ConfigForContext(bound, me.Strict())
+		Expect(err).To(Succeed())
+		Expect(c.GetId()).To(Equal(ctx.ConfigContext().GetId()))
+
+		a, err := me.AttributesForContext(bound, me.Strict())
+		Expect(err).To(Succeed())
+		Expect(a.GetId()).To(Equal(ctx.AttributesContext().GetId()))
+	})
+
+	It("falls back to default contexts", func() {
+		b, err := me.BundleFromContext(context.Background())
+		Expect(err).To(Succeed())
+		Expect(b.Credentials.GetId()).To(Equal(me.DefaultContext().GetId()))
+
+		a, err := me.AttributesForContext(context.Background())
+		Expect(err).To(Succeed())
+		Expect(a.GetId()).To(Equal(attributes.DefaultContext.GetId()))
+	})
+
+	It("fails in strict mode", func() {
+		_, err := me.BundleFromContext(context.Background(), me.Strict())
+		Expect(err).To(MatchError(`context "credentials.context.mandelsoft.de" not found`))
+
+		_, err = me.ConfigForContext(context.Background(), me.Strict())
+		Expect(err).To(MatchError(ContainSubstring("not found")))
+
+		_, err = me.AttributesForContext(context.Background(), me.Strict())
+		Expect(err).To(MatchError(ContainSubstring("not found")))
+	})
+
+	It("detects inconsistent bindings in strict mode", func() {
+		bound := ctx.BindTo(config.New().BindTo(context.Background()))
+
+		_, err := me.BundleFromContext(bound)
+		Expect(err).To(Succeed())
+		_, err = me.BundleFromContext(bound, me.Strict())
+		Expect(err).To(MatchError(ContainSubstring("does not belong to credentials context")))
+	})
+})
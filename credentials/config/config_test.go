@@ -206,4 +206,65 @@ consumers:
 			Expect(result.Properties()).To(Equal(props))
 		})
 	})
+	Context("retract", func() {
+		var ctx credentials.Context
+
+		consumer := func(host string) credentials.ConsumerIdentity {
+			return credentials.ConsumerIdentity{
+				credentials.ID_TYPE: "mytype",
+				"host":              host,
+			}
+		}
+		creds := func(token string) credentials.CredentialsSpec {
+			return directcreds.NewCredentials(utils.Properties{"token": token})
+		}
+
+		BeforeEach(func() {
+			ctx = credentials.WithConfigs(config.New()).New()
+		})
+
+		It("removes retracted consumers and aliases", func() {
+			cfg1 := localconfig.New()
+			Expect(cfg1.AddConsumer(consumer("first"), creds("first"))).To(Succeed())
+			Expect(cfg1.AddAlias("alias", repospec, direct)).To(Succeed())
+			cfg2 := localconfig.New()
+			Expect(cfg2.AddConsumer(consumer("second"), creds("second"))).To(Succeed())
+
+			h, err := ctx.ConfigContext().ApplyConfigWithHandle(cfg1, "first")
+			Expect(err).To(Succeed())
+			Expect(ctx.ConfigContext().ApplyConfig(cfg2, "second")).To(Succeed())
+			ctx.SetCredentialsForConsumer(consumer("explicit"), creds("explicit"))
+
+			Expect(credentials.CredentialsForConsumer(ctx, consumer("first"), credentials.CompleteMatch)).NotTo(BeNil())
+			_, err = ctx.RepositoryForSpec(aliases.NewRepositorySpec("alias"))
+			Expect(err).To(Succeed())
+
+			ctx.ConfigContext().Retract(config.ByHandle(h))
+
+			Expect(credentials.CredentialsForConsumer(ctx, consumer("first"), credentials.CompleteMatch)).To(BeNil())
+			_, err = ctx.RepositoryForSpec(aliases.NewRepositorySpec("alias"))
+			Expect(err).To(MatchError(ContainSubstring("alias")))
+
+			result, err := credentials.CredentialsForConsumer(ctx, consumer("second"), credentials.CompleteMatch)
+			Expect(err).To(Succeed())
+			Expect(result.Properties()).To(Equal(utils.Properties{"token": "second"}))
+			result, err = credentials.CredentialsForConsumer(ctx, consumer("explicit"), credentials.CompleteMatch)
+			Expect(err).To(Succeed())
+			Expect(result.Properties()).To(Equal(utils.Properties{"token": "explicit"}))
+		})
+
+		It("keeps consumers explicitly overwritten", func() {
+			cfg := localconfig.New()
+			Expect(cfg.AddConsumer(consumer("first"), creds("config"))).To(Succeed())
+
+			h, err := ctx.ConfigContext().ApplyConfigWithHandle(cfg, "first")
+			Expect(err).To(Succeed())
+			ctx.SetCredentialsForConsumer(consumer("first"), creds("explicit"))
+
+			ctx.ConfigContext().Retract(config.ByHandle(h))
+			result, err := credentials.CredentialsForConsumer(ctx, consumer("first"), credentials.CompleteMatch)
+			Expect(err).To(Succeed())
+			Expect(result.Properties()).To(Equal(utils.Properties{"token": "explicit"}))
+		})
+	})
 })
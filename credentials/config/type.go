@@ -6,6 +6,7 @@ import (
 	"github.com/mandelsoft/goutils/errors"
 
 	cfgcpi "github.com/mandelsoft/ctxmgmt/config/cpi"
+	"github.com/mandelsoft/ctxmgmt/config/diff"
 	"github.com/mandelsoft/ctxmgmt/credentials/cpi"
 	"github.com/mandelsoft/ctxmgmt/utils/runtime"
 )
@@ -18,6 +19,9 @@ const (
 func init() {
 	cfgcpi.RegisterConfigType(cfgcpi.NewConfigType[*Config](ConfigType, usage))
 	cfgcpi.RegisterConfigType(cfgcpi.NewConfigType[*Config](ConfigTypeV1, usage))
+
+	// credential properties must never be shown in config diffs.
+	diff.RegisterRedactor(ConfigType, diff.RedactBelow("properties"))
 }
 
 // Config describes a configuration for the config context.
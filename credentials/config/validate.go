@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/mandelsoft/goutils/maputils"
+
+	cfgcpi "github.com/mandelsoft/ctxmgmt/config/cpi"
+	"github.com/mandelsoft/ctxmgmt/config/validation"
+	"github.com/mandelsoft/ctxmgmt/credentials/cpi"
+)
+
+func init() {
+	validation.RegisterValidator(ConfigType, validation.ValidatorFunction(validate))
+}
+
+// validate checks the repository types and consumer identity
+// types against the types known by the credentials context.
+// Identity types are not required to have a dedicated identity
+// matcher, so unknown identity types are only reported as warning,
+// if they are similar to a known one.
+func validate(ctx cfgcpi.ContextProvider, cfg cfgcpi.Config) []validation.Finding {
+	c, ok := cfg.(*Config)
+	if !ok {
+		return nil
+	}
+	crctx := cpi.DefaultContext
+	if p, ok := ctx.(cpi.ContextProvider); ok {
+		crctx = p.CredentialsContext()
+	}
+
+	var findings []validation.Finding
+	repotypes := crctx.RepositoryTypes()
+	checkRepo := func(element string, spec *cpi.GenericRepositorySpec) {
+		typ := spec.GetType()
+		if _, ok := repotypes.KnownTypes()[typ]; !ok {
+			findings = append(findings, validation.Unknown(validation.ERROR, element, "repository type", typ, repotypes.KnownTypeNames()))
+		}
+	}
+	for i, r := range c.Repositories {
+		checkRepo(fmt.Sprintf("repositories[%d].repository.type", i), &r.Repository)
+	}
+	for _, n := range maputils.OrderedKeys(c.Aliases) {
+		r := c.Aliases[n]
+		checkRepo(fmt.Sprintf("aliases.%s.repository.type", n), &r.Repository)
+	}
+
+	matchers := crctx.ConsumerIdentityMatchers()
+	var types []string
+	for _, m := range matchers.List() {
+		types = append(types, m.Type)
+	}
+	for i, e := range c.Consumers {
+		typ := e.Identity.Type()
+		if typ != "" && matchers.Get(typ) == nil {
+			f := validation.Unknown(validation.WARNING, fmt.Sprintf("consumers[%d].identity.type", i), "identity matcher type", typ, types)
+			if len(f.Suggestions) > 0 {
+				findings = append(findings, f)
+			}
+		}
+	}
+	return findings
+}
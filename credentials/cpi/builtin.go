@@ -1,7 +1,10 @@
 package cpi
 
 import (
+	"reflect"
+
 	"github.com/mandelsoft/ctxmgmt/credentials/internal"
+	"github.com/mandelsoft/ctxmgmt/utils/runtime"
 )
 
 const AliasRepositoryType = internal.AliasRepositoryType
@@ -22,6 +25,10 @@ func NewAliasRegistry(t RepositoryType, setter internal.SetAliasFunction) Reposi
 	}
 }
 
+func (a *aliasRegistry) SerializedType() reflect.Type {
+	return runtime.SerializedType(a.RepositoryType)
+}
+
 func (a *aliasRegistry) SetAlias(ctx Context, name string, spec RepositorySpec, creds CredentialsSource) error {
 	return a.setter(ctx, name, spec, creds)
 }
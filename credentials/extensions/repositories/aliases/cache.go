@@ -1,6 +1,7 @@
 package aliases
 
 import (
+	"fmt"
 	"sync"
 
 	"github.com/mandelsoft/ctxmgmt"
@@ -9,20 +10,66 @@ import (
 
 const ATTR_REPOS = "github.com/mandelsoft/ctxmgmt/credentials/extensions/repositories/aliases"
 
+// Repositories holds the aliases defined for a context.
+// For an overlay context it refers to the aliases of the parent
+// context, which are copied on first use, to keep the repository
+// cache local to the overlay.
 type Repositories struct {
 	sync.RWMutex
-	repos map[string]*Repository
+	owner  ctxmgmt.ContextIdentity
+	parent *Repositories
+	repos  map[string]*Repository
 }
 
-func newRepositories(ctxmgmt.Context) interface{} {
+func newRepositories(ctx ctxmgmt.Context) interface{} {
 	return &Repositories{
+		owner: ctx.GetId(),
 		repos: map[string]*Repository{},
 	}
 }
 
+// repositories provides the aliases owned by the given context.
+// If the context inherits the aliases of a parent context, a
+// local alias set layered on the inherited one is created.
+func repositories(ctx cpi.Context) (*Repositories, error) {
+	r := ctx.GetAttributes().GetOrCreateAttribute(ATTR_REPOS, newRepositories)
+	repos, ok := r.(*Repositories)
+	if !ok {
+		return nil, fmt.Errorf("failed to assert type %T to Repositories", r)
+	}
+	if repos.owner != ctx.GetId() {
+		local := newRepositories(ctx).(*Repositories)
+		local.parent = repos
+		err := ctx.GetAttributes().SetAttribute(ATTR_REPOS, local)
+		if err != nil {
+			return nil, err
+		}
+		repos = local
+	}
+	return repos, nil
+}
+
 func (c *Repositories) GetRepository(name string) *Repository {
 	c.RLock()
-	defer c.RUnlock()
+	r := c.repos[name]
+	c.RUnlock()
+	if r != nil || c.parent == nil {
+		return r
+	}
+
+	r = c.parent.GetRepository(name)
+	if r == nil {
+		return nil
+	}
+	c.Lock()
+	defer c.Unlock()
+	if c.repos[name] == nil {
+		c.repos[name] = &Repository{
+			name:  r.name,
+			spec:  r.spec,
+			creds: r.creds,
+		}
+	}
 	return c.repos[name]
 }
 
@@ -35,3 +82,9 @@ func (c *Repositories) Set(name string, spec cpi.RepositorySpec, creds cpi.Crede
 		creds: creds,
 	}
 }
+
+func (c *Repositories) Delete(name string) {
+	c.Lock()
+	defer c.Unlock()
+	delete(c.repos, name)
+}
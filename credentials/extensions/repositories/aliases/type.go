@@ -1,8 +1,6 @@
 package aliases
 
 import (
-	"fmt"
-
 	"github.com/mandelsoft/ctxmgmt/credentials/cpi"
 	"github.com/mandelsoft/ctxmgmt/utils/runtime"
 )
@@ -18,12 +16,15 @@ func init() {
 }
 
 func setAlias(ctx cpi.Context, name string, spec cpi.RepositorySpec, creds cpi.CredentialsSource) error {
-	r := ctx.GetAttributes().GetOrCreateAttribute(ATTR_REPOS, newRepositories)
-	repos, ok := r.(*Repositories)
-	if !ok {
-		return fmt.Errorf("failed to assert type %T to Repositories", r)
+	repos, err := repositories(ctx)
+	if err != nil {
+		return err
+	}
+	if spec == nil {
+		repos.Delete(name)
+	} else {
+		repos.Set(name, spec, creds)
 	}
-	repos.Set(name, spec, creds)
 	return nil
 }
 
@@ -46,10 +47,9 @@ func (a *RepositorySpec) GetType() string {
 }
 
 func (a *RepositorySpec) Repository(ctx cpi.Context, creds cpi.Credentials) (cpi.Repository, error) {
-	r := ctx.GetAttributes().GetOrCreateAttribute(ATTR_REPOS, newRepositories)
-	repos, ok := r.(*Repositories)
-	if !ok {
-		return nil, fmt.Errorf("failed to assert type %T to Repositories", r)
+	repos, err := repositories(ctx)
+	if err != nil {
+		return nil, err
 	}
 	alias := repos.GetRepository(a.Alias)
 	if alias == nil {
@@ -35,6 +35,9 @@ func (r *Repositories) GetRepository(ctx cpi.Context, name string, data []byte,
 		if err == nil {
 			r.repos[name] = repo
 		}
+	} else {
+		// re-read a modified config file
+		err = repo.Read(false)
 	}
 	return repo, err
 }
@@ -6,6 +6,7 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/docker/cli/cli/config"
 	"github.com/docker/cli/cli/config/configfile"
@@ -26,6 +27,7 @@ type Repository struct {
 	path      string
 	data      []byte
 	config    *configfile.ConfigFile
+	modtime   time.Time
 }
 
 func NewRepository(ctx cpi.Context, path string, data []byte, propagate bool) (*Repository, error) {
@@ -78,7 +80,7 @@ func (r *Repository) WriteCredentials(name string, creds cpi.Credentials) (cpi.C
 func (r *Repository) Read(force bool) error {
 	r.lock.Lock()
 	defer r.lock.Unlock()
-	if !force && r.config != nil {
+	if !force && r.config != nil && !r.modified() {
 		return nil
 	}
 	var (
@@ -91,10 +93,15 @@ func (r *Repository) Read(force bool) error {
 		if err != nil {
 			return errors.Wrapf(err, "cannot resolve path %q", r.path)
 		}
+		fi, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("failed to read file '%s': %w", path, err)
+		}
 		data, err = os.ReadFile(path)
 		if err != nil {
 			return fmt.Errorf("failed to read file '%s': %w", path, err)
 		}
+		r.modtime = fi.ModTime()
 		id = cpi.ProviderIdentity(PROVIDER + "/" + path)
 	} else if len(r.data) > 0 {
 		data = r.data
@@ -112,6 +119,20 @@ func (r *Repository) Read(force bool) error {
 	return nil
 }
 
+// modified checks whether the config file has been modified
+// since it has been read.
+func (r *Repository) modified() bool {
+	if r.path == "" {
+		return false
+	}
+	path, err := ioutils.ResolvePath(r.path)
+	if err != nil {
+		return false
+	}
+	fi, err := os.Stat(path)
+	return err == nil && !fi.ModTime().Equal(r.modtime)
+}
+
 func newCredentials(auth types.AuthConfig) cpi.Credentials {
 	props := utils.Properties{
 		cpi.ATTR_USERNAME: norm(auth.Username),
@@ -67,6 +67,14 @@ func (a *RepositorySpec) GetType() string {
 	return Type
 }
 
+// GetReferencedFiles provides the docker config file used by the repository.
+func (a *RepositorySpec) GetReferencedFiles() []string {
+	if a.DockerConfigFile == "" {
+		return nil
+	}
+	return []string{a.DockerConfigFile}
+}
+
 func (a *RepositorySpec) Repository(ctx cpi.Context, creds cpi.Credentials) (cpi.Repository, error) {
 	r := ctx.GetAttributes().GetOrCreateAttribute(ATTR_REPOS, newRepositories)
 	repos, ok := r.(*Repositories)
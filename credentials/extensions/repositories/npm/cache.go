@@ -28,6 +28,9 @@ func (r *Cache) GetRepository(ctx cpi.Context, name string, prop bool) (*Reposit
 		if err == nil {
 			r.repos[name] = repo
 		}
+	} else {
+		// re-read a modified npmrc file
+		err = repo.Read(false)
 	}
 	return repo, err
 }
@@ -2,9 +2,12 @@ package npm
 
 import (
 	"fmt"
+	"os"
+	"time"
 
 	"github.com/mandelsoft/goutils/errors"
 	"github.com/mandelsoft/goutils/general"
+	"github.com/mandelsoft/goutils/ioutils"
 
 	"github.com/mandelsoft/ctxmgmt/credentials/cpi"
 	npmCredentials "github.com/mandelsoft/ctxmgmt/credentials/identity/npm"
@@ -18,6 +21,7 @@ type Repository struct {
 	path      string
 	propagate bool
 	npmrc     npmConfig
+	modtime   time.Time
 }
 
 func NewRepository(ctx cpi.Context, path string, prop ...bool) (*Repository, error) {
@@ -60,7 +64,7 @@ func (r *Repository) WriteCredentials(_ string, _ cpi.Credentials) (cpi.Credenti
 }
 
 func (r *Repository) Read(force bool) error {
-	if !force && r.npmrc != nil {
+	if !force && r.npmrc != nil && !r.modified() {
 		return nil
 	}
 
@@ -71,6 +75,9 @@ func (r *Repository) Read(force bool) error {
 	if err != nil {
 		return fmt.Errorf("failed to load npmrc: %w", err)
 	}
+	if fi, err := os.Stat(path); err == nil {
+		r.modtime = fi.ModTime()
+	}
 	id := cpi.ProviderIdentity(PROVIDER + "/" + path)
 
 	if r.propagate {
@@ -80,6 +87,17 @@ func (r *Repository) Read(force bool) error {
 	return nil
 }
 
+// modified checks whether the npmrc file has been modified
+// since it has been read.
+func (r *Repository) modified() bool {
+	path, err := ioutils.ResolvePath(r.path)
+	if err != nil {
+		return false
+	}
+	fi, err := os.Stat(path)
+	return err == nil && !fi.ModTime().Equal(r.modtime)
+}
+
 func newCredentials(token string) cpi.Credentials {
 	props := utils.Properties{
 		npmCredentials.ATTR_TOKEN: token,
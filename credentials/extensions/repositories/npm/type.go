@@ -53,6 +53,14 @@ func (rs *RepositorySpec) GetType() string {
 	return Type
 }
 
+// GetReferencedFiles provides the npmrc file used by the repository.
+func (rs *RepositorySpec) GetReferencedFiles() []string {
+	if rs.NpmrcFile == "" {
+		return nil
+	}
+	return []string{rs.NpmrcFile}
+}
+
 func (rs *RepositorySpec) Repository(ctx cpi.Context, _ cpi.Credentials) (cpi.Repository, error) {
 	r := ctx.GetAttributes().GetOrCreateAttribute(".npmrc", createCache)
 	cache, ok := r.(*Cache)
@@ -0,0 +1,56 @@
+package configkey
+
+import (
+	"github.com/mandelsoft/ctxmgmt/credentials/cpi"
+	"github.com/mandelsoft/ctxmgmt/utils/encryption"
+	"github.com/mandelsoft/ctxmgmt/utils/listformat"
+)
+
+const (
+	// CONSUMER_TYPE is the consumer type for keys used to
+	// decrypt encrypted values in config files.
+	CONSUMER_TYPE = "ConfigEncryption"
+
+	// ID_KEYID is the id of the requested key.
+	ID_KEYID = "keyId"
+
+	// ATTR_KEY is the base64 encoded key.
+	ATTR_KEY = cpi.ATTR_KEY
+)
+
+func init() {
+	attrs := listformat.FormatListElements("", listformat.StringElementDescriptionList{
+		ATTR_KEY, "the base64 encoded key",
+	})
+
+	cpi.RegisterStandardIdentity(CONSUMER_TYPE, IdentityMatcher, `Config encryption keys
+
+It matches the <code>`+CONSUMER_TYPE+`</code> consumer type and the optional
+key id (<code>`+ID_KEYID+`</code>) of the key used to encrypt values
+in config files. Consumers without a key id match any key id.`,
+		attrs)
+}
+
+func IdentityMatcher(pattern, cur, id cpi.ConsumerIdentity) bool {
+	return cpi.PartialMatch(pattern, cur, id)
+}
+
+// GetConsumerId provides the consumer identity for a key id.
+func GetConsumerId(keyid string) cpi.ConsumerIdentity {
+	return cpi.NewConsumerIdentity(CONSUMER_TYPE, ID_KEYID, keyid)
+}
+
+// GetKey provides the key with the given id configured for the
+// credentials context. If no key is configured, or the configured
+// key has another id, nil is returned.
+func GetKey(ctx cpi.ContextProvider, keyid string) (*encryption.Key, error) {
+	creds, err := cpi.CredentialsForConsumer(ctx.CredentialsContext(), GetConsumerId(keyid), IdentityMatcher)
+	if err != nil || creds == nil || !creds.ExistsProperty(ATTR_KEY) {
+		return nil, err
+	}
+	key, err := encryption.ParseKey(creds.GetProperty(ATTR_KEY))
+	if err != nil || key.ID() != keyid {
+		return nil, err
+	}
+	return key, nil
+}
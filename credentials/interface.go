@@ -17,6 +17,12 @@ const (
 
 const CONTEXT_TYPE = internal.CONTEXT_TYPE
 
+const (
+	ELEMENT_CONFIG          = internal.ELEMENT_CONFIG
+	ELEMENT_REPOSITORYTYPES = internal.ELEMENT_REPOSITORYTYPES
+	ELEMENT_MATCHERS        = internal.ELEMENT_MATCHERS
+)
+
 const AliasRepositoryType = internal.AliasRepositoryType
 
 type (
@@ -41,6 +47,7 @@ type (
 	IdentityMatcherInfo      = internal.IdentityMatcherInfo
 	IdentityMatcherInfos     = internal.IdentityMatcherInfos
 	IdentityMatcherRegistry  = internal.IdentityMatcherRegistry
+	ExplicitConsumer         = internal.ExplicitConsumer
 )
 
 type (
@@ -49,6 +56,11 @@ type (
 	DirectCredentials      = internal.DirectCredentials
 )
 
+type (
+	Description         = internal.Description
+	ConsumerDescription = internal.ConsumerDescription
+)
+
 func DefaultContext() internal.Context {
 	return internal.DefaultContext
 }
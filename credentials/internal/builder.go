@@ -7,11 +7,32 @@ import (
 	"github.com/mandelsoft/ctxmgmt/config"
 )
 
+// Builder elements, which can be created with a dedicated
+// builder mode (see Builder.WithElementMode).
+const (
+	ELEMENT_CONFIG          = "config"
+	ELEMENT_REPOSITORYTYPES = "repositorytypes"
+	ELEMENT_MATCHERS        = "matchers"
+)
+
+var elements = []string{ELEMENT_CONFIG, ELEMENT_REPOSITORYTYPES, ELEMENT_MATCHERS}
+
+var conflicts = []ctxmgmt.ElementConflict{
+	{
+		Element:    ELEMENT_CONFIG,
+		Mode:       ctxmgmt.MODE_SHARED,
+		Other:      ELEMENT_REPOSITORYTYPES,
+		OtherModes: []ctxmgmt.BuilderMode{ctxmgmt.MODE_INITIAL},
+		Reason:     "the shared config may describe repositories of unknown types",
+	},
+}
+
 type Builder struct {
 	ctx        context.Context
 	config     config.Context
 	reposcheme RepositoryTypeScheme
 	matchers   IdentityMatcherRegistry
+	modes      ctxmgmt.ElementModes
 }
 
 func (b *Builder) getContext() context.Context {
@@ -41,24 +62,68 @@ func (b Builder) WithStandardConumerMatchers(matchers IdentityMatcherRegistry) B
 	return b
 }
 
+// WithElementMode sets a builder mode for a dedicated builder element
+// overriding the general mode given for the context creation.
+// The mode for the config element is used to create a new config context.
+// The identity matchers are shared with the standard matchers by default,
+// independent of the general mode.
+func (b Builder) WithElementMode(element string, mode ctxmgmt.BuilderMode) Builder {
+	b.modes = b.modes.With(element, mode)
+	return b
+}
+
+// Validate validates the builder settings for the given
+// general builder mode.
+func (b Builder) Validate(m ...ctxmgmt.BuilderMode) error {
+	var explicit []string
+	if b.config != nil {
+		explicit = append(explicit, ELEMENT_CONFIG)
+	}
+	if b.reposcheme != nil {
+		explicit = append(explicit, ELEMENT_REPOSITORYTYPES)
+	}
+	if b.matchers != nil {
+		explicit = append(explicit, ELEMENT_MATCHERS)
+	}
+	return b.modes.Validate(ctxmgmt.Mode(m...), elements, conflicts, explicit...)
+}
+
 func (b Builder) Bound() (Context, context.Context) {
 	c := b.New()
 	return c, context.WithValue(b.getContext(), key, c)
 }
 
+// New creates a new credentials context.
+// It panics for invalid builder settings, use Build
+// to handle such errors.
 func (b Builder) New(m ...ctxmgmt.BuilderMode) Context {
+	c, err := b.Build(m...)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Build creates a new credentials context.
+func (b Builder) Build(m ...ctxmgmt.BuilderMode) (Context, error) {
+	err := b.Validate(m...)
+	if err != nil {
+		return nil, err
+	}
+
 	mode := ctxmgmt.Mode(m...)
 	ctx := b.getContext()
 
 	if b.config == nil {
 		var ok bool
+		cmode := b.modes.Get(ELEMENT_CONFIG, mode)
 		b.config, ok = config.DefinedForContext(ctx)
-		if !ok && mode != ctxmgmt.MODE_SHARED {
-			b.config = config.New(mode)
+		if !ok && cmode != ctxmgmt.MODE_SHARED {
+			b.config = config.New(cmode)
 		}
 	}
 	if b.reposcheme == nil {
-		switch mode {
+		switch b.modes.Get(ELEMENT_REPOSITORYTYPES, mode) {
 		case ctxmgmt.MODE_INITIAL:
 			b.reposcheme = NewRepositoryTypeScheme(nil)
 		case ctxmgmt.MODE_CONFIGURED:
@@ -73,7 +138,17 @@ func (b Builder) New(m ...ctxmgmt.BuilderMode) Context {
 		}
 	}
 	if b.matchers == nil {
-		b.matchers = StandardIdentityMatchers
+		switch b.modes.Get(ELEMENT_MATCHERS, ctxmgmt.MODE_SHARED) {
+		case ctxmgmt.MODE_INITIAL:
+			b.matchers = NewMatcherRegistry()
+		case ctxmgmt.MODE_CONFIGURED, ctxmgmt.MODE_EXTENDED:
+			b.matchers = NewMatcherRegistry()
+			for _, i := range StandardIdentityMatchers.List() {
+				b.matchers.Register(i.Type, i.Matcher, i.Description, i.CredentialAttributes)
+			}
+		default:
+			b.matchers = StandardIdentityMatchers
+		}
 	}
-	return ctxmgmt.SetupContext(mode, newContext(b.config, b.reposcheme, b.matchers, b.config))
+	return ctxmgmt.SetupContext(mode, newContext(b.config, b.reposcheme, b.matchers, b.config)), nil
 }
@@ -56,4 +56,51 @@ var _ = Describe("builder test", func() {
 		Expect(ctx.ConfigContext()).NotTo(BeIdenticalTo(config.DefaultContext()))
 		Expect(len(ctx.ConfigContext().ConfigTypes().KnownTypeNames())).To(Equal(0))
 	})
+
+	Context("element modes", func() {
+		It("uses a dedicated mode for the config context", func() {
+			ctx := local.Builder{}.
+				WithElementMode(local.ELEMENT_CONFIG, ctxmgmt.MODE_SHARED).
+				WithElementMode(local.ELEMENT_REPOSITORYTYPES, ctxmgmt.MODE_CONFIGURED).
+				New(ctxmgmt.MODE_INITIAL)
+
+			Expect(ctx.ConfigContext().GetId()).To(Equal(config.DefaultContext().GetId()))
+			Expect(ctx.RepositoryTypes()).NotTo(BeIdenticalTo(local.DefaultRepositoryTypeScheme))
+			Expect(ctx.RepositoryTypes().KnownTypeNames()).To(Equal(local.DefaultRepositoryTypeScheme.KnownTypeNames()))
+		})
+
+		It("rejects incompatible element modes", func() {
+			_, err := local.Builder{}.WithElementMode(local.ELEMENT_CONFIG, ctxmgmt.MODE_SHARED).Build(ctxmgmt.MODE_INITIAL)
+			Expect(err).To(MatchError(ContainSubstring(`mode shared for element "config" incompatible with mode initial for element "repositorytypes"`)))
+
+			_, err = local.Builder{}.WithElementMode(local.ELEMENT_REPOSITORYTYPES, ctxmgmt.MODE_INITIAL).Build(ctxmgmt.MODE_SHARED)
+			Expect(err).To(MatchError(ContainSubstring(`mode shared for element "config" incompatible with mode initial for element "repositorytypes"`)))
+
+			_, err = local.Builder{}.
+				WithConfig(config.DefaultContext()).
+				WithElementMode(local.ELEMENT_REPOSITORYTYPES, ctxmgmt.MODE_INITIAL).
+				Build(ctxmgmt.MODE_SHARED)
+			Expect(err).To(Succeed())
+		})
+
+		It("creates separate matchers", func() {
+			ctx := local.Builder{}.New(ctxmgmt.MODE_INITIAL)
+			Expect(ctx.ConsumerIdentityMatchers()).To(BeIdenticalTo(local.StandardIdentityMatchers))
+
+			ctx = local.Builder{}.WithElementMode(local.ELEMENT_MATCHERS, ctxmgmt.MODE_CONFIGURED).New(ctxmgmt.MODE_SHARED)
+			Expect(ctx.ConsumerIdentityMatchers()).NotTo(BeIdenticalTo(local.StandardIdentityMatchers))
+			Expect(ctx.ConsumerIdentityMatchers().List()).To(HaveLen(len(local.StandardIdentityMatchers.List())))
+
+			ctx = local.Builder{}.WithElementMode(local.ELEMENT_MATCHERS, ctxmgmt.MODE_INITIAL).New(ctxmgmt.MODE_SHARED)
+			Expect(ctx.ConsumerIdentityMatchers().List()).To(BeEmpty())
+		})
+
+		It("rejects modes for explicitly set elements", func() {
+			_, err := local.Builder{}.
+				WithConfig(config.DefaultContext()).
+				WithElementMode(local.ELEMENT_CONFIG, ctxmgmt.MODE_INITIAL).
+				Build()
+			Expect(err).To(MatchError(ContainSubstring(`mode initial given for explicitly set element "config"`)))
+		})
+	})
 })
@@ -4,6 +4,8 @@ const AliasRepositoryType = "Alias"
 
 type SetAliasFunction func(ctx Context, name string, spec RepositorySpec, creds CredentialsSource) error
 
+// AliasRegistry is implemented by the repository type for aliases.
+// Setting an alias with a nil specification removes the alias.
 type AliasRegistry interface {
 	SetAlias(ctx Context, name string, spec RepositorySpec, creds CredentialsSource) error
 }
@@ -107,6 +107,13 @@ func (c *_consumers) Match(ectx EvaluationContext, pattern ConsumerIdentity, cur
 	return nil, cur
 }
 
+// ExplicitConsumer describes credentials explicitly set
+// for a consumer identity.
+type ExplicitConsumer struct {
+	Identity    ConsumerIdentity
+	Credentials CredentialsSource
+}
+
 type _consumer struct {
 	providerId  ProviderIdentity
 	identity    ConsumerIdentity
@@ -153,6 +160,8 @@ type consumerProviderRegistry struct {
 	explicit  *_consumers
 	providers map[ProviderIdentity]ConsumerProvider
 	ordered   []ConsumerProvider
+	// parent is used as fallback for an overlay context.
+	parent ConsumerProvider
 }
 
 func newConsumerProviderRegistry() *consumerProviderRegistry {
@@ -212,6 +221,9 @@ func (p *consumerProviderRegistry) Get(id ConsumerIdentity) (CredentialsSource,
 			return credsrc, ok
 		}
 	}
+	if p.parent != nil {
+		return p.parent.Get(id)
+	}
 	return nil, false
 }
 
@@ -266,6 +278,9 @@ func (p *consumerProviderRegistry) Match(ectx EvaluationContext, pattern Consume
 			credsrc = f
 		}
 	}
+	if credsrc == nil && p.parent != nil {
+		credsrc, cur = p.catchedMatch(ectx, p.parent, pattern, cur, m)
+	}
 	// If this is the case, we are in a situation where we have excluded all providers (since they are all in the stack).
 	// If we would simply return with no credentials, the follow-up coding would assume, that it should query the
 	// credential repository without any credentials, since none have been found.
@@ -290,3 +305,25 @@ func (p *consumerProviderRegistry) Set(id ConsumerIdentity, pid ProviderIdentity
 
 	p.explicit.Set(id, pid, creds)
 }
+
+// Remove removes the credentials explicitly set for a consumer.
+func (p *consumerProviderRegistry) Remove(id ConsumerIdentity) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	delete(p.explicit.data, string(id.Key()))
+}
+
+func (p *consumerProviderRegistry) explicitConsumers() []ExplicitConsumer {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	var list []ExplicitConsumer
+	for _, k := range maputils.OrderedKeys(p.explicit.data) {
+		e := p.explicit.data[k]
+		if e.providerId == "" {
+			list = append(list, ExplicitConsumer{Identity: e.identity, Credentials: e.credentials})
+		}
+	}
+	return list
+}
@@ -21,6 +21,19 @@ import (
 // CONTEXT_TYPE is the global type for a credential context.
 const CONTEXT_TYPE = "credentials" + ctxmgmt.CONTEXT_SUFFIX
 
+func init() {
+	ctxmgmt.RegisterContextType(ctxmgmt.NewContextType(CONTEXT_TYPE,
+		"context managing credentials for consumers and credential repositories",
+		func(mode ...ctxmgmt.BuilderMode) ctxmgmt.Context {
+			return Builder{}.New(mode...)
+		},
+		func(ctx context.Context) ctxmgmt.Context {
+			return FromContext(ctx)
+		},
+		config.CONTEXT_TYPE,
+	))
+}
+
 // ProviderIdentity is used to uniquely identify a provider
 // for a configured consumer id. If non-empty it
 // must start with a DNSname identifying the origin of the
@@ -88,11 +101,18 @@ type Context interface {
 	GetCredentialsForConsumer(ConsumerIdentity, ...IdentityMatcher) (CredentialsSource, error)
 	getCredentialsForConsumer(EvaluationContext, ConsumerIdentity, ...IdentityMatcher) (CredentialsSource, error)
 	SetCredentialsForConsumer(identity ConsumerIdentity, creds CredentialsSource)
+	// GetExplicitConsumers provides the consumer credentials
+	// set with SetCredentialsForConsumer.
+	GetExplicitConsumers() []ExplicitConsumer
 	SetCredentialsForConsumerWithProvider(pid ProviderIdentity, identity ConsumerIdentity, creds CredentialsSource)
 
 	SetAlias(name string, spec RepositorySpec, creds ...CredentialsSource) error
 
 	ConsumerIdentityMatchers() IdentityMatcherRegistry
+
+	// Parent provides the parent context of an overlay
+	// context (see NewOverlay) or nil for a regular context.
+	Parent() Context
 }
 
 var key = reflect.TypeOf(_context{})
@@ -132,6 +152,10 @@ type _context struct {
 	knownRepositoryTypes     RepositoryTypeScheme
 	consumerIdentityMatchers IdentityMatcherRegistry
 	consumerProviders        *consumerProviderRegistry
+	configured               configured
+
+	// parent is the parent context of an overlay context.
+	parent Context
 }
 
 var (
@@ -281,11 +305,17 @@ func (c *_context) defaultMatcher(id ConsumerIdentity, matchers ...IdentityMatch
 func (c *_context) SetCredentialsForConsumer(identity ConsumerIdentity, creds CredentialsSource) {
 	c.Update()
 	c.consumerProviders.Set(identity, "", creds)
+	c.configured.setConsumer(identity, c.InUpdate())
+}
+
+func (c *_context) GetExplicitConsumers() []ExplicitConsumer {
+	return c.consumerProviders.explicitConsumers()
 }
 
 func (c *_context) SetCredentialsForConsumerWithProvider(pid ProviderIdentity, identity ConsumerIdentity, creds CredentialsSource) {
 	c.Update()
 	c.consumerProviders.Set(identity, pid, creds)
+	c.configured.setConsumer(identity, c.InUpdate())
 }
 
 func (c *_context) ConsumerIdentityMatchers() IdentityMatcherRegistry {
@@ -299,7 +329,11 @@ func (c *_context) SetAlias(name string, spec RepositorySpec, creds ...Credentia
 		return errors.ErrNotSupported("aliases")
 	}
 	if a, ok := t.(AliasRegistry); ok {
-		return a.SetAlias(c, name, spec, CredentialsChain(creds))
+		err := a.SetAlias(c, name, spec, CredentialsChain(creds))
+		if err == nil {
+			c.configured.setAlias(name, c.InUpdate())
+		}
+		return err
 	}
 	return errors.ErrNotImplemented("interface", "AliasRegistry", reflect.TypeOf(t).String())
 }
@@ -0,0 +1,63 @@
+package internal
+
+import (
+	"sort"
+
+	"github.com/mandelsoft/goutils/maputils"
+
+	"github.com/mandelsoft/ctxmgmt"
+)
+
+// Description describes the credentials specific state
+// of a credentials context.
+type Description struct {
+	ConsumerProviders []string              `json:"consumerProviders,omitempty"`
+	Consumers         []ConsumerDescription `json:"consumers,omitempty"`
+	RepositoryTypes   []string              `json:"repositoryTypes,omitempty"`
+	IdentityMatchers  []string              `json:"identityMatchers,omitempty"`
+}
+
+// ConsumerDescription describes an explicitly configured
+// consumer identity.
+type ConsumerDescription struct {
+	Identity ConsumerIdentity `json:"identity"`
+	Provider ProviderIdentity `json:"provider,omitempty"`
+}
+
+var _ ctxmgmt.DescriptionProvider = (*_context)(nil)
+
+func (c *_context) DescribeSection() (string, interface{}) {
+	return "credentials", c.Describe()
+}
+
+// Describe provides a description of the credentials specific state.
+func (c *_context) Describe() *Description {
+	d := &Description{
+		RepositoryTypes: c.knownRepositoryTypes.KnownTypeNames(),
+	}
+	for _, i := range c.consumerIdentityMatchers.List() {
+		d.IdentityMatchers = append(d.IdentityMatchers, i.Type)
+	}
+	sort.Strings(d.IdentityMatchers)
+	d.ConsumerProviders, d.Consumers = c.consumerProviders.describe()
+	return d
+}
+
+func (p *consumerProviderRegistry) describe() ([]string, []ConsumerDescription) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	var providers []string
+	for _, id := range maputils.OrderedKeys(p.providers) {
+		providers = append(providers, string(id))
+	}
+	var consumers []ConsumerDescription
+	for _, k := range maputils.OrderedKeys(p.explicit.data) {
+		e := p.explicit.data[k]
+		consumers = append(consumers, ConsumerDescription{
+			Identity: e.identity,
+			Provider: e.providerId,
+		})
+	}
+	return providers, consumers
+}
@@ -0,0 +1,67 @@
+package internal
+
+import (
+	"github.com/mandelsoft/ctxmgmt"
+	"github.com/mandelsoft/ctxmgmt/config"
+	cfgcpi "github.com/mandelsoft/ctxmgmt/config/cpi"
+)
+
+// NewOverlay creates a copy-on-write overlay context for a parent
+// credentials context. It uses an overlay (see config.NewOverlay) of the
+// config context of the parent and shares the type registries and
+// identity matchers with the parent. The attributes of the overlay
+// inherit the attributes of the parent, so aliases defined for the
+// parent are visible in the overlay.
+//
+// Credential requests not satisfied by consumer credentials or
+// providers registered for the overlay fall through to the parent.
+// Consumer credentials, providers, aliases and config objects set
+// for the overlay are kept local and are never visible in the parent.
+// The parent does not refer to the overlay, so it can be discarded
+// without affecting the parent.
+func NewOverlay(parent Context) Context {
+	pc := ctxmgmt.PersistentContextRef(parent)
+	p := ctxmgmt.InternalContextRef(pc).(*_context)
+
+	configctx := config.NewOverlay(p.ConfigContext())
+	c := &_context{
+		sharedattributes:         p.sharedattributes,
+		knownRepositoryTypes:     p.knownRepositoryTypes,
+		consumerIdentityMatchers: p.consumerIdentityMatchers,
+		consumerProviders:        newConsumerProviderRegistry(),
+		parent:                   pc,
+	}
+	c.consumerProviders.parent = &parentProvider{ctx: p}
+	c._InternalContext = ctxmgmt.NewContextBase(c, CONTEXT_TYPE, key, p.GetAttributes(), configctx)
+	c.updater = cfgcpi.NewUpdaterForFactory(configctx, c.CredentialsContext)
+	return newView(c, true)
+}
+
+// Parent provides the parent context of an overlay context
+// or nil for a regular context.
+func (c *_context) Parent() Context {
+	return c.parent
+}
+
+// parentProvider provides read access to the consumer
+// credentials and providers of a parent context.
+type parentProvider struct {
+	ctx *_context
+}
+
+var _ ConsumerProvider = (*parentProvider)(nil)
+
+// Unregister does nothing, providers of the parent
+// cannot be unregistered via an overlay.
+func (p *parentProvider) Unregister(id ProviderIdentity) {
+}
+
+func (p *parentProvider) Get(id ConsumerIdentity) (CredentialsSource, bool) {
+	p.ctx.Update()
+	return p.ctx.consumerProviders.Get(id)
+}
+
+func (p *parentProvider) Match(ectx EvaluationContext, id ConsumerIdentity, cur ConsumerIdentity, matcher IdentityMatcher) (CredentialsSource, ConsumerIdentity) {
+	p.ctx.Update()
+	return p.ctx.consumerProviders.Match(ectx, id, cur, matcher)
+}
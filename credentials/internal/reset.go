@@ -0,0 +1,80 @@
+package internal
+
+import (
+	"sync"
+
+	"github.com/mandelsoft/goutils/errors"
+	"github.com/mandelsoft/goutils/maputils"
+
+	"github.com/mandelsoft/ctxmgmt"
+)
+
+// configured keeps track of the consumers and aliases
+// set by configuration.
+type configured struct {
+	lock      sync.Mutex
+	consumers map[string]ConsumerIdentity
+	aliases   map[string]struct{}
+}
+
+func (c *configured) setConsumer(id ConsumerIdentity, configured bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if !configured {
+		delete(c.consumers, string(id.Key()))
+		return
+	}
+	if c.consumers == nil {
+		c.consumers = map[string]ConsumerIdentity{}
+	}
+	c.consumers[string(id.Key())] = id
+}
+
+func (c *configured) setAlias(name string, configured bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if !configured {
+		delete(c.aliases, name)
+		return
+	}
+	if c.aliases == nil {
+		c.aliases = map[string]struct{}{}
+	}
+	c.aliases[name] = struct{}{}
+}
+
+func (c *configured) reset() ([]ConsumerIdentity, []string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	consumers := maputils.OrderedValues(c.consumers)
+	aliases := maputils.OrderedKeys(c.aliases)
+	c.consumers = nil
+	c.aliases = nil
+	return consumers, aliases
+}
+
+// InUpdate implements ctxmgmt.UpdateState.
+func (c *_context) InUpdate() bool {
+	_, inupdate := c.updater.State()
+	return inupdate
+}
+
+// ResetConfig removes the consumer credentials, aliases and
+// attributes set by configuration. It is called before the
+// configuration is applied again after a retraction.
+func (c *_context) ResetConfig() error {
+	consumers, aliases := c.configured.reset()
+	for _, id := range consumers {
+		c.consumerProviders.Remove(id)
+	}
+	list := errors.ErrListf("resetting aliases")
+	if len(aliases) > 0 {
+		if a, ok := c.knownRepositoryTypes.GetType(AliasRepositoryType).(AliasRegistry); ok {
+			for _, n := range aliases {
+				list.Add(a.SetAlias(c, n, nil, nil))
+			}
+		}
+	}
+	ctxmgmt.ResetConfiguredAttributes(c.GetAttributes())
+	return list.Result()
+}
@@ -0,0 +1,96 @@
+package credentials_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/ctxmgmt/config"
+	me "github.com/mandelsoft/ctxmgmt/credentials"
+	credcfg "github.com/mandelsoft/ctxmgmt/credentials/config"
+	"github.com/mandelsoft/ctxmgmt/credentials/extensions/repositories/aliases"
+	"github.com/mandelsoft/ctxmgmt/credentials/extensions/repositories/directcreds"
+	"github.com/mandelsoft/ctxmgmt/credentials/extensions/repositories/memory"
+	"github.com/mandelsoft/ctxmgmt/utils"
+)
+
+var _ = Describe("overlay contexts", func() {
+	id1 := me.NewConsumerIdentity("test", "host", "alice")
+	id2 := me.NewConsumerIdentity("test", "host", "bob")
+
+	var parent me.Context
+
+	BeforeEach(func() {
+		parent = me.New()
+	})
+
+	It("falls through to the parent", func() {
+		parent.SetCredentialsForConsumer(id1, me.CredentialsFromList("user", "parent"))
+		overlay := me.NewOverlay(parent)
+		Expect(overlay.Parent().GetId()).To(Equal(parent.GetId()))
+
+		creds, err := me.CredentialsForConsumer(overlay, id1)
+		Expect(err).To(Succeed())
+		Expect(creds.GetProperty("user")).To(Equal("parent"))
+
+		overlay.SetCredentialsForConsumer(id1, me.CredentialsFromList("user", "overlay"))
+		overlay.SetCredentialsForConsumer(id2, me.CredentialsFromList("user", "overlay"))
+
+		creds, err = me.CredentialsForConsumer(overlay, id1)
+		Expect(err).To(Succeed())
+		Expect(creds.GetProperty("user")).To(Equal("overlay"))
+
+		creds, err = me.CredentialsForConsumer(parent, id1)
+		Expect(err).To(Succeed())
+		Expect(creds.GetProperty("user")).To(Equal("parent"))
+		creds, err = me.CredentialsForConsumer(parent, id2)
+		Expect(err).To(Succeed())
+		Expect(creds).To(BeNil())
+	})
+
+	It("inherits configuration applied to the parent later on", func() {
+		overlay := me.NewOverlay(parent)
+
+		cfg := credcfg.New()
+		Expect(cfg.AddConsumer(id1, directcreds.NewCredentials(utils.Properties{"user": "parent"}))).To(Succeed())
+		Expect(parent.ConfigContext().ApplyConfig(cfg, "parent")).To(Succeed())
+
+		creds, err := me.CredentialsForConsumer(overlay, id1)
+		Expect(err).To(Succeed())
+		Expect(creds.GetProperty("user")).To(Equal("parent"))
+	})
+
+	It("keeps configuration local", func() {
+		overlay := me.NewOverlay(parent)
+		Expect(overlay.ConfigContext().Parent().GetId()).To(Equal(parent.ConfigContext().GetId()))
+
+		cfg := credcfg.New()
+		Expect(cfg.AddConsumer(id2, directcreds.NewCredentials(utils.Properties{"user": "overlay"}))).To(Succeed())
+		Expect(overlay.ConfigContext().ApplyConfig(cfg, "overlay")).To(Succeed())
+
+		creds, err := me.CredentialsForConsumer(overlay, id2)
+		Expect(err).To(Succeed())
+		Expect(creds.GetProperty("user")).To(Equal("overlay"))
+
+		creds, err = me.CredentialsForConsumer(parent, id2)
+		Expect(err).To(Succeed())
+		Expect(creds).To(BeNil())
+		_, cfgs := parent.ConfigContext().GetConfig(config.AllGenerations, nil)
+		Expect(cfgs).To(BeEmpty())
+	})
+
+	It("keeps aliases local", func() {
+		Expect(parent.SetAlias("parent", memory.NewRepositorySpec("parent"))).To(Succeed())
+		overlay := me.NewOverlay(parent)
+		Expect(overlay.SetAlias("overlay", memory.NewRepositorySpec("overlay"))).To(Succeed())
+
+		_, err := overlay.RepositoryForSpec(aliases.NewRepositorySpec("parent"))
+		Expect(err).To(Succeed())
+		_, err = overlay.RepositoryForSpec(aliases.NewRepositorySpec("overlay"))
+		Expect(err).To(Succeed())
+
+		_, err = parent.RepositoryForSpec(aliases.NewRepositorySpec("parent"))
+		Expect(err).To(Succeed())
+		_, err = parent.RepositoryForSpec(aliases.NewRepositorySpec("overlay"))
+		Expect(err).To(MatchError(ContainSubstring("overlay")))
+	})
+})
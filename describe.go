@@ -0,0 +1,246 @@
+package ctxmgmt
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mandelsoft/logging"
+	"github.com/modern-go/reflect2"
+	"sigs.k8s.io/yaml"
+
+	"github.com/mandelsoft/ctxmgmt/utils"
+	"github.com/mandelsoft/ctxmgmt/utils/runtime"
+)
+
+// DescriptionProvider is an optional interface a context implementation
+// may implement to contribute a context type specific section
+// to the description of a context.
+// It returns the name of the section and a serializable
+// representation of the section content.
+type DescriptionProvider interface {
+	DescribeSection() (string, interface{})
+}
+
+// LazyValueDescriber is an optional interface a LazyAttributeValue
+// may implement to be described without being evaluated.
+// It returns a description of the value origin and the last
+// evaluated value, or nil, if the value has not been evaluated, yet.
+type LazyValueDescriber interface {
+	DescribeLazyValue() (string, interface{})
+}
+
+// ContextDescription is a structured report describing the
+// state of a data context.
+type ContextDescription struct {
+	Type       string                 `json:"type"`
+	Id         ContextIdentity        `json:"id"`
+	RefCount   int                    `json:"refCount"`
+	Attributes *AttributesDescription `json:"attributes,omitempty"`
+	Delegates  *DelegatesDescription  `json:"delegates,omitempty"`
+	Sections   map[string]interface{} `json:"sections,omitempty"`
+}
+
+// AttributesDescription describes the attributes of a context
+// and the chain of parent attribute sets.
+type AttributesDescription struct {
+	Context ContextIdentity         `json:"context,omitempty"`
+	Values  []*AttributeDescription `json:"values,omitempty"`
+	Parent  *AttributesDescription  `json:"parent,omitempty"`
+}
+
+// AttributeDescription describes a single attribute value.
+// If the value cannot be encoded by the AttributeScheme,
+// the encoding error is reported instead.
+// Lazy attribute values are not evaluated. They are described
+// by their origin and the last evaluated value, if provided
+// (see LazyValueDescriber).
+type AttributeDescription struct {
+	Name   string          `json:"name"`
+	GoType string          `json:"goType"`
+	Lazy   string          `json:"lazy,omitempty"`
+	Value  json.RawMessage `json:"value,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// DelegatesDescription describes the delegates of a context.
+type DelegatesDescription struct {
+	DefaultLogLevel string   `json:"defaultLogLevel,omitempty"`
+	ActionTypes     []string `json:"actionTypes,omitempty"`
+}
+
+// DescribeContext provides a structured report about the given
+// context.
+func DescribeContext(ctx Context) *ContextDescription {
+	if reflect2.IsNil(ctx) {
+		return nil
+	}
+	d := &ContextDescription{
+		Type:       ctx.GetType(),
+		Id:         ctx.GetId(),
+		RefCount:   GetContextRefCount(ctx),
+		Attributes: DescribeAttributes(ctx.GetAttributes()),
+		Delegates:  describeDelegates(ctx),
+	}
+	if p, ok := ctx.(DescriptionProvider); ok {
+		n, s := p.DescribeSection()
+		if n != "" && s != nil {
+			d.Sections = map[string]interface{}{n: s}
+		}
+	}
+	return d
+}
+
+// DescribeAttributes describes an attribute set including
+// its parent chain.
+// Attributes are only described if they are based on the
+// standard implementation provided by NewAttributes.
+func DescribeAttributes(attrs Attributes) *AttributesDescription {
+	a, ok := attrs.(*_attributes)
+	if !ok || a == nil {
+		return nil
+	}
+	return a.describe()
+}
+
+func (c *_attributes) describe() *AttributesDescription {
+	c.RLock()
+	d := &AttributesDescription{}
+	if !reflect2.IsNil(c.ctx) {
+		d.Context = c.ctx.GetId()
+	}
+	values := make(map[string]interface{}, len(c.attributes))
+	names := make([]string, 0, len(c.attributes))
+	for n, v := range c.attributes {
+		names = append(names, n)
+		values[n] = v
+	}
+	parent := c.parent
+	c.RUnlock()
+
+	sort.Strings(names)
+	for _, n := range names {
+		d.Values = append(d.Values, describeAttribute(n, values[n]))
+	}
+
+	d.Parent = DescribeAttributes(parent)
+	return d
+}
+
+func describeAttribute(name string, value interface{}) *AttributeDescription {
+	d := &AttributeDescription{
+		Name:   name,
+		GoType: fmt.Sprintf("%T", value),
+	}
+	if l, ok := value.(LazyAttributeValue); ok {
+		d.Lazy = "not evaluated"
+		value = nil
+		if p, ok := l.(LazyValueDescriber); ok {
+			d.Lazy, value = p.DescribeLazyValue()
+		}
+		if reflect2.IsNil(value) {
+			return d
+		}
+		d.GoType = fmt.Sprintf("%T", value)
+	}
+	data, err := DefaultAttributeScheme.Encode(name, value, runtime.DefaultJSONEncoding)
+	if err != nil {
+		d.Error = err.Error()
+		return d
+	}
+	switch {
+	case len(data) == 0:
+	case json.Valid(data):
+		d.Value = data
+	default:
+		d.Value, _ = json.Marshal(string(data))
+	}
+	return d
+}
+
+func describeDelegates(ctx Context) *DelegatesDescription {
+	d := &DelegatesDescription{}
+	if l := ctx.LoggingContext(); l != nil {
+		d.DefaultLogLevel = logging.LevelName(l.GetDefaultLevel())
+	}
+	if a := ctx.GetActions(); a != nil && a.GetActionTypes() != nil {
+		d.ActionTypes = a.GetActionTypes().GetActionNames()
+	}
+	return d
+}
+
+// AsJSON renders the description as JSON document.
+func (d *ContextDescription) AsJSON() ([]byte, error) {
+	return json.MarshalIndent(d, "", "  ")
+}
+
+// AsYAML renders the description as YAML document.
+func (d *ContextDescription) AsYAML() ([]byte, error) {
+	return yaml.Marshal(d)
+}
+
+// Print prints a human-readable form of the description.
+func (d *ContextDescription) Print(p utils.Printer) {
+	p.Printf("type:     %s\n", d.Type)
+	p.Printf("id:       %s\n", d.Id)
+	p.Printf("refcount: %d\n", d.RefCount)
+	if d.Delegates != nil {
+		p.Printf("log level: %s\n", d.Delegates.DefaultLogLevel)
+		if len(d.Delegates.ActionTypes) > 0 {
+			p.Printf("action types: %s\n", strings.Join(d.Delegates.ActionTypes, ", "))
+		}
+	}
+	if d.Attributes != nil {
+		p.Printf("attributes:\n")
+		d.Attributes.Print(p.AddGap("  "))
+	}
+	names := make([]string, 0, len(d.Sections))
+	for n := range d.Sections {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		p.Printf("%s:\n", n)
+		data, err := yaml.Marshal(d.Sections[n])
+		if err != nil {
+			p.AddGap("  ").Printf("error: %s\n", err)
+			continue
+		}
+		p.AddGap("  ").Printf("%s", string(data))
+	}
+}
+
+// String provides a human-readable form of the description.
+func (d *ContextDescription) String() string {
+	p, buf := utils.NewBufferedPrinter()
+	d.Print(p)
+	return buf.String()
+}
+
+// Print prints a human-readable form of the attribute description
+// including its parent chain.
+func (d *AttributesDescription) Print(p utils.Printer) {
+	for d != nil {
+		p.Printf("- context: %s\n", d.Context)
+		g := p.AddGap("  ")
+		if len(d.Values) == 0 {
+			g.Printf("no attributes\n")
+		}
+		for _, v := range d.Values {
+			lazy := ""
+			if v.Lazy != "" {
+				lazy = fmt.Sprintf(" [lazy: %s]", v.Lazy)
+			}
+			switch {
+			case v.Error != "":
+				g.Printf("%s: <%s> (%s)%s\n", v.Name, v.GoType, v.Error, lazy)
+			case len(v.Value) == 0 && v.Lazy != "":
+				g.Printf("%s: <%s>%s\n", v.Name, v.GoType, lazy)
+			default:
+				g.Printf("%s: %s%s\n", v.Name, string(v.Value), lazy)
+			}
+		}
+		d = d.Parent
+	}
+}
@@ -0,0 +1,83 @@
+package ctxmgmt_test
+
+import (
+	"encoding/json"
+
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	me "github.com/mandelsoft/ctxmgmt"
+	"github.com/mandelsoft/ctxmgmt/attrs/tmpcache"
+	"github.com/mandelsoft/ctxmgmt/config"
+	"github.com/mandelsoft/ctxmgmt/credentials"
+)
+
+var _ = Describe("context description", func() {
+	It("describes attributes with parent chain", func() {
+		ctx := credentials.New(me.MODE_DEFAULTED)
+		tmpcache.Set(ctx.AttributesContext(), &tmpcache.Attribute{Path: "/tmp/cache"})
+
+		d := me.DescribeContext(ctx)
+		Expect(d.Type).To(Equal(credentials.CONTEXT_TYPE))
+		Expect(d.Id).To(Equal(ctx.GetId()))
+		Expect(d.RefCount).To(Equal(1))
+
+		Expect(d.Attributes).NotTo(BeNil())
+		Expect(d.Attributes.Context).To(Equal(ctx.GetId()))
+		Expect(d.Attributes.Parent).NotTo(BeNil())
+		Expect(d.Attributes.Parent.Context).To(Equal(ctx.ConfigContext().GetId()))
+		root := d.Attributes.Parent.Parent
+		Expect(root).NotTo(BeNil())
+		Expect(root.Context).To(Equal(ctx.AttributesContext().GetId()))
+		Expect(root.Values).To(ContainElement(&me.AttributeDescription{
+			Name:   tmpcache.ATTR_KEY,
+			GoType: "*tmpcache.Attribute",
+			Value:  json.RawMessage(`"/tmp/cache"`),
+		}))
+	})
+
+	It("does not evaluate lazy attributes", func() {
+		ctx := config.New(me.MODE_DEFAULTED)
+		called := false
+		MustBeSuccessful(ctx.GetAttributes().SetAttribute("lazy", me.LazyAttributeFunction(func(ctx me.Context, name string) (interface{}, error) {
+			called = true
+			return "value", nil
+		})))
+
+		d := me.DescribeContext(ctx)
+		Expect(d.Attributes.Values).To(ContainElement(&me.AttributeDescription{
+			Name:   "lazy",
+			GoType: "ctxmgmt.LazyAttributeFunction",
+			Lazy:   "not evaluated",
+		}))
+		Expect(d.String()).To(ContainSubstring("lazy: <ctxmgmt.LazyAttributeFunction> [lazy: not evaluated]\n"))
+		Expect(called).To(BeFalse())
+	})
+
+	It("describes config context", func() {
+		ctx := config.New(me.MODE_DEFAULTED)
+		ctx.AddConfigSet("test", config.NewConfigSet("test set"))
+
+		d := me.DescribeContext(ctx)
+		Expect(d.Sections).To(HaveKey("config"))
+		s := d.Sections["config"].(*config.Description)
+		Expect(s.ConfigSets).To(Equal([]string{"test"}))
+
+		data := Must(d.AsYAML())
+		Expect(string(data)).To(ContainSubstring("configSets:\n    - test\n"))
+		Expect(d.String()).To(ContainSubstring("type:     " + config.CONTEXT_TYPE))
+	})
+
+	It("describes credentials context", func() {
+		ctx := credentials.New(me.MODE_DEFAULTED)
+		id := credentials.NewConsumerIdentity("test", "hostname", "localhost")
+		ctx.SetCredentialsForConsumer(id, credentials.CredentialsFromList("user", "alice"))
+
+		d := me.DescribeContext(ctx)
+		Expect(d.Sections).To(HaveKey("credentials"))
+		s := d.Sections["credentials"].(*credentials.Description)
+		Expect(s.Consumers).To(Equal([]credentials.ConsumerDescription{{Identity: id}}))
+		Expect(s.RepositoryTypes).NotTo(BeEmpty())
+	})
+})
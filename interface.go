@@ -2,6 +2,7 @@ package ctxmgmt
 
 import (
 	"context"
+	"io"
 
 	"github.com/mandelsoft/ctxmgmt/action/handlers"
 	ctxlog "github.com/mandelsoft/ctxmgmt/logging"
@@ -58,6 +59,8 @@ type InternalContext interface {
 	runtimefinalizer.RecorderProvider
 	GetKey() interface{}
 	GetAllocatable() refmgmt.Allocatable
+	LifecycleObservers() *LifecycleObservers
+	CleanupProvider
 }
 
 type Attributes interface {
@@ -67,6 +70,42 @@ type Attributes interface {
 	SetAttribute(name string, value interface{}) error
 	SetEncodedAttribute(name string, data []byte, unmarshaller runtime.Unmarshaler) error
 	GetOrCreateAttribute(name string, creator AttributeFactory) interface{}
+
+	// Watch registers a watcher for changes of the given attribute.
+	// It is called for changes of the attribute value set for this
+	// attribute set, and for changes of values inherited from
+	// parent attribute sets.
+	// The watcher is unregistered by closing the returned closer
+	// or by finalizing the attribute set.
+	Watch(name string, w AttributeWatcher) io.Closer
+}
+
+// AttributeEvent describes a change of an attribute value.
+type AttributeEvent struct {
+	// Context is the context owning the attribute set the watcher
+	// is registered for.
+	Context Context
+	// Name is the name of the changed attribute.
+	Name string
+	// Old is the effective value before the change.
+	Old interface{}
+	// New is the effective value after the change.
+	New interface{}
+	// Inherited is true if the change is inherited from a parent
+	// attribute set.
+	Inherited bool
+}
+
+// AttributeWatcher is notified about changes of an attribute value.
+type AttributeWatcher interface {
+	AttributeChanged(evt *AttributeEvent)
+}
+
+// AttributeWatcherFunction is a function usable as AttributeWatcher.
+type AttributeWatcherFunction func(evt *AttributeEvent)
+
+func (f AttributeWatcherFunction) AttributeChanged(evt *AttributeEvent) {
+	f(evt)
 }
 
 // Updater is the interface for contexts and other objects
@@ -81,6 +120,14 @@ func (u UpdateFunc) Update() error {
 	return u()
 }
 
+// UpdateState is an optional interface for an Updater reporting
+// whether a configuration update is in progress. It is used by
+// attribute sets to keep track of the attributes set by
+// configuration (see ResetConfiguredAttributes).
+type UpdateState interface {
+	InUpdate() bool
+}
+
 // AttributeFactory is used to atomically create a new attribute for a context.
 type AttributeFactory func(Context) interface{}
 
@@ -0,0 +1,54 @@
+package ctxmgmt
+
+import (
+	"github.com/mandelsoft/goutils/errors"
+	"github.com/modern-go/reflect2"
+)
+
+// LazyAttributeValue is an attribute value, which is evaluated
+// on access.
+// If set as attribute value, GetAttribute and GetOrCreateAttribute
+// provide the evaluated value instead of the lazy value itself.
+// The evaluated value is normalized by the Converter of the
+// attribute type, if provided.
+// The value is evaluated in the context of the attribute set
+// it has been set for.
+// Attribute watchers observe the lazy value itself when it is set,
+// re-evaluations are not notified.
+type LazyAttributeValue interface {
+	EvaluateAttribute(ctx Context, name string) (interface{}, error)
+}
+
+// LazyAttributeFunction is a function usable as LazyAttributeValue.
+type LazyAttributeFunction func(ctx Context, name string) (interface{}, error)
+
+func (f LazyAttributeFunction) EvaluateAttribute(ctx Context, name string) (interface{}, error) {
+	return f(ctx, name)
+}
+
+// evaluate resolves a LazyAttributeValue. Evaluation errors are logged,
+// a value provided together with the error is still used.
+func (c *_attributes) evaluate(name string, v interface{}) interface{} {
+	l, ok := v.(LazyAttributeValue)
+	if !ok {
+		return v
+	}
+	r, err := l.EvaluateAttribute(c.ctx, name)
+	if err == nil && !reflect2.IsNil(r) {
+		r, err = DefaultAttributeScheme.Convert(name, r)
+		if errors.IsErrUnknownKind(err, "attribute") {
+			err = nil
+		}
+	}
+	if err != nil {
+		if reflect2.IsNil(c.ctx) {
+			Logger.Error("cannot evaluate attribute", "attribute", name, "error", err.Error())
+		} else {
+			c.ctx.LoggingContext().Logger(Realm).Error("cannot evaluate attribute", "attribute", name, "error", err.Error(), "id", c.ctx.GetId())
+		}
+	}
+	if reflect2.IsNil(r) {
+		return nil
+	}
+	return r
+}
@@ -0,0 +1,141 @@
+package ctxmgmt
+
+import (
+	"io"
+	"slices"
+	"sync"
+
+	"github.com/modern-go/reflect2"
+)
+
+// LifecycleEventType describes the kind of a LifecycleEvent.
+type LifecycleEventType string
+
+const (
+	// LIFECYCLE_CREATED is sent after a new context has been set up
+	// (see SetupContext).
+	LIFECYCLE_CREATED LifecycleEventType = "created"
+	// LIFECYCLE_VIEW is sent for every new view of a context, including
+	// the initial view provided by the context creation.
+	LIFECYCLE_VIEW LifecycleEventType = "view"
+	// LIFECYCLE_FINALIZE is sent after the finalizers of a context
+	// have been executed by the Finalize method.
+	LIFECYCLE_FINALIZE LifecycleEventType = "finalize"
+	// LIFECYCLE_CLEANUP is sent after a context has been cleaned up
+	// because its last reference is gone.
+	LIFECYCLE_CLEANUP LifecycleEventType = "cleanup"
+)
+
+// LifecycleEvent describes a lifecycle event of a context.
+type LifecycleEvent struct {
+	Type LifecycleEventType
+	// Context is the affected context. For LIFECYCLE_CREATED and
+	// LIFECYCLE_VIEW events this is the created view,
+	// for the other events the internal context implementation.
+	Context Context
+	// Id is the identity of the context.
+	Id ContextIdentity
+	// ContextType is the type of the context.
+	ContextType string
+	// Mode is the builder mode used to create the context.
+	// It is only set for LIFECYCLE_CREATED events.
+	Mode BuilderMode
+	// Error is the result of a finalization or cleanup.
+	Error error
+}
+
+// LifecycleObserver is notified about lifecycle events of contexts.
+// Cleanup events are sent while the reference management of
+// the context is locked, therefore an observer must not
+// create new views for the context.
+type LifecycleObserver interface {
+	LifecycleEvent(evt *LifecycleEvent)
+}
+
+// LifecycleObserverFunction is a function usable as LifecycleObserver.
+type LifecycleObserverFunction func(evt *LifecycleEvent)
+
+func (f LifecycleObserverFunction) LifecycleEvent(evt *LifecycleEvent) {
+	f(evt)
+}
+
+// LifecycleObservers is a registry for lifecycle observers.
+type LifecycleObservers struct {
+	lock      sync.RWMutex
+	observers []*lifecycleObserver
+}
+
+type lifecycleObserver struct {
+	registry *LifecycleObservers
+	observer LifecycleObserver
+}
+
+func (o *lifecycleObserver) Close() error {
+	o.registry.unregister(o)
+	return nil
+}
+
+// Register registers an observer. It can be unregistered
+// by closing the returned closer.
+func (r *LifecycleObservers) Register(o LifecycleObserver) io.Closer {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	e := &lifecycleObserver{registry: r, observer: o}
+	r.observers = append(r.observers, e)
+	return e
+}
+
+func (r *LifecycleObservers) unregister(o *lifecycleObserver) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if i := slices.Index(r.observers, o); i >= 0 {
+		r.observers = slices.Delete(slices.Clone(r.observers), i, i+1)
+	}
+}
+
+// Notify sends an event to all registered observers.
+func (r *LifecycleObservers) Notify(evt *LifecycleEvent) {
+	if r == nil {
+		return
+	}
+	r.lock.RLock()
+	list := r.observers
+	r.lock.RUnlock()
+	for _, o := range list {
+		o.observer.LifecycleEvent(evt)
+	}
+}
+
+var lifecycleObservers = &LifecycleObservers{}
+
+// RegisterLifecycleObserver registers a global lifecycle observer
+// notified about the events of all contexts.
+func RegisterLifecycleObserver(o LifecycleObserver) io.Closer {
+	return lifecycleObservers.Register(o)
+}
+
+type lifecycleProvider interface {
+	LifecycleObservers() *LifecycleObservers
+}
+
+// AddLifecycleObserver registers a lifecycle observer for a dedicated
+// context. It is notified about the events of this context, only.
+// It returns nil, if the context does not support lifecycle observers.
+func AddLifecycleObserver(ctx Context, o LifecycleObserver) io.Closer {
+	if p, ok := ctx.(lifecycleProvider); ok {
+		return p.LifecycleObservers().Register(o)
+	}
+	return nil
+}
+
+func notifyLifecycle(ctx Context, evt *LifecycleEvent) {
+	if reflect2.IsNil(ctx) {
+		return
+	}
+	evt.Id = ctx.GetId()
+	evt.ContextType = ctx.GetType()
+	lifecycleObservers.Notify(evt)
+	if p, ok := ctx.(lifecycleProvider); ok {
+		p.LifecycleObservers().Notify(evt)
+	}
+}
@@ -0,0 +1,95 @@
+package ctxmgmt_test
+
+import (
+	"runtime"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	me "github.com/mandelsoft/ctxmgmt"
+	"github.com/mandelsoft/ctxmgmt/attributes"
+	"github.com/mandelsoft/ctxmgmt/config"
+	"github.com/mandelsoft/ctxmgmt/credentials"
+)
+
+type lifecycleRecorder struct {
+	lock   sync.Mutex
+	events []me.LifecycleEvent
+}
+
+func (r *lifecycleRecorder) LifecycleEvent(evt *me.LifecycleEvent) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	e := *evt
+	e.Context = nil
+	r.events = append(r.events, e)
+}
+
+func (r *lifecycleRecorder) Get() []me.LifecycleEvent {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]me.LifecycleEvent(nil), r.events...)
+}
+
+var _ = Describe("lifecycle observers", func() {
+	var rec *lifecycleRecorder
+
+	BeforeEach(func() {
+		rec = &lifecycleRecorder{}
+	})
+
+	It("observes context creation for all context types", func() {
+		DeferCleanup(me.RegisterLifecycleObserver(rec).Close)
+
+		ctx := credentials.New(me.MODE_DEFAULTED)
+		var created []me.LifecycleEvent
+		for _, e := range rec.Get() {
+			if e.Type == me.LIFECYCLE_CREATED {
+				created = append(created, e)
+			}
+		}
+		Expect(created).To(Equal([]me.LifecycleEvent{
+			{Type: me.LIFECYCLE_CREATED, Id: ctx.AttributesContext().GetId(), ContextType: attributes.CONTEXT_TYPE, Mode: me.MODE_DEFAULTED},
+			{Type: me.LIFECYCLE_CREATED, Id: ctx.ConfigContext().GetId(), ContextType: config.CONTEXT_TYPE, Mode: me.MODE_DEFAULTED},
+			{Type: me.LIFECYCLE_CREATED, Id: ctx.GetId(), ContextType: credentials.CONTEXT_TYPE, Mode: me.MODE_DEFAULTED},
+		}))
+		Expect(rec.Get()).To(ContainElement(me.LifecycleEvent{Type: me.LIFECYCLE_VIEW, Id: ctx.GetId(), ContextType: credentials.CONTEXT_TYPE}))
+	})
+
+	It("observes views and finalization of a dedicated context", func() {
+		ctx := attributes.New()
+		other := attributes.New()
+		Expect(me.AddLifecycleObserver(ctx, rec)).NotTo(BeNil())
+
+		view := me.PersistentContextRef(ctx.AttributesContext())
+		Expect(other.Finalize()).To(Succeed())
+		Expect(ctx.Finalize()).To(Succeed())
+		Expect(rec.Get()).To(Equal([]me.LifecycleEvent{
+			{Type: me.LIFECYCLE_VIEW, Id: ctx.GetId(), ContextType: attributes.CONTEXT_TYPE},
+			{Type: me.LIFECYCLE_FINALIZE, Id: ctx.GetId(), ContextType: attributes.CONTEXT_TYPE},
+		}))
+		view.GetType()
+	})
+
+	It("unregisters observers", func() {
+		ctx := attributes.New()
+		Expect(me.AddLifecycleObserver(ctx, rec).Close()).To(Succeed())
+		Expect(ctx.Finalize()).To(Succeed())
+		Expect(rec.Get()).To(BeEmpty())
+	})
+
+	It("observes cleanup", func() {
+		ctx := attributes.New()
+		id := ctx.GetId()
+		me.AddLifecycleObserver(ctx, rec)
+		ctx = nil
+
+		Eventually(func() []me.LifecycleEvent {
+			runtime.GC()
+			time.Sleep(time.Millisecond)
+			return rec.Get()
+		}).WithTimeout(5 * time.Second).Should(ContainElement(me.LifecycleEvent{Type: me.LIFECYCLE_CLEANUP, Id: id, ContextType: attributes.CONTEXT_TYPE}))
+	})
+})
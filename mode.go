@@ -47,6 +47,11 @@ func (m BuilderMode) String() string {
 	}
 }
 
+// IsValid checks whether the mode is a known mode.
+func (m BuilderMode) IsValid() bool {
+	return m >= MODE_SHARED && m <= MODE_INITIAL
+}
+
 func Mode(m ...BuilderMode) BuilderMode {
 	return general.OptionalDefaulted(MODE_EXTENDED, m...)
 }
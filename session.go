@@ -1,18 +1,38 @@
 package ctxmgmt
 
 import (
+	"context"
+	"fmt"
 	"io"
+	"strings"
 	"sync"
 
 	"github.com/mandelsoft/goutils/errors"
 	"github.com/mandelsoft/goutils/ioutils"
+
+	"github.com/mandelsoft/ctxmgmt/utils"
+	"github.com/mandelsoft/ctxmgmt/utils/cleanup"
 )
 
+// ErrSessionClosed is the cause of the session context
+// for explicitly closed sessions.
+var ErrSessionClosed = errors.New("session closed")
+
 // Session is a context keeping track of objects requiring a close
 // after final use. When closing a session all subsequent objects
 // will be closed in the opposite order they are added.
 // Added closers may be closed prio to the session without causing
-// errors.
+// errors. Closers added to an already closed session are closed
+// immediately.
+// Closers may be added with a name and the names of other closers
+// they depend on. A closer is always closed before the closers it
+// depends on.
+//
+// Every session provides a context.Context, which is canceled
+// when the session is closed, after its closers have been closed.
+// Sessions created for a context.Context
+// (see NewSessionForContext) are closed automatically, when the given
+// context is canceled or its deadline is exceeded.
 type Session interface {
 	// Closer adds a closer returned by a function call providing a closer and an error
 	// to the session if not error is returned. The results of the call are forwarded to
@@ -21,6 +41,21 @@ type Session interface {
 	Closer(closer io.Closer, extra ...interface{}) (io.Closer, error)
 	GetOrCreate(key interface{}, creator func(SessionBase) Session) Session
 	AddCloser(closer io.Closer, callbacks ...ioutils.CloserCallback) io.Closer
+	// AddNamedCloser adds a closer with a name depending on
+	// the closers with the given names.
+	AddNamedCloser(name string, closer io.Closer, deps ...string) (io.Closer, error)
+	// ClosePlan describes the order used to close the
+	// closers without closing them.
+	ClosePlan() cleanup.Plan
+	// NewSubSession creates a new session closed together with
+	// this session. Its context is derived from the context of
+	// this session.
+	NewSubSession() Session
+	// Context provides a context.Context canceled when the
+	// session is closed.
+	Context() context.Context
+	// Status reports the state of the session and its closers.
+	Status() *SessionStatus
 	Close() error
 	IsClosed() bool
 }
@@ -34,6 +69,12 @@ type SessionBase interface {
 	Session() Session
 	IsClosed() bool
 	AddCloser(closer io.Closer, callbacks ...ioutils.CloserCallback) io.Closer
+	AddNamedCloser(name string, closer io.Closer, deps ...string) (io.Closer, error)
+	// NewSubSession creates a new session closed together with
+	// the session. It can be used by creators for GetOrCreate
+	// to provide sub sessions with a lifecycle link to the
+	// parent session.
+	NewSubSession() Session
 }
 
 type ObjectKey struct {
@@ -49,17 +90,31 @@ type sessionBase struct {
 	sync.RWMutex
 	session  Session
 	closed   bool
-	closer   []io.Closer
+	count    int
+	closers  []*sessionCloser
+	closer   cleanup.Graph
 	sessions map[interface{}]Session
+
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	stop   func() bool
 }
 
 func NewSession() Session {
+	return NewSessionForContext(context.Background())
+}
+
+// NewSessionForContext creates a new session closed automatically
+// when the given context is canceled or its deadline is exceeded.
+func NewSessionForContext(ctx context.Context) Session {
 	s := &session{
 		sessionBase{
 			sessions: map[interface{}]Session{},
 		},
 	}
 	s.base.session = s
+	s.base.ctx, s.base.cancel = context.WithCancelCause(ctx)
+	s.base.stop = context.AfterFunc(s.base.ctx, func() { s.Close() })
 	return s
 }
 
@@ -107,6 +162,37 @@ func (s *session) AddCloser(closer io.Closer, callbacks ...ioutils.CloserCallbac
 	return s.base.AddCloser(closer, callbacks...)
 }
 
+func (s *session) AddNamedCloser(name string, closer io.Closer, deps ...string) (io.Closer, error) {
+	if closer == nil {
+		return nil, nil
+	}
+	s.base.Lock()
+	defer s.base.Unlock()
+	return s.base.AddNamedCloser(name, closer, deps...)
+}
+
+func (s *session) ClosePlan() cleanup.Plan {
+	s.base.RLock()
+	defer s.base.RUnlock()
+	return s.base.closer.Plan()
+}
+
+func (s *session) NewSubSession() Session {
+	s.base.Lock()
+	defer s.base.Unlock()
+	return s.base.NewSubSession()
+}
+
+func (s *session) Context() context.Context {
+	return s.base.ctx
+}
+
+func (s *session) Status() *SessionStatus {
+	s.base.RLock()
+	defer s.base.RUnlock()
+	return s.base.Status()
+}
+
 func (s *session) GetOrCreate(key interface{}, creator func(SessionBase) Session) Session {
 	s.base.Lock()
 	defer s.base.Unlock()
@@ -126,18 +212,56 @@ func (s *sessionBase) Close() error {
 		return nil
 	}
 	s.closed = true
+	s.stop()
+	// sub sessions are closed automatically when the context is
+	// canceled, therefore the closers (including the sub sessions)
+	// are closed first to report their errors.
 	list := errors.ErrListf("closing session")
-	for i := len(s.closer) - 1; i >= 0; i-- {
-		list.Add(s.closer[i].Close())
-	}
+	list.Add(s.closer.Cleanup())
+	s.cancel(ErrSessionClosed)
 	return list.Result()
 }
 
 func (s *sessionBase) AddCloser(closer io.Closer, callbacks ...ioutils.CloserCallback) io.Closer {
-	s.closer = append(s.closer, ioutils.OnceCloser(closer, callbacks...))
+	s.count++
+	// anonymous closers use names not usable for named closers.
+	c := &sessionCloser{name: fmt.Sprintf("#%d", s.count), closer: ioutils.OnceCloser(closer, callbacks...), orig: closer}
+	s.track(c)
+	if s.closed {
+		c.Close()
+	} else {
+		s.closer.AddCloser(c.name, c)
+	}
 	return closer
 }
 
+func (s *sessionBase) AddNamedCloser(name string, closer io.Closer, deps ...string) (io.Closer, error) {
+	if strings.HasPrefix(name, "#") {
+		return nil, errors.ErrInvalid("closer name", name)
+	}
+	c := &sessionCloser{name: name, closer: ioutils.OnceCloser(closer), orig: closer}
+	if s.closed {
+		s.track(c)
+		return closer, c.Close()
+	}
+	err := s.closer.AddCloser(name, c, deps...)
+	if err != nil {
+		return nil, err
+	}
+	s.track(c)
+	return closer, nil
+}
+
+func (s *sessionBase) NewSubSession() Session {
+	sub := NewSessionForContext(s.ctx)
+	if s.closed {
+		sub.Close()
+	} else {
+		s.AddCloser(sub)
+	}
+	return sub
+}
+
 func (s *sessionBase) GetOrCreate(key interface{}, creator func(SessionBase) Session) Session {
 	cur := s.sessions[key]
 	if cur == nil {
@@ -146,3 +270,105 @@ func (s *sessionBase) GetOrCreate(key interface{}, creator func(SessionBase) Ses
 	}
 	return cur
 }
+
+func (s *sessionBase) track(c *sessionCloser) {
+	s.closers = append(s.closers, c)
+}
+
+func (s *sessionBase) Status() *SessionStatus {
+	status := &SessionStatus{Closed: s.closed}
+	if cause := context.Cause(s.ctx); cause != ErrSessionClosed {
+		status.Cause = cause
+	}
+	for _, c := range s.closers {
+		closed, err := c.state()
+		switch {
+		case err != nil:
+			status.Failed = append(status.Failed, &CloserFailure{Name: c.name, Error: err})
+		case !closed:
+			status.Open = append(status.Open, c.name)
+		}
+	}
+	return status
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// sessionCloser keeps track of the closing state of a closer
+// added to a session.
+type sessionCloser struct {
+	lock   sync.Mutex
+	name   string
+	closer io.Closer
+	orig   io.Closer
+	closed bool
+	err    error
+}
+
+func (c *sessionCloser) Close() error {
+	err := c.closer.Close()
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if !c.closed {
+		c.closed = true
+		c.err = err
+	}
+	return err
+}
+
+func (c *sessionCloser) state() (bool, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if !c.closed {
+		// closers able to report their state may be closed
+		// prior to the session.
+		if s, ok := c.orig.(interface{ IsClosed() bool }); ok && s.IsClosed() {
+			return true, nil
+		}
+	}
+	return c.closed, c.err
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// SessionStatus describes the state of a session.
+type SessionStatus struct {
+	Closed bool `json:"closed"`
+	// Cause is the reason for closing the session, if it has been closed
+	// by its context.Context, for example context.DeadlineExceeded.
+	Cause error `json:"cause,omitempty"`
+	// Open lists the closers not yet closed.
+	Open []string `json:"open,omitempty"`
+	// Failed lists the closers failed to close.
+	Failed []*CloserFailure `json:"failed,omitempty"`
+}
+
+// CloserFailure describes a failed closer.
+type CloserFailure struct {
+	Name  string `json:"name"`
+	Error error  `json:"error"`
+}
+
+// Print prints a human-readable form of the status.
+func (s *SessionStatus) Print(p utils.Printer) {
+	if s.Closed {
+		p.Printf("closed\n")
+	} else {
+		p.Printf("open\n")
+	}
+	if s.Cause != nil {
+		p.Printf("cause: %s\n", s.Cause)
+	}
+	if len(s.Open) > 0 {
+		p.Printf("open closers: %s\n", strings.Join(s.Open, ", "))
+	}
+	for _, f := range s.Failed {
+		p.Printf("failed closer %s: %s\n", f.Name, f.Error)
+	}
+}
+
+func (s *SessionStatus) String() string {
+	p, buf := utils.NewBufferedPrinter()
+	s.Print(p)
+	return buf.String()
+}
@@ -0,0 +1,139 @@
+package ctxmgmt_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	me "github.com/mandelsoft/ctxmgmt"
+)
+
+var _ = Describe("session", func() {
+	var log *cleanupLog
+
+	BeforeEach(func() {
+		log = &cleanupLog{}
+	})
+
+	It("closes on context cancellation", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		sess := me.NewSessionForContext(ctx)
+		sess.AddCloser(log.Close("a"))
+
+		Expect(sess.Status().Open).To(Equal([]string{"#1"}))
+		cancel()
+		Eventually(sess.IsClosed).Should(BeTrue())
+		Expect(log.Get()).To(Equal([]string{"a"}))
+		Expect(sess.Status().Cause).To(Equal(context.Canceled))
+		Expect(sess.Status().Open).To(BeEmpty())
+	})
+
+	It("closes on deadline", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		sess := me.NewSessionForContext(ctx)
+		sess.AddCloser(log.Close("a"))
+
+		Eventually(sess.IsClosed).Should(BeTrue())
+		Expect(sess.Status().Cause).To(Equal(context.DeadlineExceeded))
+		Expect(log.Get()).To(Equal([]string{"a"}))
+	})
+
+	It("cancels its context on close", func() {
+		sess := me.NewSession()
+		Expect(sess.Context().Err()).To(BeNil())
+		Expect(sess.Close()).To(Succeed())
+		Expect(sess.Context().Err()).To(Equal(context.Canceled))
+		Expect(context.Cause(sess.Context())).To(Equal(me.ErrSessionClosed))
+		Expect(sess.Status().Cause).To(BeNil())
+	})
+
+	It("closes sub sessions with parent", func() {
+		sess := me.NewSession()
+		sess.AddCloser(log.Close("parent"))
+		sub := sess.NewSubSession()
+		sub.AddCloser(log.Close("sub"))
+
+		Expect(sess.Close()).To(Succeed())
+		Expect(sub.IsClosed()).To(BeTrue())
+		Expect(sub.Context().Err()).NotTo(BeNil())
+		Expect(log.Get()).To(Equal([]string{"sub", "parent"}))
+	})
+
+	It("links sub sessions created by GetOrCreate", func() {
+		sess := me.NewSession()
+		sub := me.GetOrCreateSubSession(sess, "key", func(base me.SessionBase) me.Session {
+			return base.NewSubSession()
+		})
+		Expect(me.GetOrCreateSubSession(sess, "key", nil)).To(BeIdenticalTo(sub))
+		sub.AddCloser(log.Close("sub"))
+
+		Expect(sess.Close()).To(Succeed())
+		Expect(log.Get()).To(Equal([]string{"sub"}))
+	})
+
+	It("reports errors of sub sessions", func() {
+		sess := me.NewSession()
+		sub := sess.NewSubSession()
+		sub.AddCloser(errCloser("sub failed"))
+		// closed before the sub session.
+		sess.AddCloser(closerFunc(func() error {
+			time.Sleep(10 * time.Millisecond)
+			return nil
+		}))
+
+		Expect(sess.Close()).To(MatchError(ContainSubstring("sub failed")))
+		Expect(sub.IsClosed()).To(BeTrue())
+	})
+
+	It("reports sub sessions closed early", func() {
+		sess := me.NewSession()
+		sub := sess.NewSubSession()
+		Expect(sess.Status().Open).To(Equal([]string{"#1"}))
+		Expect(sub.Close()).To(Succeed())
+		Expect(sess.Status().Open).To(BeEmpty())
+	})
+
+	It("reports failed closers", func() {
+		sess := me.NewSession()
+		sess.AddCloser(errCloser("failed"))
+		_, err := sess.AddNamedCloser("open", log.Close("open"), "never")
+		Expect(err).To(Succeed())
+
+		Expect(sess.Status().String()).To(Equal("open\nopen closers: #1, open\n"))
+		Expect(sess.Close()).NotTo(Succeed())
+		Expect(sess.Status().String()).To(Equal("closed\nfailed closer #1: unable to close: failed\n"))
+	})
+	It("closes closers added after close", func() {
+		sess := me.NewSession()
+		Expect(sess.Close()).To(Succeed())
+
+		sess.AddCloser(log.Close("a"))
+		_, err := sess.AddNamedCloser("b", log.Close("b"))
+		Expect(err).To(Succeed())
+		Expect(log.Get()).To(Equal([]string{"a", "b"}))
+		Expect(sess.Status().Open).To(BeEmpty())
+
+		_, err = sess.AddNamedCloser("failed", errCloser("failed"))
+		Expect(err).To(MatchError("unable to close: failed"))
+	})
+
+	It("closes closers added after context cancellation", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		sess := me.NewSessionForContext(ctx)
+		cancel()
+		Eventually(sess.IsClosed).Should(BeTrue())
+
+		sess.AddCloser(log.Close("a"))
+		Expect(log.Get()).To(Equal([]string{"a"}))
+		Expect(sess.Status().Open).To(BeEmpty())
+	})
+})
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error {
+	return f()
+}
@@ -53,7 +53,10 @@ func RegisterSetupHandler(h SetupHandler) {
 	registry.Register(h)
 }
 
+// SetupContext executes the registered SetupHandlers for a freshly created
+// context and notifies the lifecycle observers about the creation.
 func SetupContext[C Context](mode BuilderMode, ctx C) C {
 	registry.Setup(mode, ctx)
+	notifyLifecycle(ctx, &LifecycleEvent{Type: LIFECYCLE_CREATED, Context: ctx, Mode: mode})
 	return ctx
 }
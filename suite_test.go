@@ -5,8 +5,12 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/ctxmgmt/testhelper"
 )
 
+var _ = testhelper.ReportLiveContextsAfterSuite()
+
 func TestConfig(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Data Context Test Suite")
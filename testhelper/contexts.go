@@ -0,0 +1,89 @@
+// Package testhelper provides Ginkgo/Gomega helpers for tests
+// using data contexts.
+package testhelper
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/mandelsoft/goutils/general"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gcustom"
+	"github.com/onsi/gomega/types"
+
+	"github.com/mandelsoft/ctxmgmt"
+)
+
+// DefaultLeakTimeout is the default time to wait for the
+// garbage collection of contexts before reporting them as leaked.
+var DefaultLeakTimeout = 5 * time.Second
+
+// TrackContexts enables the context tracking for the current spec
+// and registers a cleanup verifying that all contexts created during
+// the spec are released after the spec.
+// It should be called in a BeforeEach node or at the beginning of a spec.
+// Contexts are only released, if they are not referenced anymore, so
+// variables of a surrounding container must be reset by an AfterEach node.
+func TrackContexts(timeout ...time.Duration) {
+	GinkgoHelper()
+	disable := ctxmgmt.EnableContextTracking()
+	mark := ctxmgmt.TrackingMark()
+	DeferCleanup(func() {
+		defer disable()
+		ExpectNoLeakedContexts(mark, timeout...)
+	})
+}
+
+// ReportLiveContextsAfterSuite registers a report node writing the
+// contexts still alive after all specs of a test suite have been run
+// to standard error. The report is only generated, if the context
+// tracking is enabled for the complete test process with the
+// environment variable CTXMGMT_TRACK_CONTEXTS (see ctxmgmt.TRACKING_ENV).
+// It must be called at the top level of a test suite, for example
+//
+//	var _ = testhelper.ReportLiveContextsAfterSuite()
+//
+// in the suite_test.go file.
+func ReportLiveContextsAfterSuite() bool {
+	return ReportAfterSuite("live contexts", func(Report) {
+		if !ctxmgmt.IsContextTrackingEnabled() {
+			return
+		}
+		r := LiveContextsAfterGC(0)
+		if !r.IsEmpty() {
+			fmt.Fprintf(os.Stderr, "%d contexts still alive after test suite:\n%s", len(r.Contexts), r)
+		}
+	})
+}
+
+// ExpectNoLeakedContexts asserts that all tracked contexts created after the
+// given mark (see ctxmgmt.TrackingMark) are released after garbage collection.
+func ExpectNoLeakedContexts(mark uint64, timeout ...time.Duration) {
+	GinkgoHelper()
+	Eventually(func() *ctxmgmt.ContextReport {
+		return LiveContextsAfterGC(mark)
+	}).WithTimeout(general.OptionalDefaulted(DefaultLeakTimeout, timeout...)).
+		WithPolling(10 * time.Millisecond).
+		Should(HaveNoLiveContexts())
+}
+
+// LiveContextsAfterGC triggers the garbage collection and
+// provides the report of contexts still alive created
+// after the given mark.
+func LiveContextsAfterGC(mark uint64) *ctxmgmt.ContextReport {
+	runtime.GC()
+	time.Sleep(time.Millisecond) // give finalizers a chance to run.
+	return ctxmgmt.LiveContexts(mark)
+}
+
+// HaveNoLiveContexts succeeds for an empty *ctxmgmt.ContextReport.
+// The failure message contains the report including the creation
+// stack traces of the leaked contexts and their views.
+func HaveNoLiveContexts() types.GomegaMatcher {
+	return gcustom.MakeMatcher(func(r *ctxmgmt.ContextReport) (bool, error) {
+		return r.IsEmpty(), nil
+	}).WithTemplate("Expected no live contexts, but found:\n{{.Actual.String}}")
+}
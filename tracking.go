@@ -0,0 +1,342 @@
+package ctxmgmt
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"weak"
+
+	"github.com/mandelsoft/ctxmgmt/utils"
+	"github.com/mandelsoft/ctxmgmt/utils/refmgmt"
+	"github.com/mandelsoft/ctxmgmt/utils/refmgmt/finalized"
+)
+
+// TRACKING_ENV is the name of the environment variable
+// used to enable the context tracking on program start.
+const TRACKING_ENV = "CTXMGMT_TRACK_CONTEXTS"
+
+// TRACKING_MAX_REF_EVENTS is the maximum number of Ref/Unref
+// operations recorded per context. If exceeded, the oldest
+// operations are dropped.
+const TRACKING_MAX_REF_EVENTS = 100
+
+func init() {
+	if b, err := strconv.ParseBool(os.Getenv(TRACKING_ENV)); err == nil && b {
+		EnableContextTracking()
+	}
+}
+
+var tracking atomic.Pointer[contextTracker]
+
+// trackingState keeps track of the nested enablements of the
+// context tracking.
+var trackingState struct {
+	lock  sync.Mutex
+	count int
+	old   refmgmt.Tracker
+}
+
+// EnableContextTracking enables the tracking of contexts and context views
+// for debugging purposes. For every context and view created
+// after enabling the tracking, the creation call stack is recorded,
+// as well as the latest Ref/Unref operations on the context
+// (see TRACKING_MAX_REF_EVENTS).
+// It returns a function to disable the tracking again.
+// Enablements may be nested, the tracking is disabled when all
+// enablements have been disabled.
+// Tracking can also be enabled with the environment variable
+// CTXMGMT_TRACK_CONTEXTS.
+func EnableContextTracking() func() {
+	trackingState.lock.Lock()
+	defer trackingState.lock.Unlock()
+
+	if trackingState.count == 0 {
+		t := &contextTracker{contexts: map[uint64]*trackedContext{}}
+		tracking.Store(t)
+		trackingState.old = refmgmt.SetTracker(t)
+	}
+	trackingState.count++
+
+	var once sync.Once
+	return func() {
+		once.Do(disableContextTracking)
+	}
+}
+
+func disableContextTracking() {
+	trackingState.lock.Lock()
+	defer trackingState.lock.Unlock()
+
+	trackingState.count--
+	if trackingState.count == 0 {
+		tracking.Store(nil)
+		refmgmt.SetTracker(trackingState.old)
+		trackingState.old = nil
+	}
+}
+
+// IsContextTrackingEnabled reports whether the context tracking
+// is enabled.
+func IsContextTrackingEnabled() bool {
+	return tracking.Load() != nil
+}
+
+// TrackingMark provides the sequence number of the
+// last tracked object. It can be used to restrict a report
+// to contexts created after the mark has been taken.
+func TrackingMark() uint64 {
+	if t := tracking.Load(); t != nil {
+		return t.seq.Load()
+	}
+	return 0
+}
+
+// LiveContexts reports the tracked contexts, which are still alive.
+// A context is alive as long as it is not cleaned up.
+// If a mark (see TrackingMark) is given, only contexts
+// created after the mark are reported.
+// If the tracking is not enabled, an empty report is returned.
+func LiveContexts(mark ...uint64) *ContextReport {
+	r := &ContextReport{}
+	if t := tracking.Load(); t != nil {
+		var m uint64
+		for _, e := range mark {
+			m = e
+		}
+		r.Contexts = t.report(m)
+	}
+	return r
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// ContextReport describes the live contexts.
+type ContextReport struct {
+	Contexts []*LiveContext `json:"contexts,omitempty"`
+}
+
+// LiveContext describes a context, which is still alive.
+type LiveContext struct {
+	Id       ContextIdentity `json:"id"`
+	Type     string          `json:"type"`
+	Sequence uint64          `json:"sequence"`
+	RefCount int             `json:"refCount"`
+	Created  string          `json:"created"`
+	// Views are the views to the context not yet garbage collected.
+	Views []*LiveView `json:"views,omitempty"`
+	// Refs is the history of the latest Ref/Unref operations on the context.
+	Refs []*RefEvent `json:"refs,omitempty"`
+	// DroppedRefs is the number of earlier operations dropped
+	// from the history.
+	DroppedRefs int `json:"droppedRefs,omitempty"`
+}
+
+// LiveView describes a context view, which is not yet
+// garbage collected.
+type LiveView struct {
+	Sequence uint64 `json:"sequence"`
+	Created  string `json:"created"`
+}
+
+// RefEvent describes a Ref or Unref operation.
+type RefEvent struct {
+	Op    string `json:"op"`
+	Count int    `json:"count"`
+	Stack string `json:"stack"`
+}
+
+// IsEmpty returns true if no live context is reported.
+func (r *ContextReport) IsEmpty() bool {
+	return r == nil || len(r.Contexts) == 0
+}
+
+// Print prints a human-readable form of the report.
+func (r *ContextReport) Print(p utils.Printer) {
+	if r.IsEmpty() {
+		p.Printf("no live contexts\n")
+		return
+	}
+	for _, c := range r.Contexts {
+		p.Printf("context %s (refcount %d)\n", c.Id, c.RefCount)
+		g := p.AddGap("  ")
+		g.Printf("created at:\n")
+		g.AddGap("  ").Printf("%s", c.Created)
+		for _, v := range c.Views {
+			g.Printf("live view created at:\n")
+			g.AddGap("  ").Printf("%s", v.Created)
+		}
+		if c.DroppedRefs > 0 {
+			g.Printf("%d earlier ref operations dropped\n", c.DroppedRefs)
+		}
+		for _, e := range c.Refs {
+			g.Printf("%s (refcount %d) at:\n", e.Op, e.Count)
+			g.AddGap("  ").Printf("%s", e.Stack)
+		}
+	}
+}
+
+// String provides a human-readable form of the report.
+func (r *ContextReport) String() string {
+	p, buf := utils.NewBufferedPrinter()
+	r.Print(p)
+	return buf.String()
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type contextTracker struct {
+	lock     sync.Mutex
+	seq      atomic.Uint64
+	contexts map[uint64]*trackedContext
+}
+
+type trackedContext struct {
+	seq   uint64
+	ctx   weak.Pointer[contextBase]
+	id    ContextIdentity
+	typ   string
+	stack string
+	views []*trackedView
+
+	// refs is a ring buffer of the latest ref events
+	// starting at index next.
+	refs    []*RefEvent
+	next    int
+	dropped int
+}
+
+type trackedView struct {
+	seq   uint64
+	ref   weak.Pointer[finalized.FinalizedRef]
+	stack string
+}
+
+var _ refmgmt.Tracker = (*contextTracker)(nil)
+
+func (t *contextTracker) addContext(c *contextBase) {
+	id := refmgmt.ObjectId(c.allocatable)
+	if id == 0 {
+		return
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.contexts[id] = &trackedContext{
+		seq:   t.seq.Add(1),
+		ctx:   weak.Make(c),
+		id:    c.id,
+		typ:   c.ctxtype,
+		stack: callStack(2),
+	}
+}
+
+func (t *contextTracker) addView(a refmgmt.Allocatable, ref *finalized.FinalizedRef) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if c := t.contexts[refmgmt.ObjectId(a)]; c != nil {
+		c.views = append(c.views, &trackedView{
+			seq:   t.seq.Add(1),
+			ref:   weak.Make(ref),
+			stack: callStack(3),
+		})
+	}
+}
+
+func (t *contextTracker) Ref(id uint64, name string, count int) {
+	t.event(id, "ref", count)
+}
+
+func (t *contextTracker) Unref(id uint64, name string, count int) {
+	t.event(id, "unref", count)
+}
+
+func (t *contextTracker) event(id uint64, op string, count int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	c := t.contexts[id]
+	if c == nil {
+		return
+	}
+	if count <= 0 {
+		delete(t.contexts, id)
+		return
+	}
+	e := &RefEvent{Op: op, Count: count, Stack: callStack(4)}
+	if len(c.refs) < TRACKING_MAX_REF_EVENTS {
+		c.refs = append(c.refs, e)
+		return
+	}
+	c.refs[c.next] = e
+	c.next = (c.next + 1) % len(c.refs)
+	c.dropped++
+}
+
+func (t *contextTracker) report(mark uint64) []*LiveContext {
+	var list []*LiveContext
+	var bases []*contextBase
+
+	t.lock.Lock()
+	for id, c := range t.contexts {
+		b := c.ctx.Value()
+		if b == nil {
+			delete(t.contexts, id)
+			continue
+		}
+		if c.seq <= mark {
+			continue
+		}
+		l := &LiveContext{
+			Id:          c.id,
+			Type:        c.typ,
+			Sequence:    c.seq,
+			Created:     c.stack,
+			DroppedRefs: c.dropped,
+		}
+		// the oldest event of the ring buffer is found at index next
+		l.Refs = append(append(l.Refs, c.refs[c.next:]...), c.refs[:c.next]...)
+		views := c.views[:0]
+		for _, v := range c.views {
+			if v.ref.Value() != nil {
+				views = append(views, v)
+				l.Views = append(l.Views, &LiveView{Sequence: v.seq, Created: v.stack})
+			}
+		}
+		c.views = views
+		list = append(list, l)
+		bases = append(bases, b)
+	}
+	t.lock.Unlock()
+
+	// refcounts are determined outside the tracker lock, because
+	// the tracker is called by the ref management with its lock held.
+	for i, b := range bases {
+		if m, ok := b.allocatable.(refmgmt.RefMgmt); ok {
+			list[i].RefCount = m.RefCount()
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Sequence < list[j].Sequence })
+	return list
+}
+
+func callStack(skip int) string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(skip+1, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var b strings.Builder
+	for {
+		f, more := frames.Next()
+		if strings.HasPrefix(f.Function, "runtime.") {
+			break
+		}
+		fmt.Fprintf(&b, "%s\n\t%s:%d\n", f.Function, f.File, f.Line)
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
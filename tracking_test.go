@@ -0,0 +1,94 @@
+package ctxmgmt_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	me "github.com/mandelsoft/ctxmgmt"
+	"github.com/mandelsoft/ctxmgmt/attributes"
+	"github.com/mandelsoft/ctxmgmt/credentials"
+	"github.com/mandelsoft/ctxmgmt/testhelper"
+)
+
+var _ = Describe("context tracking", func() {
+	var mark uint64
+
+	BeforeEach(func() {
+		DeferCleanup(me.EnableContextTracking())
+		mark = me.TrackingMark()
+	})
+
+	It("reports live contexts with creation stacks", func() {
+		ctx := attributes.New()
+
+		r := me.LiveContexts(mark)
+		Expect(r.Contexts).To(HaveLen(1))
+		Expect(r.Contexts[0].Id).To(Equal(ctx.GetId()))
+		Expect(r.Contexts[0].RefCount).To(Equal(1))
+		Expect(r.Contexts[0].Created).To(ContainSubstring("tracking_test.go"))
+		Expect(r.Contexts[0].Views).To(HaveLen(1))
+		Expect(r.Contexts[0].Views[0].Created).To(ContainSubstring("tracking_test.go"))
+		Expect(r.String()).To(ContainSubstring("context " + string(ctx.GetId()) + " (refcount 1)"))
+
+		ctx = nil
+		testhelper.ExpectNoLeakedContexts(mark)
+	})
+
+	It("detects leaked persistent views", func() {
+		ctx := credentials.New()
+		leak := me.PersistentContextRef(ctx.ConfigContext())
+		id := leak.GetId()
+		ctx = nil
+
+		r := testhelper.LiveContextsAfterGC(mark)
+		Expect(r.Contexts).To(ContainElement(HaveField("Id", id)))
+		Expect(testhelper.HaveNoLiveContexts().Match(r)).To(BeFalse())
+		Expect(testhelper.HaveNoLiveContexts().FailureMessage(r)).To(ContainSubstring("tracking_test.go"))
+		leak.GetType()
+	})
+
+	It("limits the ref history", func() {
+		ctx := attributes.New()
+		var refs []me.Context
+		for i := 0; i < me.TRACKING_MAX_REF_EVENTS+10; i++ {
+			refs = append(refs, me.PersistentContextRef(ctx.AttributesContext()))
+		}
+
+		r := me.LiveContexts(mark)
+		Expect(r.Contexts).To(HaveLen(1))
+		c := r.Contexts[0]
+		Expect(c.Refs).To(HaveLen(me.TRACKING_MAX_REF_EVENTS))
+		Expect(c.DroppedRefs).To(BeNumerically(">=", 10))
+		Expect(c.Refs[len(c.Refs)-1].Count).To(Equal(c.RefCount))
+		Expect(c.Refs[0].Count).To(Equal(c.RefCount - me.TRACKING_MAX_REF_EVENTS + 1))
+		Expect(r.String()).To(ContainSubstring("earlier ref operations dropped"))
+		Expect(refs).To(HaveLen(me.TRACKING_MAX_REF_EVENTS + 10))
+	})
+
+	It("ignores contexts created before the mark", func() {
+		ctx := attributes.New()
+		Expect(me.LiveContexts(me.TrackingMark())).To(testhelper.HaveNoLiveContexts())
+		ctx.GetType()
+	})
+
+	It("keeps tracking enabled for nested enablements", func() {
+		disable := me.EnableContextTracking()
+		nested := me.EnableContextTracking()
+		nested()
+		nested()
+		Expect(me.IsContextTrackingEnabled()).To(BeTrue())
+		disable()
+		Expect(me.IsContextTrackingEnabled()).To(BeTrue())
+	})
+
+	Context("with test helper", func() {
+		BeforeEach(func() {
+			testhelper.TrackContexts()
+		})
+
+		It("releases temporary contexts", func() {
+			ctx := attributes.New()
+			Expect(me.PersistentContextRef(ctx.AttributesContext())).NotTo(BeNil())
+		})
+	})
+})
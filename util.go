@@ -3,6 +3,11 @@ package ctxmgmt
 import (
 	"context"
 	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/mandelsoft/goutils/errors"
+	"github.com/mandelsoft/goutils/maputils"
 )
 
 // ForContextByKey retrieves the context for a given key to be used for a context.Context.
@@ -43,3 +48,68 @@ func SetupElement[T ElementCopyable[T]](mode BuilderMode, target *T, create Elem
 
 	return nil
 }
+
+// ElementModes maps the names of builder elements to builder modes
+// overriding the general mode used for a context creation.
+type ElementModes map[string]BuilderMode
+
+// With provides a copy of the element modes with an additional
+// mode override for the given element.
+func (m ElementModes) With(name string, mode BuilderMode) ElementModes {
+	n := maps.Clone(m)
+	if n == nil {
+		n = ElementModes{}
+	}
+	n[name] = mode
+	return n
+}
+
+// Get provides the effective mode for an element.
+func (m ElementModes) Get(name string, def BuilderMode) BuilderMode {
+	if mode, ok := m[name]; ok {
+		return mode
+	}
+	return def
+}
+
+// ElementConflict describes an incompatible combination of builder
+// modes for two elements: Element must not be created with Mode, if
+// Other is created with one of the modes given by OtherModes.
+type ElementConflict struct {
+	Element    string
+	Mode       BuilderMode
+	Other      string
+	OtherModes []BuilderMode
+	Reason     string
+}
+
+// Validate checks the element names and modes. Only the given element
+// names are accepted, elements already explicitly set (given by explicit)
+// must not be overridden. The effective modes, based on the general mode,
+// must not match any of the given conflicts. Explicitly set elements are
+// not checked for conflicts.
+func (m ElementModes) Validate(mode BuilderMode, known []string, conflicts []ElementConflict, explicit ...string) error {
+	list := errors.ErrListf("invalid element modes")
+	for _, n := range maputils.OrderedKeys(m) {
+		switch {
+		case !slices.Contains(known, n):
+			list.Add(errors.ErrUnknown("builder element", n))
+		case !m[n].IsValid():
+			list.Add(fmt.Errorf("invalid context creation mode %s for element %q", m[n], n))
+		case slices.Contains(explicit, n):
+			list.Add(fmt.Errorf("mode %s given for explicitly set element %q", m[n], n))
+		}
+	}
+	for _, c := range conflicts {
+		if slices.Contains(explicit, c.Element) || slices.Contains(explicit, c.Other) {
+			continue
+		}
+		if m.Get(c.Element, mode) != c.Mode {
+			continue
+		}
+		if o := m.Get(c.Other, mode); slices.Contains(c.OtherModes, o) {
+			list.Add(fmt.Errorf("mode %s for element %q incompatible with mode %s for element %q: %s", c.Mode, c.Element, o, c.Other, c.Reason))
+		}
+	}
+	return list.Result()
+}
@@ -0,0 +1,247 @@
+// Package cleanup provides a dependency graph for finalizable resources.
+// Resources are registered as named nodes together with the names of the
+// resources they depend on. A resource is always cleaned up before the
+// resources it depends on. Resources without a mutual dependency are
+// cleaned up in the opposite order they have been registered.
+package cleanup
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/mandelsoft/goutils/errors"
+
+	"github.com/mandelsoft/ctxmgmt/utils"
+)
+
+const KIND_CLEANUP_NODE = "cleanup node"
+
+// Function is a function used to clean up a resource.
+type Function func() error
+
+// Graph is a dependency graph for cleanup functions.
+// The zero value is an empty graph ready to use.
+type Graph struct {
+	lock  sync.Mutex
+	seq   int
+	nodes map[string]*node
+}
+
+type node struct {
+	seq      int
+	name     string
+	function Function
+	deps     []string
+}
+
+// Add adds a named cleanup function depending on the given
+// resources. The dependencies need not be registered, yet.
+// Unknown dependencies are ignored for the cleanup order.
+// An error is returned if the name is already in use or
+// the dependencies would introduce a cycle.
+func (g *Graph) Add(name string, f Function, deps ...string) error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if g.nodes == nil {
+		g.nodes = map[string]*node{}
+	}
+	if g.nodes[name] != nil {
+		return errors.ErrAlreadyExists(KIND_CLEANUP_NODE, name)
+	}
+	g.seq++
+	n := &node{seq: g.seq, name: name, function: f}
+	g.nodes[name] = n
+	err := g.addDeps(n, deps)
+	if err != nil {
+		delete(g.nodes, name)
+	}
+	return err
+}
+
+// AddCloser adds a named closer depending on the given resources.
+func (g *Graph) AddCloser(name string, c io.Closer, deps ...string) error {
+	return g.Add(name, c.Close, deps...)
+}
+
+// DependsOn declares additional dependencies for an already
+// registered node.
+func (g *Graph) DependsOn(name string, deps ...string) error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	n := g.nodes[name]
+	if n == nil {
+		return errors.ErrUnknown(KIND_CLEANUP_NODE, name)
+	}
+	return g.addDeps(n, deps)
+}
+
+func (g *Graph) addDeps(n *node, deps []string) error {
+	old := n.deps
+	for _, d := range deps {
+		if !slices.Contains(n.deps, d) {
+			n.deps = append(n.deps, d)
+		}
+	}
+	if cycle := g.cycle(n.name, n.name, nil); cycle != nil {
+		n.deps = old
+		return fmt.Errorf("dependency cycle %s", strings.Join(cycle, "->"))
+	}
+	return nil
+}
+
+// cycle checks whether start is reachable from cur and
+// provides the path, if so.
+func (g *Graph) cycle(start, cur string, path []string) []string {
+	n := g.nodes[cur]
+	if n == nil {
+		return nil
+	}
+	path = append(path, cur)
+	for _, d := range n.deps {
+		if d == start {
+			return append(path, d)
+		}
+		if slices.Contains(path, d) {
+			continue
+		}
+		if c := g.cycle(start, d, path); c != nil {
+			return c
+		}
+	}
+	return nil
+}
+
+// Remove removes a node from the graph without executing it.
+// It can be used for resources already released before the cleanup.
+func (g *Graph) Remove(name string) bool {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if g.nodes[name] == nil {
+		return false
+	}
+	delete(g.nodes, name)
+	return true
+}
+
+// Names provides the names of the registered nodes
+// in registration order.
+func (g *Graph) Names() []string {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	var list []*node
+	for _, n := range g.nodes {
+		list = append(list, n)
+	}
+	slices.SortFunc(list, func(a, b *node) int { return a.seq - b.seq })
+	names := make([]string, len(list))
+	for i, n := range list {
+		names[i] = n.name
+	}
+	return names
+}
+
+// Plan provides the planned cleanup order without
+// executing any cleanup function (dry-run).
+func (g *Graph) Plan() Plan {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	var plan Plan
+	for _, n := range g.order() {
+		plan = append(plan, &Step{Name: n.name, DependsOn: slices.Clone(n.deps)})
+	}
+	return plan
+}
+
+// Cleanup executes all cleanup functions in dependency order.
+// The cleanup of a node is executed even if the cleanup of a node
+// depending on it failed. Executed nodes are removed from the graph.
+// The errors of all failed nodes are aggregated.
+func (g *Graph) Cleanup() error {
+	g.lock.Lock()
+	order := g.order()
+	g.nodes = nil
+	g.lock.Unlock()
+
+	list := errors.ErrListf("cleanup")
+	for _, n := range order {
+		list.Addf(nil, n.function(), "%s", n.name)
+	}
+	return list.Result()
+}
+
+// order determines the cleanup order. Because the graph is acyclic,
+// there is always a node no other remaining node depends on.
+// From those nodes the latest registered one is chosen.
+func (g *Graph) order() []*node {
+	var remaining []*node
+	for _, n := range g.nodes {
+		remaining = append(remaining, n)
+	}
+	slices.SortFunc(remaining, func(a, b *node) int { return b.seq - a.seq })
+
+	var order []*node
+	for len(remaining) > 0 {
+		for i, n := range remaining {
+			if !required(n.name, remaining) {
+				order = append(order, n)
+				remaining = slices.Delete(remaining, i, i+1)
+				break
+			}
+		}
+	}
+	return order
+}
+
+func required(name string, nodes []*node) bool {
+	for _, n := range nodes {
+		if slices.Contains(n.deps, name) {
+			return true
+		}
+	}
+	return false
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Step describes a single step of a cleanup plan.
+type Step struct {
+	Name      string   `json:"name"`
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+// Plan describes the planned cleanup order.
+type Plan []*Step
+
+// Names provides the node names in cleanup order.
+func (p Plan) Names() []string {
+	names := make([]string, len(p))
+	for i, s := range p {
+		names[i] = s.Name
+	}
+	return names
+}
+
+// Print prints a human-readable form of the plan.
+func (p Plan) Print(pr utils.Printer) {
+	for i, s := range p {
+		if len(s.DependsOn) == 0 {
+			pr.Printf("%d: %s\n", i+1, s.Name)
+		} else {
+			pr.Printf("%d: %s (before %s)\n", i+1, s.Name, strings.Join(s.DependsOn, ", "))
+		}
+	}
+}
+
+func (p Plan) String() string {
+	pr, buf := utils.NewBufferedPrinter()
+	p.Print(pr)
+	return buf.String()
+}
@@ -0,0 +1,100 @@
+package cleanup_test
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/ctxmgmt/utils/cleanup"
+)
+
+var _ = Describe("cleanup graph", func() {
+	var g *cleanup.Graph
+	var log []string
+
+	step := func(name string, err ...error) cleanup.Function {
+		return func() error {
+			log = append(log, name)
+			for _, e := range err {
+				return e
+			}
+			return nil
+		}
+	}
+
+	BeforeEach(func() {
+		g = &cleanup.Graph{}
+		log = nil
+	})
+
+	It("cleans up in reverse order without dependencies", func() {
+		Expect(g.Add("a", step("a"))).To(Succeed())
+		Expect(g.Add("b", step("b"))).To(Succeed())
+		Expect(g.Add("c", step("c"))).To(Succeed())
+
+		Expect(g.Plan().Names()).To(Equal([]string{"c", "b", "a"}))
+		Expect(log).To(BeNil())
+		Expect(g.Cleanup()).To(Succeed())
+		Expect(log).To(Equal([]string{"c", "b", "a"}))
+		Expect(g.Names()).To(BeEmpty())
+	})
+
+	It("cleans up dependent nodes first", func() {
+		Expect(g.Add("provider", step("provider"), "cache", "client")).To(Succeed())
+		Expect(g.Add("cache", step("cache"))).To(Succeed())
+		Expect(g.Add("client", step("client"), "cache")).To(Succeed())
+		Expect(g.Add("other", step("other"))).To(Succeed())
+
+		Expect(g.Plan().String()).To(Equal(`1: other
+2: provider (before cache, client)
+3: client (before cache)
+4: cache
+`))
+		Expect(g.Cleanup()).To(Succeed())
+		Expect(log).To(Equal([]string{"other", "provider", "client", "cache"}))
+	})
+
+	It("declares dependencies later", func() {
+		Expect(g.Add("a", step("a"))).To(Succeed())
+		Expect(g.Add("b", step("b"))).To(Succeed())
+		Expect(g.DependsOn("a", "b")).To(Succeed())
+		Expect(g.Plan().Names()).To(Equal([]string{"a", "b"}))
+
+		Expect(g.DependsOn("c", "b")).To(MatchError(`cleanup node "c" is unknown`))
+	})
+
+	It("rejects cycles", func() {
+		Expect(g.Add("a", step("a"), "b")).To(Succeed())
+		Expect(g.Add("b", step("b"), "c")).To(Succeed())
+		Expect(g.Add("c", step("c"), "a")).To(MatchError("dependency cycle c->a->b->c"))
+		Expect(g.Names()).To(Equal([]string{"a", "b"}))
+
+		Expect(g.Add("c", step("c"))).To(Succeed())
+		Expect(g.DependsOn("c", "a")).To(MatchError("dependency cycle c->a->b->c"))
+		Expect(g.Plan().Names()).To(Equal([]string{"a", "b", "c"}))
+	})
+
+	It("rejects duplicate names", func() {
+		Expect(g.Add("a", step("a"))).To(Succeed())
+		Expect(g.Add("a", step("a"))).To(MatchError(`cleanup node "a" already exists`))
+	})
+
+	It("removes nodes", func() {
+		Expect(g.Add("a", step("a"))).To(Succeed())
+		Expect(g.Add("b", step("b"), "a")).To(Succeed())
+		Expect(g.Remove("a")).To(BeTrue())
+		Expect(g.Remove("a")).To(BeFalse())
+		Expect(g.Cleanup()).To(Succeed())
+		Expect(log).To(Equal([]string{"b"}))
+	})
+
+	It("aggregates errors per node", func() {
+		Expect(g.Add("a", step("a", fmt.Errorf("a failed")))).To(Succeed())
+		Expect(g.Add("b", step("b", fmt.Errorf("b failed")), "a")).To(Succeed())
+		Expect(g.Add("c", step("c"), "b")).To(Succeed())
+
+		Expect(g.Cleanup()).To(MatchError("cleanup: {b: b failed, a: a failed}"))
+		Expect(log).To(Equal([]string{"c", "b", "a"}))
+	})
+})
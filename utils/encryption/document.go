@@ -0,0 +1,205 @@
+package encryption
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mandelsoft/goutils/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Documents (YAML or JSON) may contain encrypted values at any
+// place. The plain text of an encrypted value is the YAML
+// representation of the original value, which may be a scalar,
+// a list or a map. If the complete document is encrypted, it
+// consists of a single encrypted string.
+//
+// Fields are described by element paths. Map entries use the path of
+// the enclosing element followed by "." and the key, list entries use
+// the path of the list followed by "[<index>]", for example
+// "configurations[1].consumers[0].credentials". The document itself
+// has the element path "". A "*" matches any map key or list index.
+
+// EncryptDocument encrypts the fields of a document matching the given
+// element paths and provides the resulting document as YAML. Already
+// encrypted fields are kept. It is an error, if a path does not match
+// any field.
+func EncryptDocument(data []byte, key *Key, paths ...string) ([]byte, error) {
+	doc, err := parseDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	var exps []*regexp.Regexp
+	for _, p := range paths {
+		exps = append(exps, pathPattern(p))
+	}
+
+	matched := make([]bool, len(paths))
+	var encrypt func(path string, n *yaml.Node) error
+	encrypt = func(path string, n *yaml.Node) error {
+		for i, e := range exps {
+			if e.MatchString(path) {
+				matched[i] = true
+				return encryptNode(key, n)
+			}
+		}
+		return forChildren(path, n, encrypt)
+	}
+
+	if err := encrypt("", root(doc)); err != nil {
+		return nil, err
+	}
+	var missing []string
+	for i, p := range paths {
+		if !matched[i] {
+			missing = append(missing, fmt.Sprintf("%q", p))
+		}
+	}
+	if len(missing) > 0 {
+		return nil, errors.Newf("no fields found for %s", strings.Join(missing, ", "))
+	}
+	return marshal(doc)
+}
+
+// DecryptDocument decrypts all encrypted values of a document using
+// the keys provided by the given resolver. If the document contains
+// encrypted values, the decrypted document is provided as YAML.
+// Otherwise, the original data is returned.
+func DecryptDocument(data []byte, keys KeyResolver) ([]byte, error) {
+	if !bytes.Contains(data, []byte(prefix)) {
+		return data, nil
+	}
+	doc, err := parseDocument(data)
+	if err != nil {
+		return nil, err
+	}
+
+	modified := false
+	list := errors.ErrListf("decrypting document")
+	var decrypt func(path string, n *yaml.Node) error
+	decrypt = func(path string, n *yaml.Node) error {
+		if !isEncryptedNode(n) {
+			return forChildren(path, n, decrypt)
+		}
+		plain, err := Decrypt(keys, n.Value)
+		if err == nil {
+			var value yaml.Node
+			err = yaml.Unmarshal(plain, &value)
+			if err == nil {
+				*n = *root(&value)
+				modified = true
+				// decrypted values may contain encrypted values, again.
+				return decrypt(path, n)
+			}
+		}
+		if path == "" {
+			path = "document"
+		}
+		list.Add(errors.Wrapf(err, "%s", path))
+		return nil
+	}
+
+	decrypt("", root(doc))
+	if err := list.Result(); err != nil {
+		return nil, err
+	}
+	if !modified {
+		return data, nil
+	}
+	return marshal(doc)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func parseDocument(data []byte) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, errors.Wrapf(err, "invalid document")
+	}
+	return &doc, nil
+}
+
+func root(doc *yaml.Node) *yaml.Node {
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		return doc.Content[0]
+	}
+	return doc
+}
+
+func marshal(doc *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func isEncryptedNode(n *yaml.Node) bool {
+	return n.Kind == yaml.ScalarNode && n.Tag == "!!str" && IsEncrypted(n.Value)
+}
+
+// encryptNode replaces a node by an encrypted string
+// keeping its comments.
+func encryptNode(key *Key, n *yaml.Node) error {
+	if isEncryptedNode(n) {
+		return nil
+	}
+	value := *n
+	value.HeadComment, value.LineComment, value.FootComment = "", "", ""
+	plain, err := yaml.Marshal(&value)
+	if err != nil {
+		return err
+	}
+	enc, err := Encrypt(key, plain)
+	if err != nil {
+		return err
+	}
+	*n = yaml.Node{
+		Kind:        yaml.ScalarNode,
+		Tag:         "!!str",
+		Value:       enc,
+		HeadComment: n.HeadComment,
+		LineComment: n.LineComment,
+		FootComment: n.FootComment,
+	}
+	return nil
+}
+
+func forChildren(path string, n *yaml.Node, f func(path string, n *yaml.Node) error) error {
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if err := f(joinPath(path, n.Content[i].Value), n.Content[i+1]); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for i, e := range n.Content {
+			if err := f(fmt.Sprintf("%s[%d]", path, i), e); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// pathPattern converts an element path with wildcards
+// into a regular expression.
+func pathPattern(path string) *regexp.Regexp {
+	exp := strings.ReplaceAll(regexp.QuoteMeta(path), `\*`, `[^.\[\]]*`)
+	return regexp.MustCompile("^" + exp + "$")
+}
@@ -0,0 +1,112 @@
+package encryption_test
+
+import (
+	"strings"
+
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/yaml"
+
+	me "github.com/mandelsoft/ctxmgmt/utils/encryption"
+)
+
+const document = `# test document
+type: test
+port: 8080
+credentials:
+  - name: alice
+    password: secret # the password
+  - name: bob
+    password: other
+`
+
+var _ = Describe("encryption", func() {
+	var key *me.Key
+
+	BeforeEach(func() {
+		key = Must(me.GenerateKey())
+	})
+
+	parse := func(data []byte) map[string]interface{} {
+		var m map[string]interface{}
+		MustBeSuccessful(yaml.Unmarshal(data, &m))
+		return m
+	}
+
+	Context("keys", func() {
+		It("parses keys", func() {
+			k := Must(me.ParseKey(key.String()))
+			Expect(k).To(Equal(key))
+			Expect(k.ID()).To(HaveLen(16))
+		})
+
+		It("rejects invalid keys", func() {
+			Expect(me.ParseKey("dGVzdA==")).Error().To(MatchError("invalid key size 4 (expected 32 bytes)"))
+		})
+	})
+
+	Context("values", func() {
+		It("encrypts and decrypts values", func() {
+			enc := Must(me.Encrypt(key, []byte("secret")))
+			Expect(me.IsEncrypted(enc)).To(BeTrue())
+			Expect(enc).To(HavePrefix("ENC[secretbox," + key.ID() + ","))
+			Expect(Must(me.KeyID(enc))).To(Equal(key.ID()))
+			Expect(string(Must(me.Decrypt(me.NewKeys(key), enc)))).To(Equal("secret"))
+		})
+
+		It("fails for unknown keys", func() {
+			enc := Must(me.Encrypt(key, []byte("secret")))
+			other := Must(me.GenerateKey())
+			Expect(me.Decrypt(me.NewKeys(other), enc)).Error().To(MatchError(`encryption key "` + key.ID() + `" not found`))
+			Expect(me.Decrypt(me.KeyResolverFunction(func(string) (*me.Key, error) { return other, nil }), enc)).Error().To(MatchError("cannot decrypt value with key " + key.ID()))
+		})
+
+		It("rejects unknown methods", func() {
+			Expect(me.Decrypt(me.NewKeys(key), "ENC[aes,id,data]")).Error().To(MatchError(ContainSubstring("aes")))
+		})
+	})
+
+	Context("documents", func() {
+		It("encrypts selected fields", func() {
+			data := Must(me.EncryptDocument([]byte(document), key, "credentials[*].password", "port"))
+			Expect(string(data)).NotTo(ContainSubstring("password: secret"))
+			Expect(string(data)).To(ContainSubstring("# the password"))
+			Expect(string(data)).To(ContainSubstring("name: alice"))
+			Expect(strings.Count(string(data), "ENC[")).To(Equal(3))
+
+			plain := Must(me.DecryptDocument(data, me.NewKeys(key)))
+			Expect(parse(plain)).To(Equal(parse([]byte(document))))
+		})
+
+		It("encrypts subtrees", func() {
+			data := Must(me.EncryptDocument([]byte(document), key, "credentials"))
+			Expect(parse(data)["credentials"]).To(HavePrefix("ENC["))
+			Expect(parse(Must(me.DecryptDocument(data, me.NewKeys(key))))).To(Equal(parse([]byte(document))))
+		})
+
+		It("encrypts complete documents", func() {
+			data := Must(me.EncryptDocument([]byte(document), key, ""))
+			Expect(strings.TrimSpace(string(data))).To(HavePrefix("ENC["))
+			Expect(parse(Must(me.DecryptDocument(data, me.NewKeys(key))))).To(Equal(parse([]byte(document))))
+		})
+
+		It("keeps encrypted fields", func() {
+			data := Must(me.EncryptDocument([]byte(document), key, "credentials[0].password"))
+			Expect(Must(me.EncryptDocument(data, Must(me.GenerateKey()), "credentials[0].password"))).To(Equal(data))
+		})
+
+		It("rejects unknown fields", func() {
+			Expect(me.EncryptDocument([]byte(document), key, "credentials[2].password", "type")).Error().To(MatchError(`no fields found for "credentials[2].password"`))
+		})
+
+		It("keeps documents without encrypted values", func() {
+			Expect(Must(me.DecryptDocument([]byte(document), me.NewKeys()))).To(Equal([]byte(document)))
+		})
+
+		It("reports fields which cannot be decrypted", func() {
+			data := Must(me.EncryptDocument([]byte(document), key, "credentials[1].password"))
+			Expect(me.DecryptDocument(data, me.NewKeys())).Error().To(MatchError(`decrypting document: credentials[1].password: encryption key "` + key.ID() + `" not found`))
+		})
+	})
+})
@@ -0,0 +1,113 @@
+package encryption
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+
+	"github.com/mandelsoft/goutils/errors"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+)
+
+// KEY_SIZE is the size of a symmetric key in bytes.
+const KEY_SIZE = 32
+
+// KIND_KEY is the error kind used for encryption keys.
+const KIND_KEY = "encryption key"
+
+// Key is a symmetric key used to encrypt values with
+// NaCl secretbox (XSalsa20 and Poly1305).
+type Key [KEY_SIZE]byte
+
+// GenerateKey creates a new random key.
+func GenerateKey() (*Key, error) {
+	var k Key
+	if _, err := rand.Read(k[:]); err != nil {
+		return nil, errors.Wrapf(err, "cannot generate key")
+	}
+	return &k, nil
+}
+
+// ParseKey parses the base64 representation of a key.
+func ParseKey(s string) (*Key, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, errors.ErrInvalidWrap(err, KIND_KEY)
+	}
+	if len(data) != KEY_SIZE {
+		return nil, errors.Newf("invalid key size %d (expected %d bytes)", len(data), KEY_SIZE)
+	}
+	var k Key
+	copy(k[:], data)
+	return &k, nil
+}
+
+// String provides the base64 representation of the key.
+func (k *Key) String() string {
+	return base64.StdEncoding.EncodeToString(k[:])
+}
+
+// ID provides a short identifier for the key, which is
+// stored together with encrypted values to find the key
+// required for the decryption.
+func (k *Key) ID() string {
+	h := sha256.Sum256(k[:])
+	return hex.EncodeToString(h[:8])
+}
+
+// ReadKeyFile reads a key file containing the base64
+// representation of a key.
+func ReadKeyFile(fs vfs.FileSystem, path string) (*Key, error) {
+	data, err := vfs.ReadFile(fs, path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read key file %q", path)
+	}
+	k, err := ParseKey(string(data))
+	if err != nil {
+		return nil, errors.Wrapf(err, "key file %q", path)
+	}
+	return k, nil
+}
+
+// WriteKeyFile writes a key file readable only by the owner.
+func WriteKeyFile(fs vfs.FileSystem, path string, key *Key) error {
+	return vfs.WriteFile(fs, path, []byte(key.String()+"\n"), 0o600)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// KeyResolver provides the key for a key id.
+// If no key is found, nil is returned.
+type KeyResolver interface {
+	GetKey(id string) (*Key, error)
+}
+
+type KeyResolverFunction func(id string) (*Key, error)
+
+func (f KeyResolverFunction) GetKey(id string) (*Key, error) {
+	return f(id)
+}
+
+// Keys is a set of keys indexed by their id.
+type Keys map[string]*Key
+
+var _ KeyResolver = Keys(nil)
+
+// NewKeys creates a key set for the given keys.
+func NewKeys(keys ...*Key) Keys {
+	r := Keys{}
+	r.Add(keys...)
+	return r
+}
+
+func (r Keys) Add(keys ...*Key) {
+	for _, k := range keys {
+		r[k.ID()] = k
+	}
+}
+
+func (r Keys) GetKey(id string) (*Key, error) {
+	return r[id], nil
+}
@@ -0,0 +1,88 @@
+package encryption
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+
+	"github.com/mandelsoft/goutils/errors"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+// METHOD_SECRETBOX is the method name for values encrypted
+// with NaCl secretbox.
+const METHOD_SECRETBOX = "secretbox"
+
+const (
+	prefix = "ENC["
+	suffix = "]"
+)
+
+const nonceSize = 24
+
+// Encrypt encrypts data with a key. The result has the format
+// ENC[secretbox,<key id>,<base64 of nonce and sealed data>].
+func Encrypt(key *Key, data []byte) (string, error) {
+	var nonce [nonceSize]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return "", errors.Wrapf(err, "cannot generate nonce")
+	}
+	sealed := secretbox.Seal(nonce[:], data, &nonce, (*[KEY_SIZE]byte)(key))
+	return prefix + METHOD_SECRETBOX + "," + key.ID() + "," + base64.StdEncoding.EncodeToString(sealed) + suffix, nil
+}
+
+// IsEncrypted checks whether a string is an encrypted value.
+func IsEncrypted(s string) bool {
+	return strings.HasPrefix(s, prefix) && strings.HasSuffix(s, suffix)
+}
+
+// KeyID provides the id of the key required to decrypt
+// an encrypted value.
+func KeyID(s string) (string, error) {
+	_, id, _, err := parse(s)
+	return id, err
+}
+
+// Decrypt decrypts an encrypted value with the
+// appropriate key provided by the given resolver.
+func Decrypt(keys KeyResolver, s string) ([]byte, error) {
+	_, id, sealed, err := parse(s)
+	if err != nil {
+		return nil, err
+	}
+	key, err := keys.GetKey(id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "key %s", id)
+	}
+	if key == nil {
+		return nil, errors.ErrNotFound(KIND_KEY, id)
+	}
+	var nonce [nonceSize]byte
+	copy(nonce[:], sealed)
+	data, ok := secretbox.Open(nil, sealed[nonceSize:], &nonce, (*[KEY_SIZE]byte)(key))
+	if !ok {
+		return nil, errors.Newf("cannot decrypt value with key %s", id)
+	}
+	return data, nil
+}
+
+func parse(s string) (string, string, []byte, error) {
+	if !IsEncrypted(s) {
+		return "", "", nil, errors.Newf("no encrypted value")
+	}
+	fields := strings.Split(s[len(prefix):len(s)-len(suffix)], ",")
+	if len(fields) != 3 {
+		return "", "", nil, errors.Newf("invalid encrypted value: expected method, key id and data")
+	}
+	if fields[0] != METHOD_SECRETBOX {
+		return "", "", nil, errors.ErrNotSupported("encryption method", fields[0])
+	}
+	sealed, err := base64.StdEncoding.DecodeString(fields[2])
+	if err != nil {
+		return "", "", nil, errors.Wrapf(err, "invalid encrypted value")
+	}
+	if len(sealed) < nonceSize+secretbox.Overhead {
+		return "", "", nil, errors.Newf("invalid encrypted value: data too short")
+	}
+	return fields[0], fields[1], sealed, nil
+}
@@ -0,0 +1,144 @@
+package jsonschema
+
+import (
+	"encoding"
+	"encoding/json"
+	"maps"
+	"reflect"
+	"strings"
+
+	"github.com/mandelsoft/goutils/generics"
+)
+
+var (
+	typeJSONMarshaler   = generics.TypeOf[json.Marshaler]()
+	typeJSONUnmarshaler = generics.TypeOf[json.Unmarshaler]()
+	typeTextMarshaler   = generics.TypeOf[encoding.TextMarshaler]()
+	typeTextUnmarshaler = generics.TypeOf[encoding.TextUnmarshaler]()
+)
+
+// For derives a schema for the serialized form of the Go type T.
+func For[T any]() *Schema {
+	return ForType(generics.TypeOf[T]())
+}
+
+// References maps Go types to schemas used instead of derived ones.
+// It is typically used to refer to the definitions of a schema
+// document for types with a custom JSON serialization.
+type References map[reflect.Type]*Schema
+
+// ForType derives a schema for the serialized form of the given Go type
+// based on the json field tags.
+// Types with a custom JSON serialization are described by an
+// unrestricted schema, types with a custom text serialization by
+// a string schema, if no schema is given by the references.
+// Derived object schemas reject unknown properties.
+func ForType(t reflect.Type, refs ...References) *Schema {
+	d := &deriver{active: map[reflect.Type]bool{}, refs: References{}}
+	for _, r := range refs {
+		maps.Copy(d.refs, r)
+	}
+	return d.schema(t)
+}
+
+type deriver struct {
+	active map[reflect.Type]bool
+	refs   References
+}
+
+func implements(t reflect.Type, i ...reflect.Type) bool {
+	for _, e := range i {
+		if t.Implements(e) || (t.Kind() != reflect.Pointer && reflect.PointerTo(t).Implements(e)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *deriver) schema(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+	if s := d.refs[t]; s != nil {
+		return s.Copy()
+	}
+	if t.Kind() == reflect.Pointer && d.refs[t.Elem()] != nil {
+		return d.schema(t.Elem())
+	}
+	if implements(t, typeJSONMarshaler, typeJSONUnmarshaler) {
+		return &Schema{}
+	}
+	if implements(t, typeTextMarshaler, typeTextUnmarshaler) {
+		return &Schema{Type: TYPE_STRING}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: TYPE_BOOLEAN}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: TYPE_INTEGER}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: TYPE_NUMBER}
+	case reflect.String:
+		return &Schema{Type: TYPE_STRING}
+	case reflect.Pointer:
+		s := d.schema(t.Elem())
+		if !s.IsAny() && s.Ref == "" {
+			s.Nullable = true
+		}
+		return s
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: TYPE_STRING, Nullable: true}
+		}
+		return &Schema{Type: TYPE_ARRAY, Items: d.schema(t.Elem()), Nullable: true}
+	case reflect.Array:
+		return &Schema{Type: TYPE_ARRAY, Items: d.schema(t.Elem())}
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return &Schema{}
+		}
+		return &Schema{Type: TYPE_OBJECT, AdditionalProperties: d.schema(t.Elem()), Nullable: true}
+	case reflect.Struct:
+		if d.active[t] {
+			return &Schema{}
+		}
+		d.active[t] = true
+		defer delete(d.active, t)
+
+		s := &Schema{Type: TYPE_OBJECT, Properties: map[string]*Schema{}, AdditionalProperties: False()}
+		d.fields(s, t)
+		return s
+	default:
+		return &Schema{}
+	}
+}
+
+func (d *deriver) fields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && (name == "" || strings.Contains(","+opts+",", ",inline,")) {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct && !implements(ft, typeJSONMarshaler, typeJSONUnmarshaler) {
+				d.fields(s, ft)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s.Properties[name] = d.schema(f.Type)
+	}
+}
@@ -0,0 +1,207 @@
+// Package jsonschema provides a minimal subset of JSON schema
+// sufficient to describe and validate the serialized form of
+// attribute and configuration values.
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// DRAFT is the JSON schema dialect used for schema documents.
+const DRAFT = "https://json-schema.org/draft/2020-12/schema"
+
+// DEFS_PREFIX is the prefix of references to the
+// definitions of a schema document.
+const DEFS_PREFIX = "#/$defs/"
+
+const (
+	TYPE_STRING  = "string"
+	TYPE_INTEGER = "integer"
+	TYPE_NUMBER  = "number"
+	TYPE_BOOLEAN = "boolean"
+	TYPE_OBJECT  = "object"
+	TYPE_ARRAY   = "array"
+	TYPE_NULL    = "null"
+)
+
+// Schema describes a JSON value.
+// An empty schema (no Type) accepts any value.
+type Schema struct {
+	Schema string `json:"$schema,omitempty"`
+	// Ref refers to a schema of the Defs of the root schema
+	// in the form #/$defs/<name>.
+	Ref         string             `json:"$ref,omitempty"`
+	Defs        map[string]*Schema `json:"$defs,omitempty"`
+	Title       string             `json:"title,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Type        string             `json:"type,omitempty"`
+	// Nullable allows a null value in addition to the given type.
+	// It is serialized as type list [<type>, "null"].
+	Nullable bool `json:"-"`
+
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+
+	Enum    []interface{} `json:"enum,omitempty"`
+	Pattern string        `json:"pattern,omitempty"`
+	Minimum *float64      `json:"minimum,omitempty"`
+	Maximum *float64      `json:"maximum,omitempty"`
+	OneOf   []*Schema     `json:"oneOf,omitempty"`
+
+	reject bool
+}
+
+// False provides a schema rejecting any value.
+// It is typically used as AdditionalProperties
+// to forbid unknown properties.
+func False() *Schema {
+	return &Schema{reject: true}
+}
+
+// IsFalse returns true if the schema rejects any value.
+func (s *Schema) IsFalse() bool {
+	return s != nil && s.reject
+}
+
+type schema Schema
+
+// schemaDoc is the serialization of a schema.
+// Its type may be a single type or a list of types.
+type schemaDoc struct {
+	*schema
+	Type interface{} `json:"type,omitempty"`
+}
+
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	if s.reject {
+		return []byte("false"), nil
+	}
+	doc := schemaDoc{schema: (*schema)(s)}
+	switch {
+	case s.Type == "":
+	case s.Nullable && s.Type != TYPE_NULL:
+		doc.Type = []string{s.Type, TYPE_NULL}
+	default:
+		doc.Type = s.Type
+	}
+	return json.Marshal(doc)
+}
+
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	switch string(bytes.TrimSpace(data)) {
+	case "true":
+		*s = Schema{}
+		return nil
+	case "false":
+		*s = Schema{reject: true}
+		return nil
+	}
+	*s = Schema{}
+	doc := schemaDoc{schema: (*schema)(s)}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	return s.setType(doc.Type)
+}
+
+// setType sets the type from its serialized form. Only
+// a single type, optionally combined with null, is supported.
+func (s *Schema) setType(t interface{}) error {
+	switch v := t.(type) {
+	case nil:
+	case string:
+		s.Type = v
+	case []interface{}:
+		for _, e := range v {
+			n, ok := e.(string)
+			if !ok {
+				return fmt.Errorf("invalid schema type %v", e)
+			}
+			switch {
+			case n == TYPE_NULL && len(v) > 1:
+				s.Nullable = true
+			case s.Type == "":
+				s.Type = n
+			default:
+				return fmt.Errorf("unsupported schema type list %v", v)
+			}
+		}
+	default:
+		return fmt.Errorf("invalid schema type %v", t)
+	}
+	return nil
+}
+
+// Parse parses a JSON or YAML schema document.
+func Parse(data []byte) (*Schema, error) {
+	var s Schema
+	err := yaml.Unmarshal(data, &s)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// MustParse parses a schema document and panics
+// if it is invalid.
+func MustParse(data string) *Schema {
+	s, err := Parse([]byte(data))
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// IsAny returns true if the schema accepts any value.
+func (s *Schema) IsAny() bool {
+	return s == nil || (!s.reject && s.Type == "" && s.Ref == "" && len(s.Enum) == 0 && len(s.OneOf) == 0)
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+var pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// RefTo provides a schema referring to the definition
+// with the given name. The name is escaped according
+// to the JSON pointer syntax.
+func RefTo(name string) *Schema {
+	return &Schema{Ref: DEFS_PREFIX + pointerEscaper.Replace(name)}
+}
+
+// OneOf provides a schema accepting exactly one of
+// the given schemas.
+func OneOf(schemas ...*Schema) *Schema {
+	return &Schema{OneOf: schemas}
+}
+
+// Copy provides a shallow copy of the schema with
+// separate properties.
+func (s *Schema) Copy() *Schema {
+	if s == nil {
+		return nil
+	}
+	c := *s
+	if s.Properties != nil {
+		c.Properties = maps.Clone(s.Properties)
+	}
+	c.Required = slices.Clone(s.Required)
+	return &c
+}
+
+// AsJSON renders the schema as JSON document.
+func (s *Schema) AsJSON() ([]byte, error) {
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// AsYAML renders the schema as YAML document.
+func (s *Schema) AsYAML() ([]byte, error) {
+	return yaml.Marshal(s)
+}
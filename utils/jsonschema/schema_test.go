@@ -0,0 +1,137 @@
+package jsonschema_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/ctxmgmt/utils/jsonschema"
+)
+
+type Base struct {
+	Kind string `json:"kind"`
+}
+
+type Spec struct {
+	Base     `json:",inline"`
+	Name     string            `json:"name"`
+	Count    int               `json:"count,omitempty"`
+	Enabled  *bool             `json:"enabled,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Items    []Item            `json:"items,omitempty"`
+	Raw      json.RawMessage   `json:"raw,omitempty"`
+	Time     time.Time         `json:"time,omitempty"`
+	Ignored  string            `json:"-"`
+	internal string
+}
+
+type Item struct {
+	Value float64 `json:"value"`
+}
+
+var _ = Describe("json schema", func() {
+	Context("derivation", func() {
+		It("derives struct schema", func() {
+			s := jsonschema.For[Spec]()
+			Expect(string(Must(json.Marshal(s)))).To(MatchJSON(`{
+  "type": "object",
+  "additionalProperties": false,
+  "properties": {
+    "kind": {"type": "string"},
+    "name": {"type": "string"},
+    "count": {"type": "integer"},
+    "enabled": {"type": ["boolean", "null"]},
+    "labels": {"type": ["object", "null"], "additionalProperties": {"type": "string"}},
+    "items": {"type": ["array", "null"], "items": {
+      "type": "object", "additionalProperties": false, "properties": {"value": {"type": "number"}}
+    }},
+    "raw": {},
+    "time": {}
+  }
+}`))
+		})
+
+		It("uses references", func() {
+			s := jsonschema.ForType(reflect.TypeFor[Spec](), jsonschema.References{
+				reflect.TypeFor[Item](): jsonschema.RefTo("item/v1"),
+			})
+			Expect(s.Properties["items"].Items).To(Equal(&jsonschema.Schema{Ref: "#/$defs/item~1v1"}))
+		})
+
+		It("parses nullable types", func() {
+			s := Must(jsonschema.Parse([]byte("type: [string, \"null\"]\n")))
+			Expect(s).To(Equal(&jsonschema.Schema{Type: jsonschema.TYPE_STRING, Nullable: true}))
+			Expect(s.Validate([]byte("null"))).To(Succeed())
+			Expect(string(Must(json.Marshal(s)))).To(Equal(`{"type":["string","null"]}`))
+
+			_, err := jsonschema.Parse([]byte("type: [string, integer]\n"))
+			Expect(err).To(MatchError(ContainSubstring("unsupported schema type list")))
+		})
+
+		It("parses schema with boolean sub schema", func() {
+			s := Must(jsonschema.Parse([]byte("type: object\nadditionalProperties: false\n")))
+			Expect(s.AdditionalProperties.IsFalse()).To(BeTrue())
+		})
+	})
+
+	Context("validation", func() {
+		s := jsonschema.For[Spec]()
+
+		It("accepts valid document", func() {
+			Expect(s.Validate([]byte("kind: test\nname: alice\ncount: 3\nitems:\n- value: 1.5\n"))).To(Succeed())
+		})
+
+		It("reports error paths", func() {
+			err := s.Validate([]byte("name: 5\ncount: 1.5\nitems:\n- value: x\n- other: 1\nunknown: true\n"))
+			Expect(err).To(HaveOccurred())
+			var errs jsonschema.ValidationErrors
+			Expect(err).To(BeAssignableToTypeOf(errs))
+			errs = err.(jsonschema.ValidationErrors)
+			Expect(errs).To(Equal(jsonschema.ValidationErrors{
+				{Path: "count", Message: "expected integer, but found 1.5"},
+				{Path: "items[0].value", Message: "expected number, but found string"},
+				{Path: "items[1].other", Message: "unknown property"},
+				{Path: "name", Message: "expected string, but found number"},
+				{Path: "unknown", Message: "unknown property"},
+			}))
+		})
+
+		It("checks required, enum and pattern", func() {
+			s := jsonschema.MustParse(`
+type: object
+required: [mode]
+properties:
+  mode:
+    type: string
+    enum: [a, b]
+  host:
+    type: string
+    pattern: "^[a-z]+$"
+`)
+			Expect(s.Validate([]byte(`{"mode": "a", "host": "local"}`))).To(Succeed())
+			Expect(s.Validate([]byte(`{"mode": "c", "host": "Local"}`))).To(MatchError(
+				`schema validation failed: host: value "Local" does not match pattern "^[a-z]+$", mode: value c not in [a b]`))
+			Expect(s.Validate([]byte(`{}`))).To(MatchError(`schema validation failed: required property "mode" missing`))
+		})
+
+		It("resolves references", func() {
+			s := jsonschema.ForType(reflect.TypeFor[Spec](), jsonschema.References{
+				reflect.TypeFor[Item](): jsonschema.RefTo("item/v1"),
+			})
+			s.Defs = map[string]*jsonschema.Schema{
+				"item/v1": jsonschema.MustParse("type: object\nrequired: [value]\n"),
+			}
+			Expect(s.Validate([]byte("kind: test\nitems:\n- value: x\n"))).To(Succeed())
+			Expect(s.Validate([]byte("kind: test\nitems:\n- other: x\n"))).To(MatchError(
+				`schema validation failed: items[0]: required property "value" missing`))
+
+			s.Defs = nil
+			Expect(s.Validate([]byte("kind: test\nitems:\n- value: x\n"))).To(MatchError(
+				`schema validation failed: items[0]: unknown schema reference "#/$defs/item~1v1"`))
+		})
+	})
+})
@@ -0,0 +1,238 @@
+package jsonschema
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// ValidationError describes a single schema violation.
+// The Path describes the location of the violating element
+// in the validated document, for example spec.items[2].name.
+// It is empty for the document root.
+type ValidationError struct {
+	Path    string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// ValidationErrors is the list of schema violations
+// found for a document.
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, v := range e {
+		msgs[i] = v.Error()
+	}
+	return "schema validation failed: " + strings.Join(msgs, ", ")
+}
+
+// Validate validates a JSON or YAML document against the schema.
+// It returns ValidationErrors if the document does not match the schema.
+func (s *Schema) Validate(data []byte) error {
+	var v interface{}
+	err := yaml.Unmarshal(data, &v)
+	if err != nil {
+		return err
+	}
+	return s.ValidateValue(v)
+}
+
+// ValidateValue validates a generic JSON value, as provided
+// by json.Unmarshal into an interface{}, against the schema.
+func (s *Schema) ValidateValue(v interface{}) error {
+	var errs ValidationErrors
+	s.validate(s, "", v, &errs)
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// resolve resolves a reference to a definition of the root schema.
+func (s *Schema) resolve(ref string) *Schema {
+	name, ok := strings.CutPrefix(ref, DEFS_PREFIX)
+	if !ok {
+		return nil
+	}
+	return s.Defs[pointerUnescaper.Replace(name)]
+}
+
+func (s *Schema) validate(root *Schema, path string, v interface{}, errs *ValidationErrors) {
+	if s == nil {
+		return
+	}
+	add := func(msg string, args ...interface{}) {
+		*errs = append(*errs, &ValidationError{Path: path, Message: fmt.Sprintf(msg, args...)})
+	}
+	if s.reject {
+		add("not allowed")
+		return
+	}
+
+	if s.Ref != "" {
+		r := root.resolve(s.Ref)
+		if r == nil {
+			add("unknown schema reference %q", s.Ref)
+			return
+		}
+		r.validate(root, path, v, errs)
+	}
+
+	if len(s.OneOf) > 0 {
+		matched := 0
+		for _, o := range s.OneOf {
+			var sub ValidationErrors
+			o.validate(root, path, v, &sub)
+			if len(sub) == 0 {
+				matched++
+			}
+		}
+		if matched != 1 {
+			add("must match exactly one of %d alternatives (matched %d)", len(s.OneOf), matched)
+		}
+	}
+
+	if len(s.Enum) > 0 {
+		found := false
+		for _, e := range s.Enum {
+			if equal(e, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			add("value %v not in %v", v, s.Enum)
+		}
+	}
+
+	if v == nil {
+		if s.Type != "" && s.Type != TYPE_NULL && !s.Nullable {
+			add("expected %s, but found null", s.Type)
+		}
+		return
+	}
+
+	switch s.Type {
+	case "":
+	case TYPE_NULL:
+		add("expected null, but found %s", kind(v))
+	case TYPE_STRING:
+		str, ok := v.(string)
+		if !ok {
+			add("expected string, but found %s", kind(v))
+			return
+		}
+		if s.Pattern != "" {
+			exp, err := regexp.Compile(s.Pattern)
+			if err != nil {
+				add("invalid pattern %q: %s", s.Pattern, err)
+			} else if !exp.MatchString(str) {
+				add("value %q does not match pattern %q", str, s.Pattern)
+			}
+		}
+	case TYPE_BOOLEAN:
+		if _, ok := v.(bool); !ok {
+			add("expected boolean, but found %s", kind(v))
+		}
+	case TYPE_INTEGER, TYPE_NUMBER:
+		n, ok := v.(float64)
+		if !ok {
+			add("expected %s, but found %s", s.Type, kind(v))
+			return
+		}
+		if s.Type == TYPE_INTEGER && n != math.Trunc(n) {
+			add("expected integer, but found %v", n)
+		}
+		if s.Minimum != nil && n < *s.Minimum {
+			add("value %v is less than minimum %v", n, *s.Minimum)
+		}
+		if s.Maximum != nil && n > *s.Maximum {
+			add("value %v is greater than maximum %v", n, *s.Maximum)
+		}
+	case TYPE_ARRAY:
+		list, ok := v.([]interface{})
+		if !ok {
+			add("expected array, but found %s", kind(v))
+			return
+		}
+		for i, e := range list {
+			s.Items.validate(root, fmt.Sprintf("%s[%d]", path, i), e, errs)
+		}
+	case TYPE_OBJECT:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			add("expected object, but found %s", kind(v))
+			return
+		}
+		for _, r := range s.Required {
+			if _, ok := m[r]; !ok {
+				add("required property %q missing", r)
+			}
+		}
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			sub := join(path, k)
+			if p, ok := s.Properties[k]; ok {
+				p.validate(root, sub, m[k], errs)
+				continue
+			}
+			if s.AdditionalProperties.IsFalse() {
+				*errs = append(*errs, &ValidationError{Path: sub, Message: "unknown property"})
+				continue
+			}
+			s.AdditionalProperties.validate(root, sub, m[k], errs)
+		}
+	default:
+		add("unknown schema type %q", s.Type)
+	}
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func kind(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return TYPE_NULL
+	case string:
+		return TYPE_STRING
+	case bool:
+		return TYPE_BOOLEAN
+	case float64:
+		return TYPE_NUMBER
+	case []interface{}:
+		return TYPE_ARRAY
+	case map[string]interface{}:
+		return TYPE_OBJECT
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+func equal(a, b interface{}) bool {
+	if n, ok := a.(int); ok {
+		a = float64(n)
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -45,6 +45,7 @@ type RefMgmt interface {
 
 type refMgmt struct {
 	lock     sync.Mutex
+	id       uint64
 	refcount int
 	closed   bool
 	before   []CleanupHandler
@@ -59,7 +60,7 @@ func NewAllocatable(cleanup func() error, unused ...bool) RefMgmt {
 			n = 0
 		}
 	}
-	return &refMgmt{refcount: n, cleanup: cleanup, name: "object"}
+	return &refMgmt{id: objects.Add(1), refcount: n, cleanup: cleanup, name: "object"}
 }
 
 func (c *refMgmt) WithName(name string) RefMgmt {
@@ -81,6 +82,9 @@ func (c *refMgmt) Ref() error {
 	}
 	c.refcount++
 	AllocLog.Trace("ref", "name", c.name, "refcnt", c.refcount)
+	if t := getTracker(); t != nil {
+		t.Ref(c.id, c.name, c.refcount)
+	}
 	return nil
 }
 
@@ -95,6 +99,9 @@ func (c *refMgmt) Unref() error {
 
 	c.refcount--
 	AllocLog.Trace("unref", "name", c.name, "refcnt", c.refcount)
+	if t := getTracker(); t != nil {
+		t.Unref(c.id, c.name, c.refcount)
+	}
 	if c.refcount <= 0 {
 		for _, f := range c.before {
 			f.Cleanup()
@@ -140,6 +147,9 @@ func (c *refMgmt) UnrefLast() error {
 
 	c.refcount--
 	AllocLog.Trace("unref last", "name", c.name, "refcnt", c.refcount)
+	if t := getTracker(); t != nil {
+		t.Unref(c.id, c.name, c.refcount)
+	}
 	if c.refcount <= 0 {
 		for _, f := range c.before {
 			f.Cleanup()
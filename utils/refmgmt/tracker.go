@@ -0,0 +1,56 @@
+package refmgmt
+
+import (
+	"sync/atomic"
+)
+
+// Tracker can be set to observe the reference counting of all
+// objects created by NewAllocatable (see SetTracker).
+// It is intended for debugging purposes, only.
+// The methods are called with the new reference count,
+// a count of zero indicates the cleanup of the object.
+type Tracker interface {
+	Ref(id uint64, name string, count int)
+	Unref(id uint64, name string, count int)
+}
+
+type trackerHolder struct {
+	tracker Tracker
+}
+
+var (
+	tracker atomic.Pointer[trackerHolder]
+	objects atomic.Uint64
+)
+
+// SetTracker sets the actual tracker and returns the previous one.
+// A nil tracker disables the tracking.
+func SetTracker(t Tracker) Tracker {
+	var old *trackerHolder
+	if t == nil {
+		old = tracker.Swap(nil)
+	} else {
+		old = tracker.Swap(&trackerHolder{t})
+	}
+	if old == nil {
+		return nil
+	}
+	return old.tracker
+}
+
+func getTracker() Tracker {
+	if h := tracker.Load(); h != nil {
+		return h.tracker
+	}
+	return nil
+}
+
+// ObjectId provides the tracking id of an Allocatable
+// created by NewAllocatable.
+// It returns 0 for other implementations.
+func ObjectId(a Allocatable) uint64 {
+	if r, ok := a.(*refMgmt); ok {
+		return r.id
+	}
+	return 0
+}
@@ -0,0 +1,48 @@
+package refmgmt_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/ctxmgmt/utils/refmgmt"
+)
+
+type event struct {
+	op    string
+	id    uint64
+	count int
+}
+
+type tracker struct {
+	events []event
+}
+
+func (t *tracker) Ref(id uint64, name string, count int) {
+	t.events = append(t.events, event{"ref", id, count})
+}
+
+func (t *tracker) Unref(id uint64, name string, count int) {
+	t.events = append(t.events, event{"unref", id, count})
+}
+
+var _ = Describe("tracker", func() {
+	It("observes ref counting", func() {
+		t := &tracker{}
+		old := refmgmt.SetTracker(t)
+		defer refmgmt.SetTracker(old)
+
+		a := refmgmt.NewAllocatable(nil)
+		id := refmgmt.ObjectId(a)
+		Expect(id).NotTo(BeZero())
+
+		Expect(a.Ref()).To(Succeed())
+		Expect(a.Unref()).To(Succeed())
+		Expect(a.UnrefLast()).To(Succeed())
+
+		Expect(t.events).To(Equal([]event{
+			{"ref", id, 2},
+			{"unref", id, 1},
+			{"unref", id, 0},
+		}))
+	})
+})
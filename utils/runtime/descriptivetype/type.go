@@ -2,6 +2,7 @@ package descriptivetype
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 
 	"github.com/mandelsoft/ctxmgmt/utils/runtime"
@@ -174,6 +175,10 @@ func (t *TypedObjectTypeObject[T]) Format() string {
 	return t.format
 }
 
+func (t *TypedObjectTypeObject[T]) SerializedType() reflect.Type {
+	return runtime.SerializedType(t.VersionedTypedObjectType)
+}
+
 func (t *TypedObjectTypeObject[T]) Validate(e T) error {
 	if t.validator == nil {
 		return nil
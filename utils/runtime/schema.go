@@ -0,0 +1,108 @@
+package runtime
+
+import (
+	"reflect"
+	"slices"
+
+	"github.com/mandelsoft/ctxmgmt/utils/jsonschema"
+)
+
+// SerializedTypeProvider is implemented by decoders and type objects
+// knowing the Go type used for the serialized form of their objects.
+// It is used to derive a JSON schema for a type.
+type SerializedTypeProvider interface {
+	SerializedType() reflect.Type
+}
+
+// SchemaProvider is an optional interface a type object can implement
+// to provide a JSON schema for the serialized form of its objects
+// instead of a derived one.
+type SchemaProvider interface {
+	JSONSchema() *jsonschema.Schema
+}
+
+// SerializedType provides the Go type used for the serialized form of
+// the objects of a decoder or type object, if it is known.
+func SerializedType(o interface{}) reflect.Type {
+	if p, ok := o.(SerializedTypeProvider); ok {
+		return p.SerializedType()
+	}
+	return nil
+}
+
+// TypeSchema provides a JSON schema for the serialized form of the objects
+// of a registered type. If the type object does not provide an explicit
+// schema (see SchemaProvider), it is derived from the Go type of the
+// serialized form (see SerializedTypeProvider). The type field is
+// restricted to the given type name. Types with an unknown serialized
+// form are described by an object schema only restricting the type field.
+// The references are used for nested types with a custom serialization,
+// for example nested generic typed objects.
+func TypeSchema(name string, t interface{}, refs ...jsonschema.References) *jsonschema.Schema {
+	var s *jsonschema.Schema
+	if p, ok := t.(SchemaProvider); ok {
+		s = p.JSONSchema().Copy()
+	} else if st := SerializedType(t); st != nil {
+		s = jsonschema.ForType(st, refs...)
+		s.Nullable = false
+	}
+	if s == nil || s.IsAny() {
+		s = &jsonschema.Schema{Type: jsonschema.TYPE_OBJECT}
+	}
+	if s.Type != jsonschema.TYPE_OBJECT {
+		return s
+	}
+	s.Title = name
+	if d, ok := t.(interface{ Description() string }); ok && s.Description == "" {
+		s.Description = d.Description()
+	}
+	if s.Properties == nil {
+		s.Properties = map[string]*jsonschema.Schema{}
+	}
+	s.Properties["type"] = &jsonschema.Schema{Type: jsonschema.TYPE_STRING, Enum: []interface{}{name}}
+	if !slices.Contains(s.Required, "type") {
+		s.Required = append([]string{"type"}, s.Required...)
+	}
+	return s
+}
+
+// Schema provides the JSON schema for the given type name.
+// It returns nil, if the type is unknown.
+func (t KnownTypes[T, R]) Schema(name string, refs ...jsonschema.References) *jsonschema.Schema {
+	ty, ok := t[name]
+	if !ok {
+		return nil
+	}
+	return TypeSchema(name, ty, refs...)
+}
+
+// Schemas provides the JSON schemas for all known types.
+func (t KnownTypes[T, R]) Schemas(refs ...jsonschema.References) map[string]*jsonschema.Schema {
+	schemas := map[string]*jsonschema.Schema{}
+	for n, ty := range t {
+		schemas[n] = TypeSchema(n, ty, refs...)
+	}
+	return schemas
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func (d *DirectDecoder[T]) SerializedType() reflect.Type {
+	return d.proto
+}
+
+func (c *formatVersion[T, I, V]) SerializedType() reflect.Type {
+	return SerializedType(c.decoder)
+}
+
+func (c *caster[T, I]) SerializedType() reflect.Type {
+	return SerializedType(c.version)
+}
+
+func (t *versionedTypedObjectType[T]) SerializedType() reflect.Type {
+	return SerializedType(t._FormatVersion)
+}
+
+func (t *typeObject[T]) SerializedType() reflect.Type {
+	return SerializedType(t._TypedObjectDecoder)
+}
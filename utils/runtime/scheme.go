@@ -8,6 +8,7 @@ import (
 	"sync"
 
 	"github.com/mandelsoft/ctxmgmt/utils/errkind"
+	"github.com/mandelsoft/ctxmgmt/utils/jsonschema"
 	"github.com/mandelsoft/goutils/errors"
 	"github.com/mandelsoft/goutils/general"
 	"github.com/mandelsoft/goutils/generics"
@@ -145,6 +146,10 @@ type (
 		Convert(object TypedObject) (T, error)
 		GetDecoder(otype string) R
 		EnforceDecode(data []byte, unmarshaler Unmarshaler) (T, error)
+
+		// Schemas provides JSON schemas for the serialized
+		// forms of all known types (see TypeSchema).
+		Schemas(refs ...jsonschema.References) map[string]*jsonschema.Schema
 	}
 	_Scheme[T TypedObject, R TypedObjectDecoder[T]] interface { // cannot omit nesting, because Goland does not accept it
 		Scheme[T, R]
@@ -238,6 +243,10 @@ func (d *defaultScheme[T, R]) KnownTypes() KnownTypes[T, R] {
 	return kt
 }
 
+func (d *defaultScheme[T, R]) Schemas(refs ...jsonschema.References) map[string]*jsonschema.Schema {
+	return d.KnownTypes().Schemas(refs...)
+}
+
 // KnownTypeNames return a sorted list of known type names.
 func (d *defaultScheme[T, R]) KnownTypeNames() []string {
 	d.lock.RLock()